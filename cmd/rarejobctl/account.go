@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+	"github.com/musaprg/rarejobctl/notify"
+	"go.uber.org/zap"
+)
+
+// runAccount dispatches the subcommand of account.
+func runAccount(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: subcommand of account is required (status)", errInvalidConfig)
+	}
+	switch args[0] {
+	case "status":
+		return runAccountStatus(ctx, args[1:])
+	default:
+		return fmt.Errorf("%w: unknown subcommand of account: %s", errInvalidConfig, args[0])
+	}
+}
+
+// runAccountStatus shows the plan and the lesson tickets, and notifies if some tickets are about to expire unused.
+func runAccountStatus(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("account status", flag.ContinueOnError)
+	warnWithin := fs.Duration("warn-within", 72*time.Hour, "notify if lesson tickets left unused expire within the duration, 0 to disable")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+
+	opts, err := newClientOpts()
+	if err != nil {
+		return err
+	}
+	rc, err := librarejob.NewClient(opts)
+	if err != nil {
+		return fmt.Errorf("failed to create rarejob client: %w", err)
+	}
+	defer func() {
+		if err := rc.Teardown(); err != nil {
+			zap.L().Warn("failed to tear down rarejob client", zap.Error(err))
+		}
+	}()
+
+	if err := login(ctx, rc); err != nil {
+		return fmt.Errorf("failed to login: %w", err)
+	}
+
+	status, err := rc.AccountStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get account status: %w", err)
+	}
+
+	if *warnWithin > 0 {
+		if expiring := status.ExpiringTickets(time.Now().Add(*warnWithin)); len(expiring) > 0 {
+			var lines []string
+			for _, t := range expiring {
+				lines = append(lines, fmt.Sprintf("%s: %d left, expires at %s", t.Name, t.Remaining, t.ExpiresAt.Format("2006-01-02 15:04")))
+				zap.L().Warn("lesson tickets are about to expire", zap.String("ticket", t.Name), zap.Int("remaining", t.Remaining), zap.Time("expires_at", t.ExpiresAt))
+			}
+			notifyReminder(ctx, notify.Reminder{
+				Kind:    "tickets_expiring",
+				Message: "your lesson tickets are about to expire! book lessons before they're gone.\n\n" + strings.Join(lines, "\n"),
+			})
+		}
+	}
+
+	return printResult((*accountResult)(status), func(w io.Writer) {
+		fmt.Fprintf(w, "plan: %s\n", status.Plan)
+		if status.RemainingLessons >= 0 {
+			fmt.Fprintf(w, "remaining lessons today: %d\n", status.RemainingLessons)
+		}
+		for _, t := range status.Tickets {
+			fmt.Fprintf(w, "ticket: %s, %d left, expires at %s\n", t.Name, t.Remaining, t.ExpiresAt.Format("2006-01-02 15:04"))
+		}
+	})
+}
+
+// accountResult renders the lesson tickets of the account status.
+type accountResult librarejob.AccountStatus
+
+func (r *accountResult) header() []string {
+	return []string{"TICKET", "REMAINING", "EXPIRES AT"}
+}
+
+func (r *accountResult) rows() [][]string {
+	rows := make([][]string, 0, len(r.Tickets))
+	for _, t := range r.Tickets {
+		rows = append(rows, []string{t.Name, strconv.Itoa(t.Remaining), t.ExpiresAt.Format(time.RFC3339)})
+	}
+	return rows
+}
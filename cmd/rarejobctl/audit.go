@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+var auditLogPath = flag.String("audit-log", "", "file to append the logins, searches, reservations and cancellations to as JSON lines (default: user config directory)")
+
+// auditLog returns the audit log file opened in the append mode, shared by every client of the process.
+// Since auditing must not block the operations, it returns nil with a warning if the file can't be opened.
+var auditLog = sync.OnceValue(func() io.Writer {
+	path := *auditLogPath
+	if path == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			zap.L().Warn("audit log is not available, the actions are not audited", zap.Error(err))
+			return nil
+		}
+		path = filepath.Join(dir, "rarejobctl", "audit.log")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		zap.L().Warn("audit log is not available, the actions are not audited", zap.Error(err))
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		zap.L().Warn("audit log is not available, the actions are not audited", zap.Error(err))
+		return nil
+	}
+	return f
+})
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+	"github.com/musaprg/rarejobctl/store"
+	"go.uber.org/zap"
+)
+
+// heatmapShades renders the counts of the open slots from few to many.
+var heatmapShades = []rune(" ░▒▓█")
+
+// runTutorsAvailability samples the search results of each day and renders the counts of the open slots per hour.
+// The counts of all tutors are also saved in the history database as the samples for the forecast.
+func runTutorsAvailability(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("tutors availability", flag.ContinueOnError)
+	days := fs.Int("days", 7, "number of days to sample from the date specified by -year, -month and -day")
+	fromTime := fs.String("from", "06:00", "start of the window to sample each day formatted in HH:MM")
+	toTime := fs.String("to", "23:30", "end of the window to sample each day formatted in HH:MM")
+	tutorsFlag := fs.String("tutors", "", "comma-separated names or ids of the favorite tutors to count the slots of, empty for all tutors")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+	if *days <= 0 {
+		return fmt.Errorf("%w: -days must be positive", errInvalidConfig)
+	}
+	fh, fm, err := parseHourMinute(*fromTime)
+	if err != nil {
+		return err
+	}
+	th, tm, err := parseHourMinute(*toTime)
+	if err != nil {
+		return err
+	}
+	favorites := map[string]bool{}
+	for _, t := range strings.Split(*tutorsFlag, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			favorites[t] = true
+		}
+	}
+
+	opts, err := newClientOpts()
+	if err != nil {
+		return err
+	}
+	rc, err := librarejob.NewClient(opts)
+	if err != nil {
+		return fmt.Errorf("failed to create rarejob client: %w", err)
+	}
+	defer func() {
+		if err := rc.Teardown(); err != nil {
+			zap.L().Warn("failed to tear down rarejob client", zap.Error(err))
+		}
+	}()
+
+	if err := login(ctx, rc); err != nil {
+		return fmt.Errorf("failed to login: %w", err)
+	}
+
+	var samples []store.Sample
+	startedAt := time.Now()
+	h := heatmap{}
+	for hour := fh; hour <= th; hour++ {
+		h.Hours = append(h.Hours, hour)
+	}
+	for d := 0; d < *days; d++ {
+		date := time.Date(*year, time.Month(*month), *day+d, 0, 0, 0, 0, tz)
+		from := time.Date(date.Year(), date.Month(), date.Day(), fh, fm, 0, 0, tz)
+		by := time.Date(date.Year(), date.Month(), date.Day(), th, tm, 0, 0, tz)
+
+		zap.L().Info("sampling availability", zap.Time("date", date))
+		sampledAt := time.Now()
+		tutors, err := rc.SearchTutors(ctx, from, by.Sub(from))
+		if err != nil && !errors.Is(err, librarejob.ErrNoTutorsAvailable) {
+			return fmt.Errorf("failed to search tutors on %s: %w", date.Format(time.DateOnly), err)
+		}
+
+		counts := make([]int, len(h.Hours))
+		all := make([]int, len(h.Hours))
+		for _, t := range tutors {
+			favorite := len(favorites) == 0 || favorites[t.Name] || favorites[t.ID]
+			for _, s := range t.OpenSlots() {
+				if i := s.Hour() - fh; i >= 0 && i < len(counts) {
+					all[i]++
+					if favorite {
+						counts[i]++
+					}
+				}
+			}
+		}
+		for i, hour := range h.Hours {
+			slotAt := time.Date(date.Year(), date.Month(), date.Day(), hour, 0, 0, 0, tz)
+			// the past hours are not bookable anymore, so they tell nothing about the availability
+			if slotAt.Add(time.Hour).After(sampledAt) {
+				samples = append(samples, store.Sample{SlotAt: slotAt, OpenSlots: all[i], SampledAt: sampledAt})
+			}
+		}
+		h.Days = append(h.Days, date.Format(time.DateOnly))
+		h.Counts = append(h.Counts, counts)
+	}
+
+	if st := openStore(); st != nil {
+		if err := st.AddSamples(context.WithoutCancel(ctx), samples); err != nil {
+			zap.L().Warn("failed to save availability samples", zap.Error(err))
+		}
+		closeStore(st)
+	}
+	exportAvailability(ctx, startedAt, h)
+
+	return printResult(h, h.render)
+}
+
+func parseHourMinute(s string) (int, int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: invalid time format: %s", errInvalidConfig, s)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// heatmap is the counts of the open slots per day and hour.
+type heatmap struct {
+	Days  []string `json:"days"`
+	Hours []int    `json:"hours"`
+	// Counts is indexed by the day, then the hour.
+	Counts [][]int `json:"counts"`
+}
+
+func (h heatmap) header() []string {
+	header := []string{"DATE"}
+	for _, hour := range h.Hours {
+		header = append(header, strconv.Itoa(hour))
+	}
+	return header
+}
+
+func (h heatmap) rows() [][]string {
+	rows := make([][]string, 0, len(h.Days))
+	for i, d := range h.Days {
+		row := []string{d}
+		for _, c := range h.Counts[i] {
+			row = append(row, strconv.Itoa(c))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// render draws the heatmap with the shades relative to the max count.
+func (h heatmap) render(w io.Writer) {
+	maxCount := 0
+	for _, counts := range h.Counts {
+		for _, c := range counts {
+			maxCount = max(maxCount, c)
+		}
+	}
+
+	fmt.Fprintf(w, "%-10s ", "")
+	for _, hour := range h.Hours {
+		fmt.Fprintf(w, "%-3d", hour)
+	}
+	fmt.Fprintln(w)
+	for i, d := range h.Days {
+		fmt.Fprintf(w, "%-10s ", d)
+		for _, c := range h.Counts[i] {
+			shade := heatmapShades[0]
+			if maxCount > 0 {
+				shade = heatmapShades[(c*(len(heatmapShades)-1)+maxCount-1)/maxCount]
+			}
+			fmt.Fprintf(w, "%c%c ", shade, shade)
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "max %d open slots per hour\n", maxCount)
+}
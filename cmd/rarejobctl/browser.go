@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+)
+
+// newBrowserOpts builds the browser options from the flags.
+func newBrowserOpts() (librarejob.BrowserOpts, error) {
+	opts := librarejob.BrowserOpts{
+		UserAgent:      *userAgent,
+		AcceptLanguage: *acceptLanguage,
+	}
+	if *windowSize != "" {
+		w, h, err := parseWindowSize(*windowSize)
+		if err != nil {
+			return opts, err
+		}
+		opts.WindowWidth, opts.WindowHeight = w, h
+	}
+	if *browserPrefs != "" {
+		prefs, err := parseBrowserPrefs(*browserPrefs)
+		if err != nil {
+			return opts, err
+		}
+		opts.Prefs = prefs
+	}
+	return opts, nil
+}
+
+// parseWindowSize parses WIDTHxHEIGHT.
+func parseWindowSize(s string) (int, int, error) {
+	ws, hs, ok := strings.Cut(s, "x")
+	if !ok {
+		return 0, 0, fmt.Errorf("%w: invalid window size: %s", errInvalidConfig, s)
+	}
+	w, err := strconv.Atoi(ws)
+	if err != nil || w <= 0 {
+		return 0, 0, fmt.Errorf("%w: invalid window width: %s", errInvalidConfig, ws)
+	}
+	h, err := strconv.Atoi(hs)
+	if err != nil || h <= 0 {
+		return 0, 0, fmt.Errorf("%w: invalid window height: %s", errInvalidConfig, hs)
+	}
+	return w, h, nil
+}
+
+// parseBrowserPrefs parses comma-separated key=value pairs. Since the preferences are typed,
+// the values which look like a boolean or an integer are passed as is, and the others as strings.
+func parseBrowserPrefs(s string) (map[string]interface{}, error) {
+	prefs := make(map[string]interface{})
+	for _, kv := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(kv, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("%w: invalid browser preference: %s", errInvalidConfig, kv)
+		}
+		v = strings.TrimSpace(v)
+		if v == "true" || v == "false" {
+			prefs[k] = v == "true"
+		} else if n, err := strconv.Atoi(v); err == nil {
+			prefs[k] = n
+		} else {
+			prefs[k] = v
+		}
+	}
+	return prefs, nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+)
+
+// loadBusyFile reads the busy time which the reservation must not overlap.
+// The file is either an iCalendar (.ics) file, or a text file which has a period per line such as
+// "2022-12-27 21:00 - 22:00" or "2022-12-27 23:00 - 2022-12-28 01:00". Lines starting with # are ignored.
+func loadBusyFile(path string) ([]librarejob.Interval, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to open busy file: %w", errInvalidConfig, err)
+	}
+	defer f.Close()
+
+	var busy []librarejob.Interval
+	if strings.EqualFold(filepath.Ext(path), ".ics") {
+		busy, err = parseICS(bufio.NewScanner(f))
+	} else {
+		busy, err = parseBusyText(bufio.NewScanner(f))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid busy file: %w", errInvalidConfig, err)
+	}
+	return busy, nil
+}
+
+func parseBusyText(sc *bufio.Scanner) ([]librarejob.Interval, error) {
+	var busy []librarejob.Interval
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		start, end, ok := strings.Cut(line, " - ")
+		if !ok {
+			return nil, fmt.Errorf("line %d: period must be formatted in \"YYYY-MM-DD HH:MM - HH:MM\"", n)
+		}
+		s, err := time.ParseInLocation("2006-01-02 15:04", strings.TrimSpace(start), tz)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		end = strings.TrimSpace(end)
+		if !strings.Contains(end, " ") {
+			end = s.Format("2006-01-02") + " " + end
+		}
+		e, err := time.ParseInLocation("2006-01-02 15:04", end, tz)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		busy = append(busy, librarejob.Interval{Start: s, End: e})
+	}
+	return busy, sc.Err()
+}
+
+// parseICS reads the start and end of the events in the iCalendar. Only DTSTART and DTEND are supported,
+// so recurring events are taken only at their first occurrence.
+func parseICS(sc *bufio.Scanner) ([]librarejob.Interval, error) {
+	var busy []librarejob.Interval
+	var cur *librarejob.Interval
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		switch {
+		case line == "BEGIN:VEVENT":
+			cur = &librarejob.Interval{}
+		case line == "END:VEVENT":
+			if cur != nil && !cur.Start.IsZero() {
+				if cur.End.IsZero() {
+					cur.End = cur.Start.AddDate(0, 0, 1)
+				}
+				busy = append(busy, *cur)
+			}
+			cur = nil
+		case cur != nil && (strings.HasPrefix(line, "DTSTART") || strings.HasPrefix(line, "DTEND")):
+			t, err := parseICSTime(line)
+			if err != nil {
+				return nil, err
+			}
+			if strings.HasPrefix(line, "DTSTART") {
+				cur.Start = t
+			} else {
+				cur.End = t
+			}
+		}
+	}
+	return busy, sc.Err()
+}
+
+// parseICSTime parses the property such as "DTSTART:20221227T120000Z", "DTSTART;TZID=Asia/Tokyo:20221227T210000"
+// or "DTSTART;VALUE=DATE:20221227".
+func parseICSTime(line string) (time.Time, error) {
+	name, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid property: %s", line)
+	}
+	loc := tz
+	for _, param := range strings.Split(name, ";")[1:] {
+		if tzid, ok := strings.CutPrefix(param, "TZID="); ok {
+			l, err := time.LoadLocation(tzid)
+			if err != nil {
+				return time.Time{}, err
+			}
+			loc = l
+		}
+	}
+	switch {
+	case strings.HasSuffix(value, "Z"):
+		return time.Parse("20060102T150405Z", value)
+	case len(value) == len("20060102"):
+		return time.ParseInLocation("20060102", value, loc)
+	default:
+		return time.ParseInLocation("20060102T150405", value, loc)
+	}
+}
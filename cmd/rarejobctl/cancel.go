@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+	"github.com/musaprg/rarejobctl/store"
+	"go.uber.org/zap"
+)
+
+// runCancel cancels the reservation starting at the given time.
+func runCancel(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("cancel", flag.ContinueOnError)
+	at := fs.String("at", "", "start time of the reservation to cancel formatted in \"YYYY-MM-DD HH:MM\"")
+	forceCancel := fs.Bool("force", false, "cancel even after the free cancellation deadline, which consumes the lesson")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+	if *at == "" {
+		return fmt.Errorf("%w: -at is required", errInvalidConfig)
+	}
+	startAt, err := time.ParseInLocation("2006-01-02 15:04", *at, tz)
+	if err != nil {
+		return fmt.Errorf("%w: invalid start time: %w", errInvalidConfig, err)
+	}
+
+	st := openStore()
+	defer closeStore(st)
+
+	opts, err := newClientOpts()
+	if err != nil {
+		return err
+	}
+	rc, err := librarejob.NewClient(opts)
+	if err != nil {
+		return fmt.Errorf("failed to create rarejob client: %w", err)
+	}
+	defer func() {
+		if err := rc.Teardown(); err != nil {
+			zap.L().Warn("failed to tear down rarejob client", zap.Error(err))
+		}
+	}()
+
+	if err := login(ctx, rc); err != nil {
+		return fmt.Errorf("failed to login: %w", err)
+	}
+
+	r, err := rc.Cancel(ctx, startAt, *forceCancel)
+	if errors.Is(err, librarejob.ErrCancelDeadlinePassed) {
+		zap.L().Warn("the free cancellation deadline has passed, cancelling now consumes the lesson. use -force to cancel anyway")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to cancel reservation: %w", err)
+	}
+
+	if r.DryRun {
+		return printResult((*reserveResult)(r), func(w io.Writer) {
+			fmt.Fprintf(w, "[dry-run] would cancel tutor %s from %s to %s\n", r.Name, r.StartAt, r.EndAt)
+		})
+	}
+
+	recordHistory(ctx, st, &store.Record{
+		Kind:      store.KindCancellation,
+		TutorName: r.Name,
+		StartAt:   r.StartAt,
+		EndAt:     r.EndAt,
+	})
+	notifyCancelled(ctx, r)
+
+	return printResult((*reserveResult)(r), func(w io.Writer) {
+		fmt.Fprintf(w, "cancelled tutor %s from %s to %s\n", r.Name, r.StartAt, r.EndAt)
+	})
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+	"github.com/musaprg/rarejobctl/store"
+	"go.uber.org/zap"
+)
+
+// credentials returns the credentials to login resolved by credentialProvider.
+// They are resolved only once, since the standard input can't be read twice.
+var credentials = sync.OnceValues(func() (librarejob.Credentials, error) {
+	p, err := credentialProvider()
+	if err != nil {
+		return librarejob.Credentials{}, err
+	}
+	cred, err := p.Credentials(context.Background())
+	if err != nil {
+		return cred, err
+	}
+	if cred.Email == "" || cred.Password == "" {
+		return cred, fmt.Errorf("%w: email and password are required", errInvalidConfig)
+	}
+	return cred, nil
+})
+
+// credentialProvider returns the provider of the credentials specified by the flags. The email is read from
+// RAREJOB_EMAIL, and the password from -password-stdin, -password-file, -credential-command or -keyring.
+// Without them, the password is read from RAREJOB_PASSWORD, or prompted if it's not set and stdin is a terminal.
+func credentialProvider() (librarejob.CredentialProvider, error) {
+	email := os.Getenv("RAREJOB_EMAIL")
+
+	n := 0
+	for _, set := range []bool{*passwordStdin, *passwordFile != "", *credentialCommand != "", *useKeyring} {
+		if set {
+			n++
+		}
+	}
+	if n > 1 {
+		return nil, fmt.Errorf("%w: only one of -password-stdin, -password-file, -credential-command and -keyring can be specified", errInvalidConfig)
+	}
+
+	switch {
+	case *passwordStdin:
+		return librarejob.ReaderCredentials{Email: email, R: os.Stdin}, nil
+	case *passwordFile != "":
+		return librarejob.FileCredentials{Email: email, Path: *passwordFile}, nil
+	case *credentialCommand != "":
+		return librarejob.CommandCredentials{Email: email, Command: *credentialCommand}, nil
+	case *useKeyring:
+		if email == "" {
+			return nil, fmt.Errorf("%w: RAREJOB_EMAIL is required to look up the keyring", errInvalidConfig)
+		}
+		return librarejob.KeyringCredentials{Email: email}, nil
+	case os.Getenv("RAREJOB_PASSWORD") == "" && isInteractive():
+		return librarejob.PromptCredentials{Email: email, In: os.Stdin, Out: os.Stderr}, nil
+	default:
+		return librarejob.EnvCredentials{}, nil
+	}
+}
+
+// sessionCacheTTL is how long the session cached by login is resumed. The site may log it out earlier,
+// when login falls back to the password.
+const sessionCacheTTL = 24 * time.Hour
+
+// login resumes the session of RAREJOB_EMAIL cached in the store, or logs in with the credentials and caches the session,
+// so that the processes sharing the store, e.g. the replicas of the daemon, don't log in every time.
+func login(ctx context.Context, rc librarejob.Client) error {
+	var st store.Store
+	if *sessionCache {
+		var err error
+		if st, err = openStoreOrError(); err != nil {
+			zap.L().Debug("store is not available, the session is not cached", zap.Error(err))
+		} else {
+			defer closeStore(st)
+		}
+	}
+	if email := os.Getenv("RAREJOB_EMAIL"); st != nil && email != "" && resumeSession(ctx, rc, st, email) {
+		return nil
+	}
+
+	cred, err := credentials()
+	if err != nil {
+		return err
+	}
+	if err := rc.Login(ctx, cred.Email, cred.Password); err != nil {
+		return err
+	}
+	if st != nil {
+		saveSession(ctx, rc, st, cred.Email)
+	}
+	return nil
+}
+
+// resumeSession resumes the session of the account cached in st, and reports whether it is logged in.
+func resumeSession(ctx context.Context, rc librarejob.Client, st store.Store, email string) bool {
+	b, err := st.LoadSession(ctx, email)
+	if err != nil {
+		zap.L().Warn("failed to load the cached session, logging in with the password", zap.Error(err))
+		return false
+	}
+	if b == nil {
+		return false
+	}
+	var cookies []librarejob.Cookie
+	if err := json.Unmarshal(b, &cookies); err != nil {
+		zap.L().Warn("the cached session is broken, logging in with the password", zap.Error(err))
+		return false
+	}
+	err = rc.ResumeSession(ctx, cookies)
+	if errors.Is(err, librarejob.ErrSessionExpired) {
+		zap.L().Info("the cached session has been logged out, logging in with the password")
+		if err := st.DeleteSession(ctx, email); err != nil {
+			zap.L().Warn("failed to delete the cached session", zap.Error(err))
+		}
+		return false
+	}
+	if err != nil {
+		zap.L().Warn("failed to resume the cached session, logging in with the password", zap.Error(err))
+		return false
+	}
+	zap.L().Info("resumed the cached session", zap.String("email", email))
+	return true
+}
+
+// saveSession caches the session of rc logged in to the account into st.
+func saveSession(ctx context.Context, rc librarejob.Client, st store.Store, email string) {
+	cookies, err := rc.ExportSession(ctx)
+	if err != nil {
+		zap.L().Warn("failed to export the session, it is not cached", zap.Error(err))
+		return
+	}
+	b, err := json.Marshal(cookies)
+	if err != nil {
+		zap.L().Warn("failed to encode the session, it is not cached", zap.Error(err))
+		return
+	}
+	if err := st.SaveSession(ctx, email, b, sessionCacheTTL); err != nil {
+		zap.L().Warn("failed to cache the session", zap.Error(err))
+	}
+}
+
+// runKeyring dispatches the subcommand of keyring.
+func runKeyring(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: subcommand of keyring is required (set)", errInvalidConfig)
+	}
+	switch args[0] {
+	case "set":
+		return runKeyringSet(ctx, args[1:])
+	default:
+		return fmt.Errorf("%w: unknown subcommand of keyring: %s", errInvalidConfig, args[0])
+	}
+}
+
+// runKeyringSet prompts for the password of RAREJOB_EMAIL and stores it into the OS keyring to be used with -keyring.
+func runKeyringSet(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("keyring set", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+
+	email := os.Getenv("RAREJOB_EMAIL")
+	if email == "" {
+		return fmt.Errorf("%w: RAREJOB_EMAIL is required to store the password", errInvalidConfig)
+	}
+	cred, err := librarejob.PromptCredentials{Email: email, In: os.Stdin, Out: os.Stderr}.Credentials(ctx)
+	if err != nil {
+		return err
+	}
+	if err := (librarejob.KeyringCredentials{Email: email}).Store(cred.Password); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "saved the password of %s into the keyring\n", email)
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+	"go.uber.org/zap"
+)
+
+// runDoctor checks whether the selectors required by rarejobctl still resolve on the site.
+func runDoctor(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+
+	from, err := parseFrom()
+	if err != nil {
+		return err
+	}
+
+	opts, err := newClientOpts()
+	if err != nil {
+		return err
+	}
+	rc, err := librarejob.NewClient(opts)
+	if err != nil {
+		return fmt.Errorf("failed to create rarejob client: %w", err)
+	}
+	defer func() {
+		if err := rc.Teardown(); err != nil {
+			zap.L().Warn("failed to tear down rarejob client", zap.Error(err))
+		}
+	}()
+
+	cred, err := credentials()
+	if err != nil {
+		return err
+	}
+	checks, err := rc.CheckSelectors(ctx, cred.Email, cred.Password, from, time.Minute*time.Duration(*margin))
+	if err != nil {
+		return fmt.Errorf("failed to check selectors: %w", err)
+	}
+
+	result := doctorResult(checks)
+	if err := printResult(result, func(w io.Writer) {
+		for _, r := range result.rows() {
+			fmt.Fprintf(w, "[%s] %s/%s: %s\n", r[3], r[0], r[1], r[2])
+		}
+	}); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, c := range checks {
+		if !c.OK && !c.Skipped {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d selectors failed to resolve", failed, len(checks))
+	}
+	return nil
+}
+
+// doctorResult renders the result of the selector checks.
+type doctorResult []librarejob.SelectorCheck
+
+func (r doctorResult) header() []string {
+	return []string{"PAGE", "NAME", "SELECTOR", "RESULT"}
+}
+
+func (r doctorResult) rows() [][]string {
+	rows := make([][]string, 0, len(r))
+	for _, c := range r {
+		result := "FAIL"
+		switch {
+		case c.OK:
+			result = "PASS"
+		case c.Skipped:
+			result = "SKIP"
+		}
+		rows = append(rows, []string{c.Page, c.Name, c.Value, result})
+	}
+	return rows
+}
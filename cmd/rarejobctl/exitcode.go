@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+)
+
+// Exit codes of rarejobctl. They are part of the interface for cron jobs and wrappers, so don't change them.
+const (
+	exitCodeSuccess      = 0
+	exitCodeNoTutors     = 2
+	exitCodeLoginFailure = 3
+	exitCodeSiteError    = 4
+	exitCodeConfigError  = 5
+	// exitCodeConflict tells the wrappers that the slot was not reserved on purpose because it overlaps the existing
+	// reservations or the busy time, which retrying won't fix unlike exitCodeNoTutors. It's documented in README.
+	exitCodeConflict = 6
+)
+
+// errInvalidConfig is returned when the flags or arguments are invalid.
+var errInvalidConfig = errors.New("invalid configuration")
+
+// exitCode returns the exit code for the failure class of err.
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return exitCodeSuccess
+	case errors.Is(err, errInvalidConfig), errors.Is(err, librarejob.ErrInvalidOptions), errors.Is(err, librarejob.ErrSpreadAcrossTwoDays):
+		return exitCodeConfigError
+	case errors.Is(err, librarejob.ErrNoTutorsAvailable):
+		return exitCodeNoTutors
+	case errors.Is(err, librarejob.ErrLoginFailed), errors.Is(err, librarejob.ErrHumanVerificationRequired):
+		return exitCodeLoginFailure
+	case errors.Is(err, librarejob.ErrConflict):
+		return exitCodeConflict
+	default:
+		// the rest are failures of the site or selenium
+		return exitCodeSiteError
+	}
+}
@@ -0,0 +1,38 @@
+//go:build fixture
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/musaprg/rarejobctl/librarejob/librarejobtest"
+	"go.uber.org/zap"
+)
+
+// runFixture serves the fixture pages mimicking the rarejob pages to run the client against with -base-url, e.g. in CI.
+// It's built only with the fixture tag not to ship the test server in the released binary.
+func runFixture(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("fixture", flag.ContinueOnError)
+	listen := fs.String("listen", ":8080", "address to serve the fixture pages on")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+
+	f := librarejobtest.NewFixture(os.Getenv("RAREJOB_EMAIL"), os.Getenv("RAREJOB_PASSWORD"), nil)
+	s := &http.Server{Addr: *listen, Handler: f}
+	go func() {
+		<-ctx.Done()
+		s.Close()
+	}()
+
+	zap.L().Info("serving fixture pages", zap.String("address", *listen))
+	if err := s.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve fixture pages: %w", err)
+	}
+	return nil
+}
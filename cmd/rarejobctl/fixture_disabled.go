@@ -0,0 +1,13 @@
+//go:build !fixture
+
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// runFixture fails since the fixture server is built only with the fixture tag.
+func runFixture(ctx context.Context, args []string) error {
+	return fmt.Errorf("%w: the fixture command is not built in, build with -tags fixture to use it", errInvalidConfig)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/musaprg/rarejobctl/store"
+	"go.uber.org/zap"
+)
+
+const (
+	// forecastStep is the interval between the times the availability is forecasted at.
+	forecastStep = time.Hour
+	// forecastLeadTolerance is how far the lead time of a sample may be from the forecasted one to be counted.
+	forecastLeadTolerance = 30 * time.Minute
+)
+
+var forecastMinProbability = flag.Float64("forecast-min-probability", 0, "wait to start trying until the latest time when the slot is forecasted to be still open with this probability, from the samples of tutors availability, 0 means start immediately")
+
+// forecastPoint is the probability that a slot in the hour is still open at the time.
+type forecastPoint struct {
+	At time.Time `json:"at"`
+	// LeadHours is the hours from At to the slot.
+	LeadHours   float64 `json:"lead_hours"`
+	Probability float64 `json:"probability"`
+	// Samples is the number of the samples the probability is estimated from, which is unknown if zero.
+	Samples int `json:"samples"`
+}
+
+// forecastResult is the forecast of the slot and when to start trying to book it.
+type forecastResult struct {
+	SlotAt time.Time       `json:"slot_at"`
+	Points []forecastPoint `json:"points"`
+	// StartAt is the latest time to start trying with the min probability.
+	StartAt time.Time `json:"start_at"`
+}
+
+// runTutorsForecast forecasts the availability of the slot in the hour specified by the flags until it starts.
+func runTutorsForecast(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("tutors forecast", flag.ContinueOnError)
+	minProbability := fs.Float64("min-probability", 0.8, "min probability that the slot is still open to decide when to start trying")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+	from, err := parseFrom()
+	if err != nil {
+		return err
+	}
+
+	st, err := openStoreOrError()
+	if err != nil {
+		return err
+	}
+	defer closeStore(st)
+	samples, err := st.ListSamples(ctx, store.SampleQuery{})
+	if err != nil {
+		return err
+	}
+
+	points := forecast(samples, from, time.Now())
+	res := forecastResult{SlotAt: from, Points: points, StartAt: forecastStart(points, *minProbability)}
+	return printResult(res, func(w io.Writer) {
+		for _, p := range res.Points {
+			prob := "unknown"
+			if p.Samples > 0 {
+				prob = formatRate(p.Probability)
+			}
+			fmt.Fprintf(w, "%s  %6.1fh before  %7s (%d samples)\n", p.At.In(tz).Format("2006-01-02 15:04"), p.LeadHours, prob, p.Samples)
+		}
+		fmt.Fprintf(w, "start trying by %s\n", res.StartAt.In(tz).Format("2006-01-02 15:04"))
+	})
+}
+
+// forecast estimates the probability that a slot in the hour of slotAt is still open at every step from now to the slot.
+func forecast(samples []store.Sample, slotAt, now time.Time) []forecastPoint {
+	points := []forecastPoint{}
+	for at := now; at.Before(slotAt); at = at.Add(forecastStep) {
+		p, n := predictOpen(samples, slotAt, at)
+		points = append(points, forecastPoint{At: at, LeadHours: slotAt.Sub(at).Hours(), Probability: p, Samples: n})
+	}
+	return points
+}
+
+// predictOpen estimates the probability that a slot in the hour of slotAt is still open at the time at,
+// from the samples of the same hour of the day taken as long before the slot. It returns the number of the samples,
+// and the probability is smoothed so that a few samples don't make it 0 or 1.
+func predictOpen(samples []store.Sample, slotAt, at time.Time) (float64, int) {
+	hour := slotAt.In(tz).Hour()
+	lead := slotAt.Sub(at)
+	open, n := 0, 0
+	for _, s := range samples {
+		if s.SlotAt.In(tz).Hour() != hour {
+			continue
+		}
+		if d := s.SlotAt.Sub(s.SampledAt) - lead; d < -forecastLeadTolerance || d > forecastLeadTolerance {
+			continue
+		}
+		n++
+		if s.OpenSlots > 0 {
+			open++
+		}
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	return float64(open+1) / float64(n+2), n
+}
+
+// forecastStart returns the latest time of the points until which the slot is forecasted to be open with minProbability.
+// The times without samples are regarded as open. It returns the first time if the slot may be taken already.
+func forecastStart(points []forecastPoint, minProbability float64) time.Time {
+	if len(points) == 0 {
+		return time.Now()
+	}
+	start := points[0].At
+	for _, p := range points {
+		if p.Samples > 0 && p.Probability < minProbability {
+			break
+		}
+		start = p.At
+	}
+	return start
+}
+
+// waitForecast waits until the time to start trying to book the slot at slotAt forecasted with -forecast-min-probability.
+// It doesn't wait if the forecast is not available.
+func waitForecast(ctx context.Context, st store.Store, slotAt time.Time) error {
+	if *forecastMinProbability <= 0 {
+		return nil
+	}
+	if st == nil {
+		zap.L().Warn("history database is not available, start trying without the forecast")
+		return nil
+	}
+	samples, err := st.ListSamples(ctx, store.SampleQuery{})
+	if err != nil {
+		zap.L().Warn("failed to load availability samples, start trying without the forecast", zap.Error(err))
+		return nil
+	}
+	start := forecastStart(forecast(samples, slotAt, time.Now()), *forecastMinProbability)
+	d := time.Until(start)
+	if d <= 0 {
+		return nil
+	}
+	zap.L().Info("waiting until the forecasted time to start trying", zap.Time("start_at", start))
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (r forecastResult) header() []string {
+	return []string{"AT", "LEAD HOURS", "PROBABILITY", "SAMPLES"}
+}
+
+func (r forecastResult) rows() [][]string {
+	rows := make([][]string, 0, len(r.Points))
+	for _, p := range r.Points {
+		rows = append(rows, []string{p.At.Format(time.RFC3339), strconv.FormatFloat(p.LeadHours, 'f', 1, 64), strconv.FormatFloat(p.Probability, 'f', 3, 64), strconv.Itoa(p.Samples)})
+	}
+	return rows
+}
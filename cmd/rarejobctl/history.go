@@ -0,0 +1,310 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+	"github.com/musaprg/rarejobctl/store"
+	"go.uber.org/zap"
+)
+
+var dbPath = flag.String("db", os.Getenv("RAREJOB_DB"), "SQLite database or URL of Redis (redis:// or rediss://) to record reservations, cancellations and failed attempts into, shared by the processes reserving the same account (default: SQLite in user config directory)")
+
+// openStore opens the history database. Since recording the history must not block reservations,
+// it returns nil with a warning if the database is not available.
+func openStore() store.Store {
+	st, err := openStoreOrError()
+	if err != nil {
+		zap.L().Warn("history database is not available, the history is not recorded", zap.Error(err))
+		return nil
+	}
+	return st
+}
+
+// openStoreOrError opens the history database for the commands which can't work without it.
+func openStoreOrError() (store.Store, error) {
+	path := *dbPath
+	if path == "" {
+		p, err := store.DefaultPath()
+		if err != nil {
+			return nil, err
+		}
+		path = p
+	}
+	return store.Open(path)
+}
+
+func closeStore(st store.Store) {
+	if st == nil {
+		return
+	}
+	if err := st.Close(); err != nil {
+		zap.L().Warn("failed to close history database", zap.Error(err))
+	}
+}
+
+// recordHistory adds r to the history database if available.
+func recordHistory(ctx context.Context, st store.Store, r *store.Record) {
+	if st == nil {
+		return
+	}
+	// the event should be recorded even if the operation has been cancelled
+	if err := st.Add(context.WithoutCancel(ctx), r); err != nil {
+		zap.L().Warn("failed to record history", zap.Error(err), zap.String("kind", string(r.Kind)))
+	}
+}
+
+// reservationRecord converts the result of Reserve into the history record.
+func reservationRecord(r *librarejob.Reserve) *store.Record {
+	return &store.Record{
+		Kind:      store.KindReservation,
+		TutorName: r.Name,
+		StartAt:   r.StartAt,
+		EndAt:     r.EndAt,
+		DryRun:    r.DryRun,
+	}
+}
+
+// attemptRecord converts the failure of Reserve into the history record.
+func attemptRecord(from time.Time, margin time.Duration, err error) *store.Record {
+	return &store.Record{
+		Kind:      store.KindAttempt,
+		StartAt:   from,
+		EndAt:     from.Add(margin),
+		DryRun:    *dryRun,
+		Error:     err.Error(),
+		ErrorCode: librarejob.ErrorCode(err),
+	}
+}
+
+// runHistory dispatches the subcommand of history. Showing the records is the default.
+func runHistory(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "sync":
+			return runHistorySync(ctx, args[1:])
+		case "lessons":
+			return runHistoryLessons(ctx, args[1:])
+		}
+	}
+	return runHistoryRecords(ctx, args)
+}
+
+// runHistoryRecords shows the recorded reservations, cancellations and failed attempts.
+func runHistoryRecords(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	kind := fs.String("kind", "", "show only the records of the kind (reservation, cancellation or attempt)")
+	since := fs.String("since", "", "show only the records created on or after the date formatted in YYYY-MM-DD")
+	limit := fs.Int("limit", 20, "max number of records to show, 0 means no limit")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+
+	q := store.Query{Kind: store.Kind(*kind), Limit: *limit}
+	switch q.Kind {
+	case "", store.KindReservation, store.KindCancellation, store.KindAttempt:
+	default:
+		return fmt.Errorf("%w: invalid kind: %s", errInvalidConfig, *kind)
+	}
+	if *since != "" {
+		s, err := time.ParseInLocation("2006-01-02", *since, tz)
+		if err != nil {
+			return fmt.Errorf("%w: invalid date: %w", errInvalidConfig, err)
+		}
+		q.Since = s
+	}
+
+	st, err := openStoreOrError()
+	if err != nil {
+		return err
+	}
+	defer closeStore(st)
+
+	records, err := st.List(ctx, q)
+	if err != nil {
+		return err
+	}
+	if records == nil {
+		records = []store.Record{}
+	}
+
+	result := historyResult(records)
+	return printResult(result, func(w io.Writer) {
+		for _, r := range records {
+			line := fmt.Sprintf("%s %-12s %s - %s", r.CreatedAt.In(tz).Format("2006-01-02 15:04"), r.Kind, r.StartAt.In(tz).Format("2006-01-02 15:04"), r.EndAt.In(tz).Format("15:04"))
+			if r.TutorName != "" {
+				line += " " + r.TutorName
+			}
+			if r.DryRun {
+				line += " [dry-run]"
+			}
+			if r.Error != "" {
+				line += " (" + r.ErrorCode + ": " + r.Error + ")"
+			}
+			fmt.Fprintln(w, line)
+		}
+	})
+}
+
+// historyResult renders the recorded history.
+type historyResult []store.Record
+
+func (r historyResult) header() []string {
+	return []string{"ID", "CREATED", "KIND", "TUTOR", "START", "END", "DRY RUN", "ERROR CODE", "ERROR"}
+}
+
+func (r historyResult) rows() [][]string {
+	rows := make([][]string, 0, len(r))
+	for _, rec := range r {
+		rows = append(rows, []string{
+			strconv.FormatInt(rec.ID, 10),
+			rec.CreatedAt.In(tz).Format(time.RFC3339),
+			string(rec.Kind),
+			rec.TutorName,
+			rec.StartAt.In(tz).Format(time.RFC3339),
+			rec.EndAt.In(tz).Format(time.RFC3339),
+			strconv.FormatBool(rec.DryRun),
+			rec.ErrorCode,
+			rec.Error,
+		})
+	}
+	return rows
+}
+
+// runHistorySync walks the lesson history on the site and saves the lessons into the history database.
+// Only the lessons after the latest saved one are synced unless -full is given.
+func runHistorySync(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("history sync", flag.ContinueOnError)
+	full := fs.Bool("full", false, "sync the whole lesson history instead of the lessons after the latest synced one")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+
+	st, err := openStoreOrError()
+	if err != nil {
+		return err
+	}
+	defer closeStore(st)
+
+	var since time.Time
+	if !*full {
+		latest, err := st.LatestLesson(ctx)
+		if err != nil {
+			return err
+		}
+		// resync the last day as the status of the recent lessons may not have been settled
+		if !latest.IsZero() {
+			since = latest.AddDate(0, 0, -1)
+		}
+	}
+
+	opts, err := newClientOpts()
+	if err != nil {
+		return err
+	}
+	rc, err := librarejob.NewClient(opts)
+	if err != nil {
+		return fmt.Errorf("failed to create rarejob client: %w", err)
+	}
+	defer func() {
+		if err := rc.Teardown(); err != nil {
+			zap.L().Warn("failed to tear down rarejob client", zap.Error(err))
+		}
+	}()
+
+	if err := login(ctx, rc); err != nil {
+		return fmt.Errorf("failed to login: %w", err)
+	}
+
+	lessons, err := rc.LessonHistory(ctx, since)
+	if err != nil {
+		return fmt.Errorf("failed to get lesson history: %w", err)
+	}
+	saved := make([]store.Lesson, 0, len(lessons))
+	for _, l := range lessons {
+		saved = append(saved, store.Lesson(l))
+	}
+	if err := st.SaveLessons(ctx, saved); err != nil {
+		return err
+	}
+
+	return printResult(lessonsResult(saved), func(w io.Writer) {
+		fmt.Fprintf(w, "synced %d lessons\n", len(saved))
+	})
+}
+
+// runHistoryLessons shows the lessons synced from the lesson history.
+func runHistoryLessons(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("history lessons", flag.ContinueOnError)
+	tutor := fs.String("tutor", "", "show only the lessons with the tutor")
+	since := fs.String("since", "", "show only the lessons on or after the date formatted in YYYY-MM-DD")
+	limit := fs.Int("limit", 20, "max number of lessons to show, 0 means no limit")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+
+	q := store.LessonQuery{TutorName: *tutor, Limit: *limit}
+	if *since != "" {
+		s, err := time.ParseInLocation("2006-01-02", *since, tz)
+		if err != nil {
+			return fmt.Errorf("%w: invalid date: %w", errInvalidConfig, err)
+		}
+		q.Since = s
+	}
+
+	st, err := openStoreOrError()
+	if err != nil {
+		return err
+	}
+	defer closeStore(st)
+
+	lessons, err := st.ListLessons(ctx, q)
+	if err != nil {
+		return err
+	}
+	if lessons == nil {
+		lessons = []store.Lesson{}
+	}
+
+	result := lessonsResult(lessons)
+	return printResult(result, func(w io.Writer) {
+		for _, r := range result.rows() {
+			fmt.Fprintln(w, strings.Join(r, " "))
+		}
+	})
+}
+
+// loadLessons returns the whole lesson history synced by history sync.
+func loadLessons(ctx context.Context, st store.Store) ([]librarejob.Lesson, error) {
+	saved, err := st.ListLessons(ctx, store.LessonQuery{})
+	if err != nil {
+		return nil, err
+	}
+	lessons := make([]librarejob.Lesson, 0, len(saved))
+	for _, l := range saved {
+		lessons = append(lessons, librarejob.Lesson(l))
+	}
+	return lessons, nil
+}
+
+// lessonsResult renders the lessons synced from the lesson history.
+type lessonsResult []store.Lesson
+
+func (r lessonsResult) header() []string {
+	return []string{"START", "ID", "TUTOR", "MATERIAL", "STATUS", "COMPLETED"}
+}
+
+func (r lessonsResult) rows() [][]string {
+	rows := make([][]string, 0, len(r))
+	for _, l := range r {
+		rows = append(rows, []string{l.StartAt.In(tz).Format("2006-01-02 15:04"), l.ID, l.TutorName, l.Material, l.Status, strconv.FormatBool(l.Completed)})
+	}
+	return rows
+}
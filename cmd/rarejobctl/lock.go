@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/musaprg/rarejobctl/store"
+	"go.uber.org/zap"
+)
+
+const (
+	// reservationLockTTL is how long the lock of the reservation outlives the process which crashed holding it.
+	reservationLockTTL = time.Minute
+	// reservationLockPoll is the interval of trying to acquire the lock held by another process.
+	reservationLockPoll = 2 * time.Second
+)
+
+// lockReservation acquires the lock of reserving the lessons on the day of from in the store, waiting for another process
+// holding it, e.g. a replica fired by the same schedule, to finish so that the reservation made by it is found in the history.
+// The lock is extended in background until unlock is called. Since the lock must not block reservations,
+// it proceeds without the lock with a warning if the store is not available.
+func lockReservation(ctx context.Context, st store.Store, from time.Time) (unlock func(), err error) {
+	if st == nil {
+		return func() {}, nil
+	}
+	name := "reserve:" + from.In(tz).Format(time.DateOnly)
+	owner := lockOwner()
+	for waiting := false; ; waiting = true {
+		ok, err := st.TryLock(ctx, name, owner, reservationLockTTL)
+		if err != nil {
+			zap.L().Warn("failed to lock the reservation, reserving without the lock", zap.Error(err))
+			return func() {}, nil
+		}
+		if ok {
+			break
+		}
+		if !waiting {
+			zap.L().Info("another process is reserving the lessons on the day, waiting for it", zap.String("lock", name))
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to lock the reservation: %w", ctx.Err())
+		case <-time.After(reservationLockPoll):
+		}
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(reservationLockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if ok, err := st.TryLock(context.WithoutCancel(ctx), name, owner, reservationLockTTL); err != nil || !ok {
+					zap.L().Warn("failed to extend the lock of the reservation", zap.Bool("lost", !ok), zap.Error(err))
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+		if err := st.Unlock(context.WithoutCancel(ctx), name, owner); err != nil {
+			zap.L().Warn("failed to unlock the reservation", zap.Error(err))
+		}
+	}, nil
+}
+
+// lockOwner returns the id of this process unique among the hosts and the containers sharing the store.
+func lockOwner() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 8)
+	rand.Read(b)
+	return fmt.Sprintf("%s:%d:%s", host, os.Getpid(), hex.EncodeToString(b))
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+	"github.com/musaprg/rarejobctl/librarejob/librarejobtest"
+)
+
+func TestParseSlot(t *testing.T) {
+	tz = time.FixedZone("Asia/Tokyo", 9*60*60)
+	want := time.Date(2022, 12, 27, 21, 0, 0, 0, tz)
+	tests := []struct {
+		name    string
+		s       string
+		want    time.Time
+		wantErr bool
+	}{
+		{"RFC 3339", "2022-12-27T21:00:00+09:00", want, false},
+		{"without seconds", "2022-12-27T21:00+09:00", want, false},
+		{"in UTC", "2022-12-27T12:00:00Z", want, false},
+		{"in UTC without seconds", "2022-12-27T12:00Z", want, false},
+		{"without timezone", "2022-12-27T21:00:00", time.Time{}, true},
+		{"date and time with space", "2022-12-27 21:00", time.Time{}, true},
+		{"empty", "", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSlot(tt.s)
+			if tt.wantErr {
+				if !errors.Is(err, errInvalidConfig) {
+					t.Errorf("parseSlot(%q) = %v, %v, want errInvalidConfig", tt.s, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSlot(%q) = %v", tt.s, err)
+			}
+			if !got.Equal(tt.want) || got.Location() != tz {
+				t.Errorf("parseSlot(%q) = %s, want %s", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryableReservation(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"no tutors available", librarejob.ErrNoTutorsAvailable, true},
+		{"slot taken", librarejob.ErrSlotTaken, true},
+		{"timeout", context.DeadlineExceeded, true},
+		{"invalid options", fmt.Errorf("%w: margin", librarejob.ErrInvalidOptions), false},
+		{"spread across two days", librarejob.ErrSpreadAcrossTwoDays, false},
+		{"conflict", librarejob.ErrConflict, false},
+		{"maintenance", librarejob.ErrSiteMaintenance, false},
+		{"exact slot unavailable", fmt.Errorf("%w at 21:00", librarejob.ErrSlotUnavailable), false},
+		{"unverified", librarejob.ErrReservationUnverified, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryableReservation(tt.err); got != tt.want {
+				t.Errorf("retryableReservation(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsLoopback(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"127.0.0.1:50051", true},
+		{"[::1]:50051", true},
+		{"localhost:50051", true},
+		{":50051", false},
+		{"0.0.0.0:50051", false},
+		{"192.168.1.10:50051", false},
+		{"example.com:50051", false},
+		{"invalid", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := isLoopback(tt.addr); got != tt.want {
+				t.Errorf("isLoopback(%q) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginSessionCache(t *testing.T) {
+	t.Setenv("RAREJOB_EMAIL", "fixture@example.com")
+	t.Setenv("RAREJOB_PASSWORD", "fixture")
+	*dbPath = filepath.Join(t.TempDir(), "history.db")
+	t.Cleanup(func() { *dbPath = "" })
+	ctx := context.Background()
+	newClient := func(email string) *librarejobtest.Client {
+		c := librarejobtest.NewClient(nil)
+		c.Email, c.Password = email, "fixture"
+		return c
+	}
+	logins := func(c *librarejobtest.Client) int {
+		return len(c.CallsOf("Login"))
+	}
+
+	first := newClient("fixture@example.com")
+	if err := login(ctx, first); err != nil {
+		t.Fatalf("login() = %v", err)
+	}
+	if logins(first) != 1 {
+		t.Errorf("login() without the cache called Login %d times, want 1", logins(first))
+	}
+
+	// the next process resumes the session cached by the first one
+	second := newClient("fixture@example.com")
+	if err := login(ctx, second); err != nil {
+		t.Fatalf("login() = %v", err)
+	}
+	if logins(second) != 0 {
+		t.Errorf("login() with the cache called Login %d times, want 0", logins(second))
+	}
+
+	// the session rejected by the site falls back to the password
+	third := newClient("fixture@example.com")
+	third.FailNext("ResumeSession", librarejob.ErrSessionExpired)
+	if err := login(ctx, third); err != nil {
+		t.Fatalf("login() = %v", err)
+	}
+	if logins(third) != 1 {
+		t.Errorf("login() with the expired cache called Login %d times, want 1", logins(third))
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+	"github.com/musaprg/rarejobctl/notify"
+	"go.uber.org/zap"
+)
+
+// runMaterial fetches the material assigned to an upcoming lesson and prints it in Markdown, or JSON with -output json.
+// With -dir, it's also saved as <start time>.md in the directory to read it before the lesson.
+func runMaterial(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("material", flag.ContinueOnError)
+	at := fs.String("at", "", "start time of the reservation formatted in \"YYYY-MM-DD HH:MM\" (default: the next reservation)")
+	dir := fs.String("dir", "", "directory to save the material as a Markdown file into")
+	notifyMaterial := fs.Bool("notify", false, "send the preview of the material to the configured notifiers as well")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+
+	var startAt time.Time
+	if *at != "" {
+		s, err := time.ParseInLocation("2006-01-02 15:04", *at, tz)
+		if err != nil {
+			return fmt.Errorf("%w: invalid start time: %w", errInvalidConfig, err)
+		}
+		startAt = s
+	}
+
+	opts, err := newClientOpts()
+	if err != nil {
+		return err
+	}
+	rc, err := librarejob.NewClient(opts)
+	if err != nil {
+		return fmt.Errorf("failed to create rarejob client: %w", err)
+	}
+	defer func() {
+		if err := rc.Teardown(); err != nil {
+			zap.L().Warn("failed to tear down rarejob client", zap.Error(err))
+		}
+	}()
+
+	if err := login(ctx, rc); err != nil {
+		return fmt.Errorf("failed to login: %w", err)
+	}
+
+	m, err := rc.LessonMaterial(ctx, startAt)
+	if err != nil {
+		return fmt.Errorf("failed to fetch lesson material: %w", err)
+	}
+	if *dir != "" {
+		if err := saveMaterial(*dir, m); err != nil {
+			return err
+		}
+	}
+	if *notifyMaterial {
+		notifyReminder(ctx, notify.Reminder{
+			Kind:     "lesson_material",
+			Message:  fmt.Sprintf("the material of your lesson at %s is ready. preview it before the lesson!", m.StartAt.In(tz).Format("2006-01-02 15:04")),
+			URL:      m.URL,
+			Material: m,
+		})
+	}
+
+	return printResult(m, func(w io.Writer) {
+		writeMaterialMarkdown(w, m)
+	})
+}
+
+func saveMaterial(dir string, m *librarejob.Material) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create material directory: %w", err)
+	}
+	f, err := os.Create(filepath.Join(dir, m.StartAt.In(tz).Format("2006-01-02-1504")+".md"))
+	if err != nil {
+		return fmt.Errorf("failed to save material: %w", err)
+	}
+	defer f.Close()
+	writeMaterialMarkdown(f, m)
+	return f.Close()
+}
+
+func writeMaterialMarkdown(w io.Writer, m *librarejob.Material) {
+	fmt.Fprintf(w, "# %s\n\n", m.Title)
+	fmt.Fprintf(w, "- Lesson: %s\n", m.StartAt.In(tz).Format("2006-01-02 15:04"))
+	fmt.Fprintf(w, "- URL: %s\n\n", m.URL)
+	for i, b := range m.Blocks {
+		switch b.Kind {
+		case librarejob.MaterialHeading:
+			// the title is the only h1
+			fmt.Fprintf(w, "%s %s\n\n", strings.Repeat("#", max(b.Level, 2)), b.Text)
+		case librarejob.MaterialListItem:
+			fmt.Fprintf(w, "- %s\n", b.Text)
+			// a blank line closes the list
+			if i+1 == len(m.Blocks) || m.Blocks[i+1].Kind != librarejob.MaterialListItem {
+				fmt.Fprintln(w)
+			}
+		default:
+			fmt.Fprintf(w, "%s\n\n", b.Text)
+		}
+	}
+}
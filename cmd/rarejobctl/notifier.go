@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+	"github.com/musaprg/rarejobctl/notify"
+	"go.uber.org/zap"
+)
+
+// notifyTimeout bounds each notification, which is sent even after the context of the command is done.
+const notifyTimeout = 30 * time.Second
+
+// notifiers returns every notifier configured in the environment, which are notified at once.
+var notifiers = sync.OnceValue(func() notify.Multi {
+	m, err := notify.Configured()
+	if err != nil {
+		zap.L().Warn("failed to configure notifiers", zap.Error(err))
+	}
+	if len(m) == 0 {
+		zap.L().Warn("no notifier is configured")
+	}
+	return m
+})
+
+// notifyEvent notifies the event with f, only logging the failure since the notifications must not fail the command.
+func notifyEvent(ctx context.Context, event string, f func(ctx context.Context, n notify.Notifier) error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), notifyTimeout)
+	defer cancel()
+	if err := f(ctx, notifiers()); err != nil {
+		zap.L().Warn("failed to notify", zap.String("event", event), zap.Error(err))
+	}
+}
+
+func notifyReserved(ctx context.Context, r *librarejob.Reserve) {
+	notifyEvent(ctx, "reserved", func(ctx context.Context, n notify.Notifier) error { return n.OnReserved(ctx, r) })
+}
+
+func notifyFailed(ctx context.Context, f notify.Failure) {
+	notifyEvent(ctx, "failed", func(ctx context.Context, n notify.Notifier) error { return n.OnFailed(ctx, f) })
+}
+
+func notifyCancelled(ctx context.Context, r *librarejob.Reserve) {
+	notifyEvent(ctx, "cancelled", func(ctx context.Context, n notify.Notifier) error { return n.OnCancelled(ctx, r) })
+}
+
+func notifyReminder(ctx context.Context, r notify.Reminder) {
+	notifyEvent(ctx, "reminder", func(ctx context.Context, n notify.Notifier) error { return n.OnReminder(ctx, r) })
+}
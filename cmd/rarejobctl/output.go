@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+)
+
+const (
+	outputText  = "text"
+	outputJSON  = "json"
+	outputTable = "table"
+	outputCSV   = "csv"
+	// outputNDJSON writes each element of the result as a line of JSON. The tutors command streams the tutors
+	// as soon as they are scraped.
+	outputNDJSON = "ndjson"
+)
+
+var output = flag.String("output", outputText, "output format of the command result (text, json, ndjson, table or csv), logs are written to stderr")
+
+// tabular is implemented by the command results which can be rendered as a table or CSV.
+type tabular interface {
+	header() []string
+	rows() [][]string
+}
+
+func validateOutput() error {
+	switch *output {
+	case outputText, outputJSON, outputNDJSON, outputTable, outputCSV:
+		return nil
+	default:
+		return fmt.Errorf("%w: invalid output format: %s", errInvalidConfig, *output)
+	}
+}
+
+// printResult writes the command result to stdout in the selected output format.
+// text is called to render the result for the text format.
+func printResult(v interface{}, text func(w io.Writer)) error {
+	switch *output {
+	case outputJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case outputNDJSON:
+		enc := json.NewEncoder(os.Stdout)
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice {
+			for i := 0; i < rv.Len(); i++ {
+				if err := enc.Encode(rv.Index(i).Interface()); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return enc.Encode(v)
+	case outputTable:
+		t, ok := v.(tabular)
+		if !ok {
+			return fmt.Errorf("output format %s is not supported by this command", *output)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(t.header(), "\t"))
+		for _, r := range t.rows() {
+			fmt.Fprintln(tw, strings.Join(r, "\t"))
+		}
+		return tw.Flush()
+	case outputCSV:
+		t, ok := v.(tabular)
+		if !ok {
+			return fmt.Errorf("output format %s is not supported by this command", *output)
+		}
+		w := csv.NewWriter(os.Stdout)
+		if err := w.Write(t.header()); err != nil {
+			return err
+		}
+		if err := w.WriteAll(t.rows()); err != nil {
+			return err
+		}
+		return w.Error()
+	default:
+		text(os.Stdout)
+		return nil
+	}
+}
+
+// printError writes the error to stdout if the output format is machine-readable.
+// For the text format, the error is only logged.
+func printError(err error) {
+	if *output != outputJSON && *output != outputNDJSON {
+		return
+	}
+	_ = printResult(struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}{
+		Error: err.Error(),
+		Code:  librarejob.ErrorCode(err),
+	}, nil)
+}
+
+// tutorsResult renders the tutor search result.
+type tutorsResult librarejob.Tutors
+
+func (r tutorsResult) header() []string {
+	return []string{"ID", "NAME", "RATING", "AVAILABLE SLOTS"}
+}
+
+func (r tutorsResult) rows() [][]string {
+	rows := make([][]string, 0, len(r))
+	for _, t := range r {
+		var slots []string
+		for _, s := range t.OpenSlots() {
+			slots = append(slots, s.Format("15:04"))
+		}
+		rows = append(rows, []string{t.ID, t.Name, strconv.FormatFloat(t.Rating, 'f', 2, 64), strings.Join(slots, " ")})
+	}
+	return rows
+}
+
+// reserveResult renders the result of the reservation.
+type reserveResult librarejob.Reserve
+
+func (r *reserveResult) header() []string {
+	return []string{"TYPE", "NAME", "START", "END", "DRY RUN", "ALREADY EXISTS"}
+}
+
+func (r *reserveResult) rows() [][]string {
+	return [][]string{{string(r.Type), r.Name, r.StartAt.Format(time.RFC3339), r.EndAt.Format(time.RFC3339), strconv.FormatBool(r.DryRun), strconv.FormatBool(r.AlreadyExists)}}
+}
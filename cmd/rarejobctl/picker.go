@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+	"github.com/musaprg/rarejobctl/store"
+	"go.uber.org/zap"
+)
+
+var interactive = flag.Bool("interactive", false, "list the available tutors and slots numbered and reserve the one picked at the prompt instead of the first one")
+
+// pickedSlot is a slot of a tutor picked by the user.
+type pickedSlot struct {
+	tutor librarejob.Tutor
+	slot  time.Time
+}
+
+// pickableSlots returns every available slot of the tutors, earliest first.
+// The search result order is kept among the tutors in the same slot.
+func pickableSlots(tutors librarejob.Tutors) []pickedSlot {
+	var items []pickedSlot
+	for _, t := range tutors {
+		for _, s := range t.OpenSlots() {
+			items = append(items, pickedSlot{tutor: t, slot: s})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].slot.Before(items[j].slot) })
+	return items
+}
+
+// request returns req restricted to the picked slot. The window to search again contains only the slot
+// since the next one starts 30 minutes later, or the slot and the next one if req is consecutive.
+func (p pickedSlot) request(req librarejob.ReserveRequest) librarejob.ReserveRequest {
+	req.From = p.slot
+	req.Margin = 25 * time.Minute
+	if req.Consecutive {
+		req.Margin += 30 * time.Minute
+	}
+	req.Tutor = p.tutor.ID
+	if req.Tutor == "" {
+		req.Tutor = p.tutor.Name
+	}
+	return req
+}
+
+// reserveInteractively logs in, lists the tutors available in the window of req, and reserves the slot
+// the user picks at the prompt. It returns nil without an error if the user picks nothing.
+func reserveInteractively(ctx context.Context, rc librarejob.Client, st store.Store, req librarejob.ReserveRequest) (*librarejob.Reserve, error) {
+	if err := login(ctx, rc); err != nil {
+		return nil, fmt.Errorf("failed to login: %w", err)
+	}
+	tutors, err := rc.SearchTutors(ctx, req.From, req.Margin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search tutors: %w", err)
+	}
+	items := pickableSlots(tutors)
+	if len(items) == 0 {
+		return nil, fmt.Errorf("failed to reserve tutor: %w", librarejob.ErrNoTutorsAvailable)
+	}
+
+	picked, err := promptSlot(ctx, bufio.NewReader(os.Stdin), os.Stderr, items)
+	if err != nil {
+		return nil, err
+	}
+	if picked == nil {
+		return nil, nil
+	}
+
+	preq := picked.request(req)
+	r, err := rc.Reserve(ctx, preq)
+	if err != nil {
+		recordHistory(ctx, st, attemptRecord(preq.From, preq.Margin, err))
+		return nil, fmt.Errorf("failed to reserve tutor: %w", err)
+	}
+	recordHistory(ctx, st, reservationRecord(r))
+	return r, nil
+}
+
+// promptSlot lists the items numbered on out and asks the user to pick one and confirm it.
+// It returns nil if the user answers nothing or declines the confirmation.
+func promptSlot(ctx context.Context, in *bufio.Reader, out io.Writer, items []pickedSlot) (*pickedSlot, error) {
+	for i, it := range items {
+		fmt.Fprintf(out, "%3d) %s  %-24s  %.2f\n", i+1, it.slot.Format("15:04"), it.tutor.Name, it.tutor.Rating)
+	}
+	for {
+		fmt.Fprintf(out, "Pick a slot [1-%d] (empty to abort): ", len(items))
+		answer, err := readAnswer(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		if answer == "" {
+			return nil, nil
+		}
+		n, err := strconv.Atoi(answer)
+		if err != nil || n < 1 || n > len(items) {
+			fmt.Fprintf(out, "invalid number: %s\n", answer)
+			continue
+		}
+
+		it := items[n-1]
+		fmt.Fprintf(out, "Reserve %s at %s? [y/N]: ", it.tutor.Name, it.slot.Format("15:04"))
+		answer, err = readAnswer(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		if a := strings.ToLower(answer); a != "y" && a != "yes" {
+			return nil, nil
+		}
+		return &it, nil
+	}
+}
+
+// readAnswer reads a line from in without the surrounding spaces, or returns the error of ctx if it's done first.
+func readAnswer(ctx context.Context, in *bufio.Reader) (string, error) {
+	type answer struct {
+		line string
+		err  error
+	}
+	done := make(chan answer, 1)
+	go func() {
+		line, err := in.ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+		done <- answer{strings.TrimSpace(line), err}
+	}()
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case a := <-done:
+		if a.err == io.EOF {
+			zap.L().Debug("stdin is closed, aborting the prompt")
+			return "", nil
+		}
+		return a.line, a.err
+	}
+}
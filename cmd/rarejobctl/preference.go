@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+	"github.com/musaprg/rarejobctl/store"
+)
+
+var (
+	windowsFlag  = flag.String("windows", "", "comma-separated extra windows of the start time formatted in HH:MM-HH:MM, tried after the window of -time and -margin")
+	windowDays   = flag.Int("window-days", 1, "number of days from the date to repeat the windows on, the earlier day preferred")
+	weekdaysFlag = flag.String("weekdays", "", "comma-separated weekdays to reserve on, e.g. mon,wed,fri, empty means any")
+	preferTimes  = flag.String("prefer-times", "", "comma-separated start times formatted in HH:MM preferred over the others in the windows, the most preferred first")
+	earliestFlag = flag.String("earliest", "", "earliest start time formatted in YYYY-MM-DD HH:MM")
+	latestFlag   = flag.String("latest", "", "latest start time formatted in YYYY-MM-DD HH:MM")
+)
+
+// weekdays are the weekdays by the first three letters of their names.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// preference returns the preference specified by the flags with the window of from and -margin first,
+// or nil if no flag of the preference is given, to reserve the first slot in the window.
+func preference(from time.Time) (*librarejob.Preference, error) {
+	if *windowsFlag == "" && *windowDays == 1 && *weekdaysFlag == "" && *preferTimes == "" && *earliestFlag == "" && *latestFlag == "" {
+		return nil, nil
+	}
+	if *windowDays < 1 {
+		return nil, fmt.Errorf("%w: -window-days must be positive", errInvalidConfig)
+	}
+
+	windows := []librarejob.Window{{From: from, Margin: time.Minute * time.Duration(*margin)}}
+	for _, w := range splitList(*windowsFlag) {
+		start, end, ok := strings.Cut(w, "-")
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid window format: %s", errInvalidConfig, w)
+		}
+		sh, sm, err := parseHourMinute(start)
+		if err != nil {
+			return nil, err
+		}
+		eh, em, err := parseHourMinute(end)
+		if err != nil {
+			return nil, err
+		}
+		wf := time.Date(from.Year(), from.Month(), from.Day(), sh, sm, 0, 0, tz)
+		wb := time.Date(from.Year(), from.Month(), from.Day(), eh, em, 0, 0, tz)
+		if wb.Before(wf) {
+			return nil, fmt.Errorf("%w: window must end after it starts: %s", errInvalidConfig, w)
+		}
+		windows = append(windows, librarejob.Window{From: wf, Margin: wb.Sub(wf)})
+	}
+
+	pref := &librarejob.Preference{PreferredTimes: splitList(*preferTimes)}
+	for d := 0; d < *windowDays; d++ {
+		for _, w := range windows {
+			pref.Windows = append(pref.Windows, librarejob.Window{From: w.From.AddDate(0, 0, d), Margin: w.Margin})
+		}
+	}
+	for _, s := range splitList(*weekdaysFlag) {
+		wd, ok := weekdays[strings.ToLower(s)[:min(len(s), 3)]]
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid weekday: %s", errInvalidConfig, s)
+		}
+		pref.Weekdays = append(pref.Weekdays, wd)
+	}
+	var err error
+	if pref.Earliest, err = parseDateTimeFlag(*earliestFlag); err != nil {
+		return nil, err
+	}
+	if pref.Latest, err = parseDateTimeFlag(*latestFlag); err != nil {
+		return nil, err
+	}
+	if err := pref.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+	return pref, nil
+}
+
+// findReservation returns the reservation in the history in any window of pref, or in the window of from and margin
+// if pref is nil. The windows include their ends as the ones of librarejob do.
+func findReservation(ctx context.Context, st store.Store, from time.Time, margin time.Duration, pref *librarejob.Preference) (*store.Record, error) {
+	windows := []librarejob.Window{{From: from, Margin: margin}}
+	if pref != nil {
+		windows = pref.Windows
+	}
+	for _, w := range windows {
+		// the history is kept in seconds
+		rec, err := st.FindReservation(ctx, w.From, w.From.Add(w.Margin+time.Second))
+		if err != nil || rec != nil {
+			return rec, err
+		}
+	}
+	return nil, nil
+}
+
+// parseDateTimeFlag parses the time formatted in YYYY-MM-DD HH:MM in -timezone, or returns zero if s is empty.
+func parseDateTimeFlag(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02 15:04", s, tz)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid time: %w", errInvalidConfig, err)
+	}
+	return t, nil
+}
+
+// splitList splits the comma-separated list, dropping the empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+var (
+	pushgatewayURL = flag.String("pushgateway", "", "URL of the Prometheus Pushgateway to push the metrics of the run to at the end, e.g. http://pushgateway:9091")
+	pushJob        = flag.String("push-job", "rarejobctl", "job name of the metrics pushed to the Pushgateway")
+)
+
+// pushMetrics pushes the metrics of the clients and of the run started at start which ended with err,
+// grouped by the command, so that the short-lived runs from cron can be monitored.
+func pushMetrics(start time.Time, err error) error {
+	if *pushgatewayURL == "" {
+		return nil
+	}
+	cmd := flag.Arg(0)
+	if cmd == "" {
+		cmd = "reserve"
+	}
+
+	reg := prometheus.NewRegistry()
+	if err := librarejob.RegisterMetrics(reg); err != nil {
+		return fmt.Errorf("failed to register metrics: %w", err)
+	}
+	success := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "rarejobctl",
+		Name:      "run_success",
+		Help:      "1 if the last run succeeded, otherwise 0.",
+	})
+	duration := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "rarejobctl",
+		Name:      "run_duration_seconds",
+		Help:      "Time taken by the last run.",
+	})
+	exit := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "rarejobctl",
+		Name:      "run_exit_code",
+		Help:      "Exit code of the last run.",
+	})
+	completion := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "rarejobctl",
+		Name:      "run_last_completion_timestamp_seconds",
+		Help:      "Unix time when the last run finished.",
+	})
+	reg.MustRegister(success, duration, exit, completion)
+
+	if err == nil {
+		success.Set(1)
+	}
+	duration.Set(time.Since(start).Seconds())
+	exit.Set(float64(exitCode(err)))
+	completion.SetToCurrentTime()
+
+	if err := push.New(*pushgatewayURL, *pushJob).Gatherer(reg).Grouping("command", cmd).Push(); err != nil {
+		return fmt.Errorf("failed to push metrics to %s: %w", *pushgatewayURL, err)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+	"github.com/musaprg/rarejobctl/notify"
+	"github.com/musaprg/rarejobctl/store"
+	"go.uber.org/zap"
+)
+
+// errRebookFailed is returned when the old reservation has been cancelled but the new one couldn't be made.
+var errRebookFailed = errors.New("the old reservation has been cancelled but the new one was not made")
+
+// runRebook cancels the reservation at -at and reserves a new slot in the window specified by the global flags.
+// Since the site can't swap reservations atomically, it checks the new window has available tutors before cancelling,
+// and reports loudly if the new reservation fails after the cancellation.
+func runRebook(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("rebook", flag.ContinueOnError)
+	at := fs.String("at", "", "start time of the reservation to cancel formatted in \"YYYY-MM-DD HH:MM\"")
+	forceCancel := fs.Bool("force-cancel", false, "cancel even after the free cancellation deadline, which consumes the lesson")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+	if *at == "" {
+		return fmt.Errorf("%w: -at is required", errInvalidConfig)
+	}
+	oldStartAt, err := time.ParseInLocation("2006-01-02 15:04", *at, tz)
+	if err != nil {
+		return fmt.Errorf("%w: invalid start time: %w", errInvalidConfig, err)
+	}
+	from, err := parseFrom()
+	if err != nil {
+		return err
+	}
+	typ, err := librarejob.ParseReservationType(*reservationType)
+	if err != nil {
+		return err
+	}
+	var busy []librarejob.Interval
+	if *busyFile != "" {
+		if busy, err = loadBusyFile(*busyFile); err != nil {
+			return err
+		}
+	}
+
+	st := openStore()
+	defer closeStore(st)
+
+	// hold the locks of both days over cancelling and reserving not to race with the reservations of the other processes,
+	// in the order of the days not to deadlock with another rebook in the opposite direction
+	if !*dryRun {
+		days := []time.Time{oldStartAt, from}
+		if from.Before(oldStartAt) {
+			days = []time.Time{from, oldStartAt}
+		}
+		for i, d := range days {
+			if i > 0 && d.In(tz).Format(time.DateOnly) == days[0].In(tz).Format(time.DateOnly) {
+				break
+			}
+			unlock, err := lockReservation(ctx, st, d)
+			if err != nil {
+				return err
+			}
+			defer unlock()
+		}
+	}
+
+	opts, err := newClientOpts()
+	if err != nil {
+		return err
+	}
+	rc, err := librarejob.NewClient(opts)
+	if err != nil {
+		return fmt.Errorf("failed to create rarejob client: %w", err)
+	}
+	defer func() {
+		if err := rc.Teardown(); err != nil {
+			zap.L().Warn("failed to tear down rarejob client", zap.Error(err))
+		}
+	}()
+
+	if err := login(ctx, rc); err != nil {
+		return fmt.Errorf("failed to login: %w", err)
+	}
+
+	// don't give up the old lesson if there is obviously nothing to book instead
+	if typ == librarejob.ReservationTypeLesson {
+		if _, err := rc.SearchTutors(ctx, from, time.Minute*time.Duration(*margin)); err != nil {
+			return fmt.Errorf("the old reservation was kept since no new slot is available: %w", err)
+		}
+	}
+
+	old, err := rc.Cancel(ctx, oldStartAt, *forceCancel)
+	if err != nil {
+		return fmt.Errorf("failed to cancel the old reservation, nothing was changed: %w", err)
+	}
+	if !old.DryRun {
+		recordHistory(ctx, st, &store.Record{
+			Kind:      store.KindCancellation,
+			TutorName: old.Name,
+			StartAt:   old.StartAt,
+			EndAt:     old.EndAt,
+		})
+	}
+
+	r, err := reserveWithRetry(ctx, rc, st, librarejob.ReserveRequest{
+		Type:        typ,
+		From:        from,
+		Margin:      time.Minute * time.Duration(*margin),
+		Busy:        busy,
+		Force:       *force,
+		Consecutive: *consecutive,
+		// the old one is still there in dry-run mode
+		Replacing: oldStartAt,
+	})
+	if err != nil {
+		if !old.DryRun {
+			notifyFailed(ctx, notify.Failure{Action: "rebook", Err: err, Cancelled: old})
+		}
+		return fmt.Errorf("%w (cancelled: %s at %s): %w", errRebookFailed, old.Name, old.StartAt.Format(time.DateTime), err)
+	}
+
+	reserved = r
+
+	if !r.DryRun {
+		notifyCancelled(ctx, old)
+		notifyReserved(ctx, r)
+	}
+
+	result := rebookResult{Cancelled: old, Reserved: r}
+	return printResult(result, func(w io.Writer) {
+		prefix := ""
+		if r.DryRun {
+			prefix = "[dry-run] "
+		}
+		fmt.Fprintf(w, "%scancelled tutor %s at %s, reserved tutor %s from %s to %s\n", prefix, old.Name, old.StartAt, r.Name, r.StartAt, r.EndAt)
+	})
+}
+
+// rebookResult renders the cancelled and the new reservations.
+type rebookResult struct {
+	Cancelled *librarejob.Reserve `json:"cancelled"`
+	Reserved  *librarejob.Reserve `json:"reserved"`
+}
+
+func (r rebookResult) header() []string {
+	return append([]string{"ACTION"}, (*reserveResult)(r.Reserved).header()...)
+}
+
+func (r rebookResult) rows() [][]string {
+	return [][]string{
+		append([]string{"cancelled"}, (*reserveResult)(r.Cancelled).rows()[0]...),
+		append([]string{"reserved"}, (*reserveResult)(r.Reserved).rows()[0]...),
+	}
+}
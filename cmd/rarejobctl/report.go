@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+	"go.uber.org/zap"
+)
+
+// runReport fetches the lesson report of the given lesson ids and prints it in Markdown, or JSON with -output json.
+// With -dir, each report is also saved as <lesson id>.md in the directory to archive them.
+func runReport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	dir := fs.String("dir", "", "directory to save the reports as Markdown files into")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("%w: lesson id is required, which is shown by history lessons", errInvalidConfig)
+	}
+
+	opts, err := newClientOpts()
+	if err != nil {
+		return err
+	}
+	rc, err := librarejob.NewClient(opts)
+	if err != nil {
+		return fmt.Errorf("failed to create rarejob client: %w", err)
+	}
+	defer func() {
+		if err := rc.Teardown(); err != nil {
+			zap.L().Warn("failed to tear down rarejob client", zap.Error(err))
+		}
+	}()
+
+	if err := login(ctx, rc); err != nil {
+		return fmt.Errorf("failed to login: %w", err)
+	}
+
+	reports := make([]*librarejob.LessonReport, 0, fs.NArg())
+	for _, id := range fs.Args() {
+		r, err := rc.FetchLessonReport(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to fetch lesson report: %w", err)
+		}
+		if *dir != "" {
+			if err := saveReport(*dir, r); err != nil {
+				return err
+			}
+		}
+		reports = append(reports, r)
+	}
+
+	return printResult(reports, func(w io.Writer) {
+		for _, r := range reports {
+			writeReportMarkdown(w, r)
+		}
+	})
+}
+
+func saveReport(dir string, r *librarejob.LessonReport) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+	f, err := os.Create(filepath.Join(dir, filepath.Base(r.LessonID)+".md"))
+	if err != nil {
+		return fmt.Errorf("failed to save report: %w", err)
+	}
+	defer f.Close()
+	writeReportMarkdown(f, r)
+	return f.Close()
+}
+
+func writeReportMarkdown(w io.Writer, r *librarejob.LessonReport) {
+	fmt.Fprintf(w, "# Lesson %s\n\n", r.LessonID)
+	if !r.StartAt.IsZero() {
+		fmt.Fprintf(w, "- Date: %s\n", r.StartAt.Format("2006-01-02 15:04"))
+	}
+	fmt.Fprintf(w, "- Tutor: %s\n", r.TutorName)
+	fmt.Fprintf(w, "- Material: %s\n\n", r.Material)
+	fmt.Fprintf(w, "## Comment\n\n%s\n\n", r.Comment)
+	if len(r.Corrections) > 0 {
+		fmt.Fprintf(w, "## Corrections\n\n")
+		for _, c := range r.Corrections {
+			fmt.Fprintf(w, "- ~~%s~~ → %s\n", c.Original, c.Corrected)
+		}
+		fmt.Fprintln(w)
+	}
+}
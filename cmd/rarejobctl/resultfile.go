@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+)
+
+var resultFile = flag.String("result-file", "", "JSON file to write the summary of the run into on every exit path, e.g. for Kubernetes CronJobs")
+
+// reserved is the reservation made by the run, reported in the result file.
+var reserved *librarejob.Reserve
+
+// runResult is the summary of the run written into the result file.
+type runResult struct {
+	Command string `json:"command"`
+	// Outcome is success, dry_run, already_exists or the error code.
+	Outcome  string `json:"outcome"`
+	ExitCode int    `json:"exit_code"`
+	// TutorName, StartAt and EndAt are set if the run has made a reservation.
+	TutorName  string     `json:"tutor_name,omitempty"`
+	StartAt    *time.Time `json:"start_at,omitempty"`
+	EndAt      *time.Time `json:"end_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	ErrorCode  string     `json:"error_code,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt time.Time  `json:"finished_at"`
+	// DurationSeconds is the time taken by the run.
+	DurationSeconds float64 `json:"duration_seconds"`
+}
+
+// errorClass returns the code of the failure class of err, which is invalid_config for the errors of the flags.
+func errorClass(err error) string {
+	if errors.Is(err, errInvalidConfig) {
+		return "invalid_config"
+	}
+	return librarejob.ErrorCode(err)
+}
+
+// writeResultFile writes the summary of the run started at start which ended with err, if -result-file is given.
+// The file is replaced at once so that the readers never see a partial result.
+func writeResultFile(start time.Time, err error) {
+	if *resultFile == "" {
+		return
+	}
+	now := time.Now()
+	cmd := flag.Arg(0)
+	if cmd == "" {
+		cmd = "reserve"
+	}
+	res := runResult{
+		Command:         cmd,
+		Outcome:         "success",
+		ExitCode:        exitCode(err),
+		StartedAt:       start,
+		FinishedAt:      now,
+		DurationSeconds: now.Sub(start).Seconds(),
+	}
+	if r := reserved; r != nil {
+		res.TutorName, res.StartAt, res.EndAt = r.Name, &r.StartAt, &r.EndAt
+		switch {
+		case r.AlreadyExists:
+			res.Outcome = "already_exists"
+		case r.DryRun:
+			res.Outcome = "dry_run"
+		}
+	}
+	if err != nil {
+		res.Outcome = errorClass(err)
+		res.Error = err.Error()
+		res.ErrorCode = res.Outcome
+	}
+
+	if werr := writeJSONFile(*resultFile, res); werr != nil {
+		// the logger may not be ready on the early exits
+		fmt.Fprintf(os.Stderr, "failed to write result file: %s\n", werr)
+	}
+}
+
+func writeJSONFile(path string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(b, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
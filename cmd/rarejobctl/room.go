@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+	"github.com/musaprg/rarejobctl/notify"
+	"go.uber.org/zap"
+)
+
+// runRoom prints the URL to enter the lesson room of an upcoming reservation.
+func runRoom(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("room", flag.ContinueOnError)
+	at := fs.String("at", "", "start time of the reservation formatted in \"YYYY-MM-DD HH:MM\" (default: the next reservation)")
+	notifyRoom := fs.Bool("notify", false, "send the URL to the configured notifiers as well")
+	attachMaterial := fs.Bool("material", false, "attach the preview of the material of the lesson to the notification of -notify")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+
+	var startAt time.Time
+	if *at != "" {
+		s, err := time.ParseInLocation("2006-01-02 15:04", *at, tz)
+		if err != nil {
+			return fmt.Errorf("%w: invalid start time: %w", errInvalidConfig, err)
+		}
+		startAt = s
+	}
+
+	opts, err := newClientOpts()
+	if err != nil {
+		return err
+	}
+	rc, err := librarejob.NewClient(opts)
+	if err != nil {
+		return fmt.Errorf("failed to create rarejob client: %w", err)
+	}
+	defer func() {
+		if err := rc.Teardown(); err != nil {
+			zap.L().Warn("failed to tear down rarejob client", zap.Error(err))
+		}
+	}()
+
+	if err := login(ctx, rc); err != nil {
+		return fmt.Errorf("failed to login: %w", err)
+	}
+
+	u, err := rc.LessonRoomURL(ctx, startAt)
+	if err != nil {
+		return fmt.Errorf("failed to get lesson room: %w", err)
+	}
+	if *notifyRoom {
+		reminder := notify.Reminder{
+			Kind:    "lesson_start",
+			Message: fmt.Sprintf("your lesson is about to start! enter the lesson room: %s", u),
+			URL:     u,
+		}
+		if *attachMaterial {
+			// the reminder is still worth sending without the material, e.g. for the free conversation
+			if m, err := rc.LessonMaterial(ctx, startAt); err != nil {
+				zap.L().Warn("failed to fetch lesson material, notifying without it", zap.Error(err))
+			} else {
+				reminder.Material = m
+			}
+		}
+		notifyReminder(ctx, reminder)
+	}
+
+	return printResult(struct {
+		URL string `json:"url"`
+	}{u}, func(w io.Writer) {
+		fmt.Fprintln(w, u)
+	})
+}
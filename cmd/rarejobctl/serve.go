@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+	"github.com/musaprg/rarejobctl/rarejobpb"
+	"github.com/musaprg/rarejobctl/server"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	grpccreds "google.golang.org/grpc/credentials"
+)
+
+// runServe serves the rarejob client over gRPC until the context is cancelled.
+func runServe(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	listen := fs.String("listen", "127.0.0.1:50051", "address to listen gRPC on, other than loopback only with the token of RAREJOB_SERVE_TOKEN or -token-file")
+	tokenFile := fs.String("token-file", "", "file of the token the callers must send as \"authorization: Bearer <token>\" instead of RAREJOB_SERVE_TOKEN")
+	tlsCert := fs.String("tls-cert", "", "certificate file to serve gRPC over TLS with -tls-key")
+	tlsKey := fs.String("tls-key", "", "private key file of -tls-cert")
+	metricsListen := fs.String("metrics-listen", ":9090", "address to serve the Prometheus metrics on /metrics and the health of the browser sessions on /healthz and /readyz, empty to disable")
+	sessions := fs.Int("sessions", 1, "number of the warm, logged-in browser sessions to serve the calls in parallel, each with its own selenium port from -selenium-port if local")
+	keepAlive := fs.Duration("keep-alive", 0, "interval of the health check of the idle browser sessions, which keeps them logged in and recreates the dead ones (default 5m), negative to disable")
+	maintenanceBackoff := fs.Duration("maintenance-backoff", 0, "wait before trying again when the site is under maintenance and the page doesn't tell when it ends (default 15m)")
+	qps := fs.Float64("qps", 0, "max navigations and element queries per second to RareJob across all the calls, 0 means no limit")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+
+	token, err := serveToken(*tokenFile)
+	if err != nil {
+		return err
+	}
+	if token == "" && !isLoopback(*listen) {
+		return fmt.Errorf("%w: the token is required to listen on %s, since the callers can reserve and cancel the lessons of the account", errInvalidConfig, *listen)
+	}
+	var serverOpts []grpc.ServerOption
+	if (*tlsCert == "") != (*tlsKey == "") {
+		return fmt.Errorf("%w: -tls-cert and -tls-key must be set together", errInvalidConfig)
+	}
+	if *tlsCert != "" {
+		creds, err := grpccreds.NewServerTLSFromFile(*tlsCert, *tlsKey)
+		if err != nil {
+			return fmt.Errorf("%w: failed to load the certificate: %w", errInvalidConfig, err)
+		}
+		serverOpts = append(serverOpts, grpc.Creds(creds))
+	}
+	if token != "" {
+		unary, stream := server.TokenAuth(token)
+		serverOpts = append(serverOpts, grpc.UnaryInterceptor(unary), grpc.StreamInterceptor(stream))
+	}
+
+	// pool is set once the browser sessions are started, and the server is not ready until then
+	var pool atomic.Pointer[librarejob.Pool]
+	if *metricsListen != "" {
+		if err := librarejob.RegisterMetrics(prometheus.DefaultRegisterer); err != nil {
+			return fmt.Errorf("failed to register metrics: %w", err)
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		mux.Handle("/healthz", healthHandler(&pool, false))
+		mux.Handle("/readyz", healthHandler(&pool, true))
+		ms := &http.Server{Addr: *metricsListen, Handler: mux}
+		go func() {
+			zap.L().Info("serving metrics", zap.String("address", *metricsListen))
+			if err := ms.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				zap.L().Error("failed to serve metrics", zap.Error(err))
+			}
+		}()
+		defer ms.Close()
+	}
+
+	opts, err := newClientOpts()
+	if err != nil {
+		return err
+	}
+	if *qps > 0 {
+		opts.Throttle.Limiter = rate.NewLimiter(rate.Limit(*qps), 1)
+	}
+	cred, err := credentials()
+	if err != nil {
+		return err
+	}
+	p, err := librarejob.NewPool(ctx, librarejob.PoolOpts{
+		Size: *sessions,
+		New: func(i int) (librarejob.Client, error) {
+			o := opts
+			if o.SeleniumHost == "" {
+				// each local selenium server listens on its own port
+				port := *o.SeleniumPort + i
+				o.SeleniumPort = &port
+			}
+			return librarejob.NewClient(o)
+		},
+		Credentials:        cred,
+		KeepAlive:          *keepAlive,
+		MaintenanceBackoff: *maintenanceBackoff,
+		Logger:             zap.L(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start browser sessions: %w", err)
+	}
+	defer func() {
+		pool.Store(nil)
+		if err := p.Close(); err != nil {
+			zap.L().Warn("failed to close browser sessions", zap.Error(err))
+		}
+	}()
+	pool.Store(p)
+
+	lis, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return fmt.Errorf("%w: failed to listen: %w", errInvalidConfig, err)
+	}
+	s := grpc.NewServer(serverOpts...)
+	rarejobpb.RegisterRarejobServer(s, server.New(p, cred.Email, cred.Password))
+
+	go func() {
+		<-ctx.Done()
+		zap.L().Info("shutting down gRPC server")
+		s.GracefulStop()
+	}()
+
+	zap.L().Info("serving gRPC", zap.String("address", lis.Addr().String()))
+	if err := s.Serve(lis); err != nil {
+		return fmt.Errorf("failed to serve gRPC: %w", err)
+	}
+	return nil
+}
+
+// serveToken returns the token of the callers read from path, or RAREJOB_SERVE_TOKEN if path is empty.
+func serveToken(path string) (string, error) {
+	if path == "" {
+		return os.Getenv("RAREJOB_SERVE_TOKEN"), nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to read the token: %w", errInvalidConfig, err)
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", fmt.Errorf("%w: the token file %s is empty", errInvalidConfig, path)
+	}
+	return token, nil
+}
+
+// isLoopback reports whether the address to listen on is only reachable from the host.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// healthHandler reports the health of the browser sessions in pool. It responds 503 if no session is alive,
+// or with ready, if no session is logged in, so that the orchestrators can restart the wedged server or stop
+// routing the calls to it. The server is alive but not ready while starting the sessions.
+func healthHandler(pool *atomic.Pointer[librarejob.Pool], ready bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res := struct {
+			OK       bool                       `json:"ok"`
+			Sessions []librarejob.SessionStatus `json:"sessions"`
+			// MaintenanceUntil is set while the site is under maintenance, when the calls fail fast.
+			MaintenanceUntil *time.Time `json:"maintenance_until,omitempty"`
+		}{Sessions: []librarejob.SessionStatus{}}
+		if p := pool.Load(); p != nil {
+			res.Sessions = p.Status()
+			for _, s := range res.Sessions {
+				if s.Alive && (!ready || s.LoggedIn) {
+					res.OK = true
+				}
+			}
+			if m := p.Maintenance(); m != nil {
+				res.MaintenanceUntil = &m.Until
+				// the process is healthy, but can't serve the calls until the maintenance ends
+				if ready {
+					res.OK = false
+				}
+			}
+		} else {
+			res.OK = !ready
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if !res.OK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if err := json.NewEncoder(w).Encode(res); err != nil {
+			zap.L().Warn("failed to write health", zap.Error(err))
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+	"go.uber.org/zap"
+)
+
+// runSetup downloads selenium server and the webdriver into the cache directory in advance.
+func runSetup(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("setup", flag.ContinueOnError)
+	browser := fs.String("browser", *seleniumBrowserName, "browser to download the webdriver for (firefox or chrome)")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+
+	checksums, err := parseDriverChecksums(*driverChecksums)
+	if err != nil {
+		return err
+	}
+	paths, err := librarejob.EnsureDrivers(ctx, librarejob.DriverOpts{
+		CacheDir:    *driverCacheDir,
+		BrowserName: *browser,
+		Logger:      zap.L(),
+		Checksums:   checksums,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set up drivers: %w", err)
+	}
+
+	return printResult(paths, func(w io.Writer) {
+		fmt.Fprintln(w, "selenium server:", paths.SeleniumPath)
+		if paths.GeckoDriverPath != "" {
+			fmt.Fprintln(w, "geckodriver:", paths.GeckoDriverPath)
+		}
+		if paths.ChromeDriverPath != "" {
+			fmt.Fprintln(w, "chromedriver:", paths.ChromeDriverPath)
+		}
+	})
+}
+
+// parseDriverChecksums parses the comma-separated file=sha256 pairs of -driver-checksums. It returns nil if s is empty.
+func parseDriverChecksums(s string) (map[string]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	checksums := make(map[string]string)
+	for _, kv := range strings.Split(s, ",") {
+		name, sum, ok := strings.Cut(kv, "=")
+		name, sum = strings.TrimSpace(name), strings.ToLower(strings.TrimSpace(sum))
+		if b, err := hex.DecodeString(sum); !ok || name == "" || err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("%w: invalid driver checksum: %s", errInvalidConfig, kv)
+		}
+		checksums[name] = sum
+	}
+	return checksums, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+	"github.com/musaprg/rarejobctl/sheets"
+	"go.uber.org/zap"
+)
+
+var (
+	sheetsID          = flag.String("sheets-id", os.Getenv("RAREJOB_SHEETS_ID"), "ID of the Google Sheet to append the results of tutors and tutors availability to, empty means no export")
+	sheetsCredentials = flag.String("sheets-credentials", os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"), "JSON key file of the service account to write the Google Sheet with")
+)
+
+// sheetsTimeout bounds the export to Google Sheets.
+const sheetsTimeout = 30 * time.Second
+
+// exportSheet appends the rows sampled at sampledAt to the sheet of the Google Sheet, if -sheets-id is given.
+// The sampled time is prepended to each row so that the sheet keeps the history of the exports.
+// The export is best effort, and its failure is only logged not to lose the result of the command.
+func exportSheet(ctx context.Context, sheet string, sampledAt time.Time, header []string, rows [][]string) {
+	if *sheetsID == "" {
+		return
+	}
+	if err := appendSheet(context.WithoutCancel(ctx), sheet, sampledAt, header, rows); err != nil {
+		zap.L().Warn("failed to export to google sheets", zap.String("sheet", sheet), zap.Error(err))
+		return
+	}
+	zap.L().Info("exported to google sheets", zap.String("sheet", sheet), zap.Int("rows", len(rows)))
+}
+
+func appendSheet(ctx context.Context, sheet string, sampledAt time.Time, header []string, rows [][]string) error {
+	if *sheetsCredentials == "" {
+		return fmt.Errorf("-sheets-credentials or GOOGLE_APPLICATION_CREDENTIALS is required")
+	}
+	b, err := os.ReadFile(*sheetsCredentials)
+	if err != nil {
+		return fmt.Errorf("failed to read credentials: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, sheetsTimeout)
+	defer cancel()
+	c, err := sheets.New(ctx, b, *sheetsID)
+	if err != nil {
+		return err
+	}
+
+	ts := sampledAt.In(tz).Format("2006-01-02 15:04:05")
+	values := make([][]string, 0, len(rows))
+	for _, r := range rows {
+		values = append(values, append([]string{ts}, r...))
+	}
+	return c.Append(ctx, sheet, append([]string{"SAMPLED AT"}, header...), values)
+}
+
+// exportTutors appends the tutors found in the search from the time to the tutors sheet.
+func exportTutors(ctx context.Context, sampledAt, from time.Time, tutors librarejob.Tutors) {
+	r := tutorsResult(tutors)
+	date := from.In(tz).Format(time.DateOnly)
+	rows := make([][]string, 0, len(tutors))
+	for _, row := range r.rows() {
+		rows = append(rows, append([]string{date}, row...))
+	}
+	exportSheet(ctx, "tutors", sampledAt, append([]string{"DATE"}, r.header()...), rows)
+}
+
+// exportAvailability appends the counts of the open slots to the availability sheet, one row per day and hour,
+// so that the sheet can be pivoted by the hour of day.
+func exportAvailability(ctx context.Context, sampledAt time.Time, h heatmap) {
+	var rows [][]string
+	for i, d := range h.Days {
+		for j, hour := range h.Hours {
+			rows = append(rows, []string{d, strconv.Itoa(hour), strconv.Itoa(h.Counts[i][j])})
+		}
+	}
+	exportSheet(ctx, "availability", sampledAt, []string{"DATE", "HOUR", "OPEN SLOTS"}, rows)
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/musaprg/rarejobctl/store"
+)
+
+// timeToBookWindow bounds how long before the reservation a failed attempt is regarded as a try for the same lesson.
+const timeToBookWindow = 24 * time.Hour
+
+// runStats aggregates the reservations and the failed attempts in the history database.
+func runStats(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	since := fs.String("since", "", "aggregate only the records created on or after the date formatted in YYYY-MM-DD")
+	top := fs.Int("top", 10, "number of the most-booked tutors to show, 0 means all")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+
+	q := store.Query{}
+	if *since != "" {
+		s, err := time.ParseInLocation("2006-01-02", *since, tz)
+		if err != nil {
+			return fmt.Errorf("%w: invalid date: %w", errInvalidConfig, err)
+		}
+		q.Since = s
+	}
+
+	st, err := openStoreOrError()
+	if err != nil {
+		return err
+	}
+	defer closeStore(st)
+
+	records, err := st.List(ctx, q)
+	if err != nil {
+		return err
+	}
+
+	s := aggregateStats(records, *top)
+	return printResult(s, func(w io.Writer) {
+		fmt.Fprintf(w, "reservations: %d, failed attempts: %d, success rate: %s\n", s.Reservations, s.Failures, formatRate(s.SuccessRate))
+		fmt.Fprintf(w, "average time to book: %s\n", time.Duration(s.AvgTimeToBookSeconds*float64(time.Second)).Round(time.Second))
+		fmt.Fprintln(w, "\nsuccess rate by time of day:")
+		for _, t := range s.ByTimeOfDay {
+			fmt.Fprintf(w, "  %s  %s (%d/%d)\n", t.Time, formatRate(t.SuccessRate), t.Reservations, t.Reservations+t.Failures)
+		}
+		fmt.Fprintln(w, "\nmost-booked tutors:")
+		for _, t := range s.TopTutors {
+			fmt.Fprintf(w, "  %-24s  %d\n", t.Name, t.Reservations)
+		}
+		fmt.Fprintln(w, "\nfailures by error class:")
+		for _, f := range s.FailuresByCode {
+			fmt.Fprintf(w, "  %-24s  %d\n", f.Code, f.Count)
+		}
+	})
+}
+
+// statsResult is the aggregation of the history. Dry-run records and cancellations are not counted.
+type statsResult struct {
+	Reservations int `json:"reservations"`
+	// Failures is the number of the failed attempts.
+	Failures    int     `json:"failures"`
+	SuccessRate float64 `json:"success_rate"`
+	// AvgTimeToBookSeconds is the average time from the first failed attempt for the lesson to its reservation,
+	// which is zero for the lessons reserved at the first attempt.
+	AvgTimeToBookSeconds float64           `json:"avg_time_to_book_seconds"`
+	ByTimeOfDay          []timeOfDayStats  `json:"by_time_of_day"`
+	TopTutors            []tutorStats      `json:"top_tutors"`
+	FailuresByCode       []errorClassStats `json:"failures_by_code"`
+}
+
+// timeOfDayStats is the attempts for the lessons starting at Time formatted in HH:MM.
+type timeOfDayStats struct {
+	Time         string  `json:"time"`
+	Reservations int     `json:"reservations"`
+	Failures     int     `json:"failures"`
+	SuccessRate  float64 `json:"success_rate"`
+}
+
+type tutorStats struct {
+	Name         string `json:"name"`
+	Reservations int    `json:"reservations"`
+}
+
+type errorClassStats struct {
+	Code  string `json:"code"`
+	Count int    `json:"count"`
+}
+
+// aggregateStats aggregates the records into the stats with the top most-booked tutors, or all of them if top is 0.
+func aggregateStats(records []store.Record, top int) *statsResult {
+	s := &statsResult{ByTimeOfDay: []timeOfDayStats{}, TopTutors: []tutorStats{}, FailuresByCode: []errorClassStats{}}
+	var reservations, attempts []store.Record
+	for _, r := range records {
+		if r.DryRun {
+			continue
+		}
+		switch r.Kind {
+		case store.KindReservation:
+			reservations = append(reservations, r)
+		case store.KindAttempt:
+			attempts = append(attempts, r)
+		}
+	}
+	s.Reservations, s.Failures = len(reservations), len(attempts)
+	s.SuccessRate = successRate(s.Reservations, s.Failures)
+
+	byTime := map[string]*timeOfDayStats{}
+	timeOfDay := func(r store.Record) *timeOfDayStats {
+		k := r.StartAt.In(tz).Format("15:04")
+		if byTime[k] == nil {
+			byTime[k] = &timeOfDayStats{Time: k}
+		}
+		return byTime[k]
+	}
+	byTutor := map[string]int{}
+	byCode := map[string]int{}
+	for _, r := range reservations {
+		timeOfDay(r).Reservations++
+		byTutor[r.TutorName]++
+	}
+	for _, a := range attempts {
+		timeOfDay(a).Failures++
+		code := a.ErrorCode
+		if code == "" {
+			code = "unknown"
+		}
+		byCode[code]++
+	}
+
+	for _, t := range byTime {
+		t.SuccessRate = successRate(t.Reservations, t.Failures)
+		s.ByTimeOfDay = append(s.ByTimeOfDay, *t)
+	}
+	sort.Slice(s.ByTimeOfDay, func(i, j int) bool { return s.ByTimeOfDay[i].Time < s.ByTimeOfDay[j].Time })
+
+	for name, n := range byTutor {
+		s.TopTutors = append(s.TopTutors, tutorStats{Name: name, Reservations: n})
+	}
+	sort.Slice(s.TopTutors, func(i, j int) bool {
+		if s.TopTutors[i].Reservations != s.TopTutors[j].Reservations {
+			return s.TopTutors[i].Reservations > s.TopTutors[j].Reservations
+		}
+		return s.TopTutors[i].Name < s.TopTutors[j].Name
+	})
+	if top > 0 && len(s.TopTutors) > top {
+		s.TopTutors = s.TopTutors[:top]
+	}
+
+	for code, n := range byCode {
+		s.FailuresByCode = append(s.FailuresByCode, errorClassStats{Code: code, Count: n})
+	}
+	sort.Slice(s.FailuresByCode, func(i, j int) bool {
+		if s.FailuresByCode[i].Count != s.FailuresByCode[j].Count {
+			return s.FailuresByCode[i].Count > s.FailuresByCode[j].Count
+		}
+		return s.FailuresByCode[i].Code < s.FailuresByCode[j].Code
+	})
+
+	if len(reservations) > 0 {
+		var total time.Duration
+		for _, r := range reservations {
+			total += timeToBook(r, attempts)
+		}
+		s.AvgTimeToBookSeconds = (total / time.Duration(len(reservations))).Seconds()
+	}
+	return s
+}
+
+// timeToBook returns the time from the first failed attempt whose window contains the reserved lesson,
+// within timeToBookWindow before the reservation, or zero if there is no such attempt.
+func timeToBook(r store.Record, attempts []store.Record) time.Duration {
+	var first time.Time
+	for _, a := range attempts {
+		if a.CreatedAt.After(r.CreatedAt) || r.CreatedAt.Sub(a.CreatedAt) > timeToBookWindow {
+			continue
+		}
+		if r.StartAt.Before(a.StartAt) || r.StartAt.After(a.EndAt) {
+			continue
+		}
+		if first.IsZero() || a.CreatedAt.Before(first) {
+			first = a.CreatedAt
+		}
+	}
+	if first.IsZero() {
+		return 0
+	}
+	return r.CreatedAt.Sub(first)
+}
+
+// successRate returns the ratio of the successes, or 0 if nothing has been tried.
+func successRate(successes, failures int) float64 {
+	if successes+failures == 0 {
+		return 0
+	}
+	return float64(successes) / float64(successes+failures)
+}
+
+func formatRate(r float64) string {
+	return strconv.FormatFloat(r*100, 'f', 1, 64) + "%"
+}
+
+func (s *statsResult) header() []string {
+	return []string{"METRIC", "KEY", "VALUE"}
+}
+
+func (s *statsResult) rows() [][]string {
+	rows := [][]string{
+		{"reservations", "", strconv.Itoa(s.Reservations)},
+		{"failures", "", strconv.Itoa(s.Failures)},
+		{"success_rate", "", formatRate(s.SuccessRate)},
+		{"avg_time_to_book", "", time.Duration(s.AvgTimeToBookSeconds * float64(time.Second)).Round(time.Second).String()},
+	}
+	for _, t := range s.ByTimeOfDay {
+		rows = append(rows, []string{"success_rate_by_time", t.Time, formatRate(t.SuccessRate)})
+	}
+	for _, t := range s.TopTutors {
+		rows = append(rows, []string{"tutor_reservations", t.Name, strconv.Itoa(t.Reservations)})
+	}
+	for _, f := range s.FailuresByCode {
+		rows = append(rows, []string{"failures_by_code", f.Code, strconv.Itoa(f.Count)})
+	}
+	return rows
+}
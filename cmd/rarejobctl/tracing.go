@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.uber.org/zap"
+)
+
+// setupTracing exports the spans of the client via OTLP/HTTP, which is configured by the standard
+// OTEL_EXPORTER_OTLP_* environment variables. The returned function flushes the spans and must be called before exiting.
+func setupTracing(ctx context.Context) (func(), error) {
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", "rarejobctl")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tp)
+
+	return func() {
+		// the context may have been cancelled by the signal, but the spans of it should be exported
+		if err := tp.Shutdown(context.WithoutCancel(ctx)); err != nil {
+			zap.L().Warn("failed to export spans", zap.Error(err))
+		}
+	}, nil
+}
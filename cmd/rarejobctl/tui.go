@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/musaprg/rarejobctl/librarejob"
+	"go.uber.org/zap"
+)
+
+// runTUI searches the tutors available in the window of the flags, lets the user pick a slot in a list,
+// and reserves it after the confirmation.
+func runTUI(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("tui", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+	from, err := parseFrom()
+	if err != nil {
+		return err
+	}
+
+	opts, err := newClientOpts()
+	if err != nil {
+		return err
+	}
+	rc, err := librarejob.NewClient(opts)
+	if err != nil {
+		return fmt.Errorf("failed to create rarejob client: %w", err)
+	}
+	defer func() {
+		if err := rc.Teardown(); err != nil {
+			zap.L().Warn("failed to tear down rarejob client", zap.Error(err))
+		}
+	}()
+
+	if err := login(ctx, rc); err != nil {
+		return fmt.Errorf("failed to login: %w", err)
+	}
+	tutors, err := rc.SearchTutors(ctx, from, time.Minute*time.Duration(*margin))
+	if err != nil {
+		return fmt.Errorf("failed to search tutors: %w", err)
+	}
+
+	m, err := tea.NewProgram(newSlotPicker(from, tutors), tea.WithContext(ctx)).Run()
+	if err != nil {
+		return fmt.Errorf("failed to run tui: %w", err)
+	}
+	picked := m.(slotPicker).picked
+	if picked == nil {
+		zap.L().Info("no slot was picked")
+		return nil
+	}
+
+	r, err := rc.Reserve(ctx, picked.request(librarejob.ReserveRequest{Type: librarejob.ReservationTypeLesson}))
+	if err != nil {
+		return fmt.Errorf("failed to reserve tutor: %w", err)
+	}
+	reserved = r
+
+	st := openStore()
+	defer closeStore(st)
+	recordHistory(ctx, st, reservationRecord(r))
+
+	if r.DryRun {
+		return printResult((*reserveResult)(r), func(w io.Writer) {
+			fmt.Fprintf(w, "[dry-run] would reserve tutor %s from %s to %s\n", r.Name, r.StartAt, r.EndAt)
+		})
+	}
+	notifyReserved(ctx, r)
+	return printResult((*reserveResult)(r), func(w io.Writer) {
+		fmt.Fprintf(w, "reserved tutor %s from %s to %s\n", r.Name, r.StartAt, r.EndAt)
+	})
+}
+
+// slotPicker is the bubbletea model listing the slots of the tutors, earliest first.
+type slotPicker struct {
+	from       time.Time
+	items      []pickedSlot
+	cursor     int
+	height     int
+	confirming bool
+	picked     *pickedSlot
+}
+
+func newSlotPicker(from time.Time, tutors librarejob.Tutors) slotPicker {
+	return slotPicker{from: from, items: pickableSlots(tutors)}
+}
+
+func (m slotPicker) Init() tea.Cmd {
+	return nil
+}
+
+func (m slotPicker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+	case tea.KeyMsg:
+		if m.confirming {
+			switch msg.String() {
+			case "y", "Y", "enter":
+				m.picked = &m.items[m.cursor]
+				return m, tea.Quit
+			case "ctrl+c":
+				return m, tea.Quit
+			default:
+				m.confirming = false
+			}
+			return m, nil
+		}
+		switch msg.String() {
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.items)-1 {
+				m.cursor++
+			}
+		case "enter", " ":
+			if len(m.items) > 0 {
+				m.confirming = true
+			}
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m slotPicker) View() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Available slots on %s\n\n", m.from.Format(time.DateOnly))
+	if len(m.items) == 0 {
+		b.WriteString("  no tutors available\n\n  q: quit\n")
+		return b.String()
+	}
+
+	// scroll to keep the cursor in the screen, leaving the lines for the header and the footer
+	rows := len(m.items)
+	if m.height > 6 && rows > m.height-6 {
+		rows = m.height - 6
+	}
+	top := 0
+	if m.cursor >= rows {
+		top = m.cursor - rows + 1
+	}
+	for i := top; i < top+rows && i < len(m.items); i++ {
+		it := m.items[i]
+		cursor := "  "
+		if i == m.cursor {
+			cursor = "> "
+		}
+		fmt.Fprintf(&b, "%s%s  %-24s  %.2f\n", cursor, it.slot.Format("15:04"), it.tutor.Name, it.tutor.Rating)
+	}
+	b.WriteString("\n")
+
+	if m.confirming {
+		it := m.items[m.cursor]
+		fmt.Fprintf(&b, "Reserve %s at %s? [Y/n]\n", it.tutor.Name, it.slot.Format("15:04"))
+	} else {
+		b.WriteString("↑/↓: move  enter: reserve  q: quit\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+	"go.uber.org/zap"
+)
+
+// runTutors dispatches the subcommand of tutors. Listing the available tutors is the default.
+func runTutors(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "profile":
+			return runTutorsProfile(ctx, args[1:])
+		case "availability":
+			return runTutorsAvailability(ctx, args[1:])
+		case "recommend":
+			return runTutorsRecommend(ctx, args[1:])
+		case "forecast":
+			return runTutorsForecast(ctx, args[1:])
+		}
+	}
+	return runTutorsSearch(ctx, args)
+}
+
+// runTutorsSearch lists the tutors available in the window specified by the flags.
+func runTutorsSearch(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("tutors", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+
+	from, err := parseFrom()
+	if err != nil {
+		return err
+	}
+
+	opts, err := newClientOpts()
+	if err != nil {
+		return err
+	}
+	rc, err := librarejob.NewClient(opts)
+	if err != nil {
+		return fmt.Errorf("failed to create rarejob client: %w", err)
+	}
+	defer func() {
+		if err := rc.Teardown(); err != nil {
+			zap.L().Warn("failed to tear down rarejob client", zap.Error(err))
+		}
+	}()
+
+	if err := login(ctx, rc); err != nil {
+		return fmt.Errorf("failed to login: %w", err)
+	}
+
+	if *output == outputNDJSON {
+		// write the tutors as soon as they are scraped instead of the whole result at last
+		enc := json.NewEncoder(os.Stdout)
+		ctx = librarejob.WithProgress(ctx, func(ev librarejob.ProgressEvent) {
+			if ev.Type != librarejob.ProgressTutorFound {
+				return
+			}
+			if err := enc.Encode(ev.Tutor); err != nil {
+				zap.L().Warn("failed to write tutor", zap.Error(err))
+			}
+		})
+	}
+
+	sampledAt := time.Now()
+	tutors, err := rc.SearchTutors(ctx, from, time.Minute*time.Duration(*margin))
+	if err != nil && !errors.Is(err, librarejob.ErrNoTutorsAvailable) {
+		return fmt.Errorf("failed to search tutors: %w", err)
+	}
+	exportTutors(ctx, sampledAt, from, tutors)
+	if *output == outputNDJSON {
+		return nil
+	}
+	if tutors == nil {
+		tutors = librarejob.Tutors{}
+	}
+
+	result := tutorsResult(tutors)
+	return printResult(result, func(w io.Writer) {
+		for _, r := range result.rows() {
+			fmt.Fprintf(w, "%s (%s): %s\n", r[1], r[2], strings.Join(strings.Fields(r[3]), ", "))
+		}
+	})
+}
+
+// runTutorsProfile shows the profiles of the tutors with the given ids.
+func runTutorsProfile(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("tutors profile", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("%w: tutor id is required, which is shown by tutors", errInvalidConfig)
+	}
+
+	opts, err := newClientOpts()
+	if err != nil {
+		return err
+	}
+	rc, err := librarejob.NewClient(opts)
+	if err != nil {
+		return fmt.Errorf("failed to create rarejob client: %w", err)
+	}
+	defer func() {
+		if err := rc.Teardown(); err != nil {
+			zap.L().Warn("failed to tear down rarejob client", zap.Error(err))
+		}
+	}()
+
+	if err := login(ctx, rc); err != nil {
+		return fmt.Errorf("failed to login: %w", err)
+	}
+
+	profiles := make(profilesResult, 0, fs.NArg())
+	for _, id := range fs.Args() {
+		t, err := rc.GetTutorProfile(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get tutor profile: %w", err)
+		}
+		profiles = append(profiles, *t)
+	}
+
+	return printResult(profiles, func(w io.Writer) {
+		for _, t := range profiles {
+			fmt.Fprintf(w, "%s (%s)\n", t.Name, t.ID)
+			fmt.Fprintf(w, "  rating: %.2f, total lessons: %d\n", t.Rating, t.TotalLessons)
+			if len(t.Specialties) > 0 {
+				fmt.Fprintf(w, "  specialties: %s\n", strings.Join(t.Specialties, ", "))
+			}
+			if t.IntroVideoURL != "" {
+				fmt.Fprintf(w, "  video: %s\n", t.IntroVideoURL)
+			}
+			if t.Introduction != "" {
+				fmt.Fprintf(w, "  %s\n", strings.ReplaceAll(t.Introduction, "\n", "\n  "))
+			}
+		}
+	})
+}
+
+// runTutorsRecommend recommends the tutors available in the window specified by the flags from the synced lesson history.
+func runTutorsRecommend(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("tutors recommend", flag.ContinueOnError)
+	n := fs.Int("n", 5, "number of tutors to recommend")
+	profiles := fs.Bool("profiles", false, "fetch the profiles of the available tutors to compare their specialties with the favorite tutors, which takes a while")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+
+	from, err := parseFrom()
+	if err != nil {
+		return err
+	}
+
+	st, err := openStoreOrError()
+	if err != nil {
+		return fmt.Errorf("failed to open history database: %w", err)
+	}
+	defer closeStore(st)
+	history, err := loadLessons(ctx, st)
+	if err != nil {
+		return err
+	}
+	if len(history) == 0 {
+		zap.L().Warn("no lesson history is synced, the tutors are ranked by the rating only. run history sync first")
+	}
+
+	opts, err := newClientOpts()
+	if err != nil {
+		return err
+	}
+	rc, err := librarejob.NewClient(opts)
+	if err != nil {
+		return fmt.Errorf("failed to create rarejob client: %w", err)
+	}
+	defer func() {
+		if err := rc.Teardown(); err != nil {
+			zap.L().Warn("failed to tear down rarejob client", zap.Error(err))
+		}
+	}()
+
+	if err := login(ctx, rc); err != nil {
+		return fmt.Errorf("failed to login: %w", err)
+	}
+
+	sampledAt := time.Now()
+	tutors, err := rc.SearchTutors(ctx, from, time.Minute*time.Duration(*margin))
+	if err != nil && !errors.Is(err, librarejob.ErrNoTutorsAvailable) {
+		return fmt.Errorf("failed to search tutors: %w", err)
+	}
+	exportTutors(ctx, sampledAt, from, tutors)
+	if *profiles {
+		for i := range tutors {
+			if tutors[i].ID == "" {
+				continue
+			}
+			p, err := rc.GetTutorProfile(ctx, tutors[i].ID)
+			if err != nil {
+				zap.L().Warn("failed to get tutor profile", zap.String("tutor", tutors[i].Name), zap.Error(err))
+				continue
+			}
+			tutors[i].Specialties = p.Specialties
+		}
+	}
+
+	recs := librarejob.RecommendTutors(tutors, history)
+	if len(recs) > *n {
+		recs = recs[:*n]
+	}
+	result := recommendResult(recs)
+	return printResult(result, func(w io.Writer) {
+		for _, r := range result {
+			fmt.Fprintf(w, "%s (%.2f): %d lessons taken, score %.2f\n", r.Tutor.Name, r.Tutor.Rating, r.PastLessons, r.Score)
+		}
+	})
+}
+
+// recommendResult renders the recommended tutors.
+type recommendResult []librarejob.Recommendation
+
+func (r recommendResult) header() []string {
+	return []string{"ID", "NAME", "RATING", "PAST LESSONS", "SCORE", "AVAILABLE SLOTS"}
+}
+
+func (r recommendResult) rows() [][]string {
+	rows := make([][]string, 0, len(r))
+	for _, rec := range r {
+		t := tutorsResult{rec.Tutor}.rows()[0]
+		rows = append(rows, []string{t[0], t[1], t[2], strconv.Itoa(rec.PastLessons), strconv.FormatFloat(rec.Score, 'f', 2, 64), t[3]})
+	}
+	return rows
+}
+
+// profilesResult renders the tutor profiles.
+type profilesResult []librarejob.Tutor
+
+func (r profilesResult) header() []string {
+	return []string{"ID", "NAME", "RATING", "TOTAL LESSONS", "SPECIALTIES", "VIDEO"}
+}
+
+func (r profilesResult) rows() [][]string {
+	rows := make([][]string, 0, len(r))
+	for _, t := range r {
+		rows = append(rows, []string{t.ID, t.Name, strconv.FormatFloat(t.Rating, 'f', 2, 64), strconv.Itoa(t.TotalLessons), strings.Join(t.Specialties, ", "), t.IntroVideoURL})
+	}
+	return rows
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// isInteractive returns true if the standard input is a terminal, where the user can answer the prompts.
+func isInteractive() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// promptHumanVerification asks the user to complete the CAPTCHA or the additional verification in the browser,
+// and waits until they press Enter.
+func promptHumanVerification(ctx context.Context, screenshot string) error {
+	if *seleniumHost == "" && !*headful {
+		return errors.New("the browser is running in the frame buffer, rerun with -headful to complete the verification in the browser window")
+	}
+	fmt.Fprintln(os.Stderr, "RareJob is asking for a CAPTCHA or an additional verification.")
+	if screenshot != "" {
+		fmt.Fprintf(os.Stderr, "The screenshot of the page is saved at %s.\n", screenshot)
+	}
+	fmt.Fprint(os.Stderr, "Complete it in the browser window, then press Enter to continue: ")
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		done <- err
+	}()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-done:
+		return err
+	}
+}
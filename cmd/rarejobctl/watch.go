@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+	"github.com/musaprg/rarejobctl/store"
+	"go.uber.org/zap"
+)
+
+// watchTutor polls the schedule of the tutor req.Tutor every interval until a slot in the windows of req opens,
+// and reserves it. It gives up once every window has ended.
+func watchTutor(ctx context.Context, rc librarejob.Client, st store.Store, req librarejob.ReserveRequest, interval time.Duration) (*librarejob.Reserve, error) {
+	pref := librarejob.Preference{Windows: []librarejob.Window{{From: req.From, Margin: req.Margin}}}
+	if req.Preference != nil {
+		pref = *req.Preference
+	}
+	var dates []time.Time
+	seen := map[string]bool{}
+	for _, w := range pref.Windows {
+		if d := w.From.In(tz).Format(time.DateOnly); !seen[d] {
+			seen[d] = true
+			dates = append(dates, w.From)
+		}
+	}
+
+	if err := login(ctx, rc); err != nil {
+		return nil, fmt.Errorf("failed to login: %w", err)
+	}
+	zap.L().Info("watching tutor schedule", zap.String("tutor", req.Tutor), zap.Duration("interval", interval))
+	for {
+		open, err := scheduleOpen(ctx, rc, req.Tutor, dates, pref)
+		switch {
+		case errors.Is(err, librarejob.ErrSessionExpired):
+			zap.L().Info("session expired while watching, logging in again")
+			if err := login(ctx, rc); err != nil {
+				return nil, fmt.Errorf("failed to login: %w", err)
+			}
+			// waits for the interval as usual, not to keep logging in if the site keeps expiring the session
+		case err != nil:
+			return nil, fmt.Errorf("failed to get tutor schedule: %w", err)
+		case open != nil:
+			zap.L().Info("slot opened", zap.String("tutor", open.Name), zap.Time("start_at", open.AvailableSlots[0].StartAt))
+			r, err := rc.Reserve(ctx, req)
+			if r != nil {
+				recordHistory(ctx, st, reservationRecord(r))
+				return r, nil
+			}
+			recordHistory(ctx, st, attemptRecord(req.From, req.Margin, err))
+			if !errors.Is(err, librarejob.ErrNoTutorsAvailable) && !errors.Is(err, librarejob.ErrSlotTaken) {
+				return nil, fmt.Errorf("failed to reserve tutor: %w", err)
+			}
+			zap.L().Info("the slot was taken before reserving, watching again", zap.Error(err))
+		}
+
+		if windowsEnded(pref, time.Now()) {
+			last := pref.Windows[len(pref.Windows)-1]
+			return nil, fmt.Errorf("failed to reserve tutor: %w", &librarejob.NoTutorsAvailableError{From: pref.Windows[0].From, To: last.From.Add(last.Margin)})
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to reserve tutor: %w", ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+// scheduleOpen returns the tutor with the first open slot acceptable by pref on the dates, or nil if no slot is open.
+func scheduleOpen(ctx context.Context, rc librarejob.Client, id string, dates []time.Time, pref librarejob.Preference) (*librarejob.Tutor, error) {
+	for _, d := range dates {
+		t, err := rc.TutorSchedule(ctx, id, d)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range t.AvailableSlots {
+			if _, ok := pref.Rank(s.StartAt, tz); ok && s.Available() && s.StartAt.After(time.Now()) {
+				t.AvailableSlots = []librarejob.Slot{s}
+				return t, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// windowsEnded reports whether every window of pref has ended by now, so no slot can open anymore.
+func windowsEnded(pref librarejob.Preference, now time.Time) bool {
+	for _, w := range pref.Windows {
+		if now.Before(w.From.Add(w.Margin)) {
+			return false
+		}
+	}
+	return true
+}
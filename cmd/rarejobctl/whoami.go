@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+	"go.uber.org/zap"
+)
+
+// whoamiResult is the account logged in.
+type whoamiResult struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Plan  string `json:"plan"`
+	// Timezone is the timezone of the account setting on the site, and LocalTimezone is -timezone.
+	Timezone      string `json:"timezone"`
+	LocalTimezone string `json:"local_timezone"`
+}
+
+// runWhoami logs in and shows the account, e.g. to verify the credentials of the profile, or the session cached for them,
+// belong to the expected account.
+func runWhoami(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("whoami", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+
+	opts, err := newClientOpts()
+	if err != nil {
+		return err
+	}
+	rc, err := librarejob.NewClient(opts)
+	if err != nil {
+		return fmt.Errorf("failed to create rarejob client: %w", err)
+	}
+	defer func() {
+		if err := rc.Teardown(); err != nil {
+			zap.L().Warn("failed to tear down rarejob client", zap.Error(err))
+		}
+	}()
+
+	if err := login(ctx, rc); err != nil {
+		return fmt.Errorf("failed to login: %w", err)
+	}
+	// the session may be resumed from the cache of RAREJOB_EMAIL without resolving the credentials
+	email := os.Getenv("RAREJOB_EMAIL")
+	if email == "" {
+		cred, err := credentials()
+		if err != nil {
+			return err
+		}
+		email = cred.Email
+	}
+	status, err := rc.AccountStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get account status: %w", err)
+	}
+
+	res := whoamiResult{
+		Name:          status.Name,
+		Email:         status.Email,
+		Plan:          status.Plan,
+		Timezone:      status.Timezone,
+		LocalTimezone: tz.String(),
+	}
+	if res.Email == "" {
+		// the site accepted the email, or the session cached for it, so it's the one of the account
+		res.Email = email
+	} else if !strings.EqualFold(res.Email, email) {
+		zap.L().Warn("logged in to the account with another email", zap.String("login_email", email), zap.String("account_email", res.Email))
+	}
+
+	return printResult(res, func(w io.Writer) {
+		fmt.Fprintf(w, "name: %s\n", orUnknown(res.Name))
+		fmt.Fprintf(w, "email: %s\n", res.Email)
+		fmt.Fprintf(w, "plan: %s\n", res.Plan)
+		fmt.Fprintf(w, "timezone: %s (-timezone: %s)\n", orUnknown(res.Timezone), res.LocalTimezone)
+	})
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
+func (r whoamiResult) header() []string {
+	return []string{"NAME", "EMAIL", "PLAN", "TIMEZONE", "LOCAL TIMEZONE"}
+}
+
+func (r whoamiResult) rows() [][]string {
+	return [][]string{{r.Name, r.Email, r.Plan, r.Timezone, r.LocalTimezone}}
+}
@@ -0,0 +1,141 @@
+package librarejob
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tebeka/selenium"
+	"go.uber.org/zap"
+)
+
+// AccountStatus is the profile, the plan and the lesson tickets of the account.
+type AccountStatus struct {
+	// Name, Email and Timezone are the profile of the account, which are empty if the page doesn't show them.
+	Name     string `json:"name,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+	Plan     string `json:"plan"`
+	// RemainingLessons is the number of lessons which can still be taken today with the plan, or -1 if unknown.
+	RemainingLessons int      `json:"remaining_lessons"`
+	Tickets          []Ticket `json:"tickets"`
+}
+
+// Ticket is a lesson ticket, which can be used in addition to the plan.
+type Ticket struct {
+	Name      string    `json:"name"`
+	Remaining int       `json:"remaining"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ExpiringTickets returns the tickets left unused which expire before t.
+func (s *AccountStatus) ExpiringTickets(t time.Time) []Ticket {
+	var tickets []Ticket
+	for _, ticket := range s.Tickets {
+		if ticket.Remaining > 0 && !ticket.ExpiresAt.IsZero() && ticket.ExpiresAt.Before(t) {
+			tickets = append(tickets, ticket)
+		}
+	}
+	return tickets
+}
+
+var (
+	numberPattern = regexp.MustCompile(`\d+`)
+	datePattern   = regexp.MustCompile(`(\d{4})[/年-](\d{1,2})[/月-](\d{1,2})`)
+)
+
+// parseNumber returns the first number in s, e.g. 3 for "残り3回".
+func parseNumber(s string) (int, error) {
+	m := numberPattern.FindString(s)
+	if m == "" {
+		return 0, fmt.Errorf("no number found: %s", s)
+	}
+	return strconv.Atoi(m)
+}
+
+// parseDate parses the date such as "2022/12/31" or "2022年12月31日".
+func parseDate(s string, loc *time.Location) (time.Time, error) {
+	m := datePattern.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, fmt.Errorf("invalid date: %s", s)
+	}
+	return time.ParseInLocation("2006-1-2", fmt.Sprintf("%s-%s-%s", m[1], m[2], m[3]), loc)
+}
+
+func (c *client) AccountStatus(ctx context.Context) (_ *AccountStatus, err error) {
+	unlock, err := c.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	defer c.l.Sync()
+	defer c.flushConsoleLogs()
+	defer func() { err = c.captureFailure("account_status", err) }()
+
+	if err := c.get(ctx, rarejobAccountURL); err != nil {
+		return nil, fmt.Errorf("failed to get account page: %w", err)
+	}
+	if c.isAt(rarejobLoginURL) {
+		return nil, ErrSessionExpired
+	}
+	if err := c.waitUntilElementLoaded(ctx, c.waits.Default, selenium.ByCSSSelector, c.sel.AccountPlan); err != nil {
+		return nil, fmt.Errorf("failed to load account page: %w", err)
+	}
+
+	status := &AccountStatus{RemainingLessons: -1}
+	plan, err := c.elementText(ctx, selenium.ByCSSSelector, c.sel.AccountPlan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plan: %w", err)
+	}
+	status.Plan = strings.TrimSpace(plan)
+	status.Name = c.optionalText(c.sel.AccountName)
+	status.Email = c.optionalText(c.sel.AccountEmail)
+	status.Timezone = c.optionalText(c.sel.AccountTimezone)
+	// the plans without daily limit don't show the remaining lessons
+	if text, err := c.elementText(ctx, selenium.ByCSSSelector, c.sel.AccountRemainingLessons); err == nil {
+		if n, err := parseNumber(text); err == nil {
+			status.RemainingLessons = n
+		}
+	}
+
+	items, _ := c.wd.FindElements(selenium.ByCSSSelector, c.sel.AccountTicket)
+	for n := 1; n <= len(items); n++ {
+		name, _ := c.elementText(ctx, selenium.ByCSSSelector, fmt.Sprintf(c.sel.AccountTicketName, n))
+		countText, err := c.elementText(ctx, selenium.ByCSSSelector, fmt.Sprintf(c.sel.AccountTicketCount, n))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the count of ticket #%d: %w", n, err)
+		}
+		count, err := parseNumber(countText)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the count of ticket #%d: %w", n, err)
+		}
+		ticket := Ticket{Name: strings.TrimSpace(name), Remaining: count}
+		if expiryText, err := c.elementText(ctx, selenium.ByCSSSelector, fmt.Sprintf(c.sel.AccountTicketExpiry, n)); err == nil {
+			if d, err := parseDate(expiryText, c.loc); err == nil {
+				// the ticket can be used through the expiry date
+				ticket.ExpiresAt = d.AddDate(0, 0, 1)
+			}
+		}
+		status.Tickets = append(status.Tickets, ticket)
+	}
+
+	c.l.Info("fetched account status", zap.String("plan", status.Plan), zap.Int("remaining_lessons", status.RemainingLessons), zap.Int("tickets", len(status.Tickets)))
+	return status, nil
+}
+
+// optionalText returns the trimmed text of the element, or "" if the selector is empty or the element is not shown.
+// It doesn't retry since the element may be missing for good.
+func (c *client) optionalText(value string) string {
+	if value == "" {
+		return ""
+	}
+	elms, _ := c.wd.FindElements(selenium.ByCSSSelector, value)
+	if len(elms) == 0 {
+		return ""
+	}
+	text, _ := elms[0].Text()
+	return strings.TrimSpace(text)
+}
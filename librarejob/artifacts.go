@@ -0,0 +1,89 @@
+package librarejob
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tebeka/selenium"
+	"go.uber.org/zap"
+)
+
+// captureFailure saves a debug bundle of the current page (screenshot, URL, sanitized cookies and page source)
+// into the artifacts directory when err is not nil, and returns err annotated with the path of the bundle.
+// The bundle is readable only by the user since the pages show the account and the reservations.
+func (c *client) captureFailure(op string, err error) error {
+	if err == nil || c.artifactsDir == "" {
+		return err
+	}
+	defer c.l.Sync()
+
+	dir := filepath.Join(c.artifactsDir, fmt.Sprintf("%s_%s", time.Now().Format("20060102T150405.000"), op))
+	if mkErr := os.MkdirAll(dir, 0700); mkErr != nil {
+		c.l.Warn("failed to create artifacts directory", zap.String("dir", dir), zap.Error(mkErr))
+		return err
+	}
+
+	// collect as much as possible, since any of them may fail when the browser is in a bad state
+	if ss, ssErr := c.fullPageScreenshot(); ssErr != nil {
+		c.l.Warn("failed to take screenshot on failure", zap.String("operation", op), zap.Error(ssErr))
+	} else {
+		c.writeArtifact(dir, "screenshot.png", ss)
+	}
+	c.writeArtifact(dir, "url.txt", []byte(c.getCurrentURL()+"\n"))
+	if cookies, cErr := c.wd.GetCookies(); cErr != nil {
+		c.l.Warn("failed to get cookies on failure", zap.String("operation", op), zap.Error(cErr))
+	} else if b, mErr := json.MarshalIndent(sanitizeCookies(cookies), "", "  "); mErr == nil {
+		c.writeArtifact(dir, "cookies.json", b)
+	}
+	if src, srcErr := c.wd.PageSource(); srcErr != nil {
+		c.l.Warn("failed to get page source on failure", zap.String("operation", op), zap.Error(srcErr))
+	} else {
+		c.writeArtifact(dir, "page.html", []byte(src))
+	}
+	c.l.Info("saved debug artifacts of the failure", zap.String("operation", op), zap.String("path", dir))
+
+	return fmt.Errorf("%w (debug artifacts: %s)", err, dir)
+}
+
+func (c *client) writeArtifact(dir, name string, b []byte) {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, b, 0600); err != nil {
+		c.l.Warn("failed to write debug artifact", zap.String("path", path), zap.Error(err))
+	}
+}
+
+// sanitizeCookies masks the cookie values since they include session ids which can be used to hijack the account.
+func sanitizeCookies(cookies []selenium.Cookie) []selenium.Cookie {
+	sanitized := make([]selenium.Cookie, 0, len(cookies))
+	for _, c := range cookies {
+		c.Value = fmt.Sprintf("<redacted %d bytes>", len(c.Value))
+		sanitized = append(sanitized, c)
+	}
+	return sanitized
+}
+
+// fullPageScreenshot takes the screenshot of the whole page by temporarily enlarging the window to the page size.
+func (c *client) fullPageScreenshot() ([]byte, error) {
+	size, err := c.wd.ExecuteScript("return [window.outerWidth, window.outerHeight, document.documentElement.scrollWidth, document.documentElement.scrollHeight];", nil)
+	if err != nil {
+		return c.wd.Screenshot()
+	}
+	dims, ok := size.([]interface{})
+	if !ok || len(dims) != 4 {
+		return c.wd.Screenshot()
+	}
+	toInt := func(v interface{}) int {
+		f, _ := v.(float64)
+		return int(f)
+	}
+	w, h, pw, ph := toInt(dims[0]), toInt(dims[1]), toInt(dims[2]), toInt(dims[3])
+	if pw > w || ph > h {
+		if err := c.wd.ResizeWindow("", max(w, pw), max(h, ph)); err == nil {
+			defer c.wd.ResizeWindow("", w, h)
+		}
+	}
+	return c.wd.Screenshot()
+}
@@ -0,0 +1,66 @@
+package librarejob
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// AuditEntry is a line of the audit log, which records an action taken on the account.
+type AuditEntry struct {
+	Time time.Time `json:"time"`
+	// Action is login, search, reserve or cancel.
+	Action string `json:"action"`
+	// Params are the parameters of the action. The password is never recorded.
+	Params map[string]interface{} `json:"params,omitempty"`
+	// Outcome is success, dry_run, already_exists or the error code.
+	Outcome         string  `json:"outcome"`
+	Error           string  `json:"error,omitempty"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	// Result is the result of the action, e.g. the reservation, if succeeded.
+	Result interface{} `json:"result,omitempty"`
+}
+
+// auditLog writes the entries to the writer as JSON lines. The zero value discards them.
+type auditLog struct {
+	l  *zap.Logger
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// record writes the entry of the action started at start. outcome is derived from err if empty.
+func (a *auditLog) record(action string, start time.Time, params map[string]interface{}, outcome string, result interface{}, err error) {
+	if a == nil || a.w == nil {
+		return
+	}
+	e := AuditEntry{
+		Time:            start,
+		Action:          action,
+		Params:          params,
+		Outcome:         outcome,
+		DurationSeconds: time.Since(start).Seconds(),
+	}
+	if e.Outcome == "" {
+		e.Outcome = resultLabel(err)
+	}
+	if err != nil {
+		e.Error = err.Error()
+	} else {
+		e.Result = result
+	}
+	b, merr := json.Marshal(e)
+	if merr != nil {
+		a.l.Warn("failed to marshal audit entry", zap.String("action", action), zap.Error(merr))
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// a line is written at once so that the entries of the clients sharing the file are not interleaved
+	if _, werr := a.w.Write(append(b, '\n')); werr != nil {
+		a.l.Warn("failed to write audit entry", zap.String("action", action), zap.Error(werr))
+	}
+}
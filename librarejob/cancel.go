@@ -0,0 +1,100 @@
+package librarejob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tebeka/selenium"
+	"go.uber.org/zap"
+)
+
+// Cancel cancels the reservation starting at startAt. It returns ErrCancelDeadlinePassed without cancelling
+// if the free cancellation deadline has passed, since it consumes the lesson, unless force is true.
+func (c *client) Cancel(ctx context.Context, startAt time.Time, force bool) (r *Reserve, err error) {
+	unlock, err := c.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	defer c.l.Sync()
+	defer c.flushConsoleLogs()
+	defer func(start time.Time) {
+		c.audit.record("cancel", start, map[string]interface{}{"start_at": startAt, "force": force}, reserveOutcome(r, err), r, err)
+	}(time.Now())
+	defer func() { err = c.captureFailure("cancel", err) }()
+
+	return c.cancel(ctx, startAt, force)
+}
+
+func (c *client) cancel(ctx context.Context, startAt time.Time, force bool) (*Reserve, error) {
+	reservations, err := c.listReservations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	n := -1
+	for i, r := range reservations {
+		if r.StartAt.Equal(startAt) {
+			n = i
+			break
+		}
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("%w: no reservation at %s", ErrReservationNotFound, startAt.Format(time.DateTime))
+	}
+	r := reservations[n]
+
+	if now := time.Now(); now.After(r.CancelDeadline) {
+		if !force {
+			return nil, fmt.Errorf("%w: the deadline was %s", ErrCancelDeadlinePassed, r.CancelDeadline.Format(time.DateTime))
+		}
+		c.l.Warn("cancelling after the free cancellation deadline", zap.Time("deadline", r.CancelDeadline), zap.Time("start_at", r.StartAt))
+	}
+
+	if err := c.clickElement(ctx, selenium.ByCSSSelector, fmt.Sprintf(c.sel.ReservationCancelButton, n+1)); err != nil {
+		return nil, fmt.Errorf("failed to click cancel button: %w", err)
+	}
+	page := newCancelConfirmationPage(c)
+	if err := page.waitLoaded(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load cancellation page: %w", err)
+	}
+
+	if c.dryRun {
+		c.l.Info("dry-run mode, skipping the confirmation of the cancellation", zap.String("tutor", r.Name), zap.Time("start_at", r.StartAt))
+		r.DryRun = true
+		return &r, nil
+	}
+	sent, err := page.confirm(ctx)
+	if !sent {
+		return nil, fmt.Errorf("failed to click confirm button: %w", err)
+	}
+	if err == nil {
+		err = page.waitFinished(ctx)
+	}
+	if err != nil {
+		// the click may have been accepted even if it failed, so the cancellation is told by my page
+		listed, listErr := c.isReserved(ctx, r.StartAt)
+		if listErr != nil || listed {
+			return nil, fmt.Errorf("cancellation was not completed: %w", errors.Join(err, listErr))
+		}
+		c.l.Warn("reservation is no longer listed on my page though the cancellation was not confirmed", zap.Error(err))
+	}
+	c.l.Info("cancelled reservation", zap.String("tutor", r.Name), zap.Time("start_at", r.StartAt))
+
+	return &r, nil
+}
+
+// isReserved reports whether the reservation starting at startAt is listed on my page.
+func (c *client) isReserved(ctx context.Context, startAt time.Time) (bool, error) {
+	reservations, err := c.listReservations(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to list reservations: %w", err)
+	}
+	for _, r := range reservations {
+		if r.StartAt.Equal(startAt) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,75 @@
+package librarejob
+
+import (
+	"fmt"
+
+	"github.com/tebeka/selenium"
+	"github.com/tebeka/selenium/chrome"
+	"github.com/tebeka/selenium/firefox"
+)
+
+// BrowserOpts overrides the fingerprint of the browser, since the site occasionally serves a different variant
+// of the pages to some browsers, which breaks the selectors.
+type BrowserOpts struct {
+	// UserAgent overrides the User-Agent header. The browser default is used if empty.
+	UserAgent string
+	// WindowWidth and WindowHeight set the size of the window, which switches the responsive layout of the site.
+	// The browser default is used unless both are set.
+	WindowWidth  int
+	WindowHeight int
+	// AcceptLanguage overrides the Accept-Language header, e.g. "ja,en-US;q=0.7,en;q=0.3".
+	AcceptLanguage string
+	// Prefs are the preferences of the browser profile, i.e. about:config of Firefox or the preferences of Chrome.
+	// They are applied after the options above, so they win on conflict.
+	Prefs map[string]interface{}
+}
+
+func (o BrowserOpts) empty() bool {
+	return o.UserAgent == "" && (o.WindowWidth <= 0 || o.WindowHeight <= 0) && o.AcceptLanguage == "" && len(o.Prefs) == 0
+}
+
+// addBrowserCapabilities adds the browser specific capabilities for the options.
+func addBrowserCapabilities(caps selenium.Capabilities, browser browserType, opts BrowserOpts) error {
+	if opts.empty() {
+		return nil
+	}
+	sized := opts.WindowWidth > 0 && opts.WindowHeight > 0
+	prefs := make(map[string]interface{})
+	var args []string
+
+	switch browser {
+	case browserTypeFirefox:
+		if opts.UserAgent != "" {
+			prefs["general.useragent.override"] = opts.UserAgent
+		}
+		if opts.AcceptLanguage != "" {
+			prefs["intl.accept_languages"] = opts.AcceptLanguage
+		}
+		if sized {
+			args = append(args, fmt.Sprintf("--width=%d", opts.WindowWidth), fmt.Sprintf("--height=%d", opts.WindowHeight))
+		}
+		for k, v := range opts.Prefs {
+			prefs[k] = v
+		}
+		caps.AddFirefox(firefox.Capabilities{Args: args, Prefs: prefs})
+
+	case browserTypeChrome:
+		if opts.UserAgent != "" {
+			args = append(args, "--user-agent="+opts.UserAgent)
+		}
+		if opts.AcceptLanguage != "" {
+			prefs["intl.accept_languages"] = opts.AcceptLanguage
+		}
+		if sized {
+			args = append(args, fmt.Sprintf("--window-size=%d,%d", opts.WindowWidth, opts.WindowHeight))
+		}
+		for k, v := range opts.Prefs {
+			prefs[k] = v
+		}
+		caps.AddChrome(chrome.Capabilities{Args: args, Prefs: prefs})
+
+	default:
+		return fmt.Errorf("%w: unsupported browser: %s", ErrInvalidOptions, browser)
+	}
+	return nil
+}
@@ -20,22 +20,24 @@ const (
 	//	https://www.rarejob.com/reservation/?year=2022&month=10&day=9&page=1&lessonTime_from=1000&lessonTime_to=1030&characteristics=4&isSaveCookie=1&order=1
 	rarejobTutorSearchURL = "https://www.rarejob.com/reservation/?year=%d&month=%d&day=%d&page=1&lessonTime_from=%d&lessonTime_to=%d&freeWord_target=1&order=1&onlyFilipinoTutor=%d&characteristics=%s"
 
+	// rarejobBaseURL is the origin of the URLs below, which is replaced by ClientOpts.BaseURL.
+	rarejobBaseURL = "https://www.rarejob.com"
+
 	rarejobLoginURL             = "https://www.rarejob.com/account/login/"
 	rarejobReservationFinishURL = "https://www.rarejob.com/reservation/reserve/finish/"
-	rarejobMyPageURL            = "https://www.rarejob.com/mypage/"
-)
-
-const (
-	loginPageEmailSelector    = "#RJ_LoginForm_email"
-	loginPagePasswordSelector = "#RJ_LoginForm_password"
-	loginPageFormSelector     = "#rj--login-form"
-
-	tutorListSelector           = ".o-listItem"
-	tutorListItemSelector       = ".o-listItem:nth-child(%d)"
-	tutorTimeSlotSelector       = ".o-listItem:nth-child(%d) .o-listItem__slot"
-	tutorNameSelector           = ".o-listItem:nth-child(%d) .o-listItem__ttl"
-	tutorTimeSlotButtonSelector = ".o-listItem:nth-child(%d) .o-listItem__slot:nth-child(%d) > .a-squareBtn"
-	tutorReserveButtonSelector  = ".lessonReserve__tutorInfoBtn > div > a"
+	// counseling and speaking test list the counselors/examiners in the same markup as the tutor search
+	rarejobCounselingSearchURL   = "https://www.rarejob.com/counseling/reservation/?year=%d&month=%d&day=%d&page=1&lessonTime_from=%d&lessonTime_to=%d"
+	rarejobCounselingFinishURL   = "https://www.rarejob.com/counseling/reservation/reserve/finish/"
+	rarejobSpeakingTestSearchURL = "https://www.rarejob.com/speakingtest/reservation/?year=%d&month=%d&day=%d&page=1&lessonTime_from=%d&lessonTime_to=%d"
+	rarejobSpeakingTestFinishURL = "https://www.rarejob.com/speakingtest/reservation/reserve/finish/"
+	rarejobMyPageURL             = "https://www.rarejob.com/mypage/"
+	rarejobLessonHistoryURL      = "https://www.rarejob.com/mypage/lesson/history/?page=%d"
+	rarejobLessonReportURL       = "https://www.rarejob.com/mypage/lesson/report/%s/"
+	rarejobAccountURL            = "https://www.rarejob.com/mypage/account/"
+	rarejobCancelFinishURL       = "https://www.rarejob.com/reservation/cancel/finish/"
+	rarejobTutorDetailURL        = "https://www.rarejob.com/teacher/detail/%s/"
+	// rarejobTutorScheduleURL is the tutor detail page showing the schedule of the day.
+	rarejobTutorScheduleURL = "https://www.rarejob.com/teacher/detail/%s/?year=%d&month=%d&day=%d#schedule"
 )
 
 const (
@@ -45,6 +47,18 @@ const (
 	defaultWaitTimeout = time.Second * 60
 )
 
+const (
+	// defaultRetryMaxAttempts is the max number of attempts for flaky webdriver operations.
+	defaultRetryMaxAttempts = 3
+	// defaultRetryInitialBackoff is the wait before the first retry of flaky webdriver operations.
+	defaultRetryInitialBackoff = time.Millisecond * 500
+	// defaultRetryMaxBackoff caps the wait between retries of flaky webdriver operations.
+	defaultRetryMaxBackoff = time.Second * 5
+	// defaultPageLoadTimeout is the page load timeout of the WebDriver session, the default of the W3C WebDriver spec,
+	// which is restored after a navigation bounded by the deadline of the context.
+	defaultPageLoadTimeout = time.Second * 300
+)
+
 const (
 	// maxSeleniumHealthCheckBackoffLimit is the timeout duration for checking the health of selenium server
 	maxSeleniumHealthCheckBackoffLimit = 5
@@ -52,6 +66,59 @@ const (
 	seleniumHealthCheckRetrySecond = 10
 )
 
+const (
+	// seleniumServerVersion is the version of selenium standalone server to download
+	seleniumServerVersion = "3.141.59"
+	// geckoDriverVersion is the version of geckodriver to download
+	geckoDriverVersion = "0.33.0"
+
+	seleniumServerDownloadURL    = "https://selenium-release.storage.googleapis.com/3.141/selenium-server-standalone-%s.jar"
+	geckoDriverDownloadURL       = "https://github.com/mozilla/geckodriver/releases/download/v%s/geckodriver-v%s-%s.%s"
+	chromeDriverLatestReleaseURL = "https://googlechromelabs.github.io/chrome-for-testing/LATEST_RELEASE_%s"
+	chromeDriverDownloadURL      = "https://storage.googleapis.com/chrome-for-testing-public/%s/%s/chromedriver-%s.zip"
+	// githubReleaseAPIURL is the release of the tag to look up the digests of the assets in.
+	githubReleaseAPIURL = "https://api.github.com/repos/%s/%s/releases/tags/%s"
+
+	// systemSeleniumPath and systemGeckoDriverPath are the locations of the binaries preinstalled in the standalone image
+	systemSeleniumPath    = "/opt/selenium/selenium-server-standalone.jar"
+	systemGeckoDriverPath = "/usr/bin/geckodriver"
+)
+
+const (
+	// defaultCancelDeadline is how long before the lesson it can be cancelled for free, used when the site doesn't show the deadline.
+	defaultCancelDeadline = 30 * time.Minute
+)
+
+const (
+	// maxLessonHistoryPages caps the pages of the lesson history to walk, in case the pagination never ends.
+	maxLessonHistoryPages = 100
+)
+
+const (
+	// tutorScrapeConcurrency is the max number of tutors scraped at once, bounded not to flood the selenium server.
+	tutorScrapeConcurrency = 4
+)
+
+const (
+	// defaultPoolKeepAlive is the interval of the health check of the idle sessions in the pool.
+	defaultPoolKeepAlive = 5 * time.Minute
+	// defaultPoolCheckTimeout bounds a health check of a session in the pool.
+	defaultPoolCheckTimeout = time.Minute
+	// defaultMaintenanceBackoff is the wait before trying again when the end of the site maintenance is unknown.
+	defaultMaintenanceBackoff = 15 * time.Minute
+)
+
+const (
+	// rollbackTimeout bounds the cancellation of the first slot of the consecutive reservation when the second one fails,
+	// which runs even if the context of the reservation is done.
+	rollbackTimeout = 3 * time.Minute
+)
+
+const (
+	// defaultRecordInterval is the minimum interval between screenshots of the session recording.
+	defaultRecordInterval = time.Second
+)
+
 const (
 	// rarejobctlTempDir is the temporary directory for rarejobctl to store files for debugging
 	rarejobctlTempDir = "/tmp/rarejobctl"
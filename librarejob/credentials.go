@@ -0,0 +1,163 @@
+package librarejob
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/term"
+)
+
+// DefaultKeyringService is the service name of the password in the OS keyring used if KeyringCredentials.Service is empty.
+const DefaultKeyringService = "rarejobctl"
+
+// Credentials is the email and the password to login.
+type Credentials struct {
+	Email    string
+	Password string
+}
+
+// CredentialProvider resolves the credentials to login. The client never reads the credentials by itself,
+// so resolve them with a provider and pass them to Login.
+type CredentialProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// EnvCredentials reads the credentials from RAREJOB_EMAIL and RAREJOB_PASSWORD.
+type EnvCredentials struct{}
+
+func (EnvCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	cred := Credentials{Email: os.Getenv("RAREJOB_EMAIL"), Password: os.Getenv("RAREJOB_PASSWORD")}
+	if cred.Password == "" {
+		return cred, fmt.Errorf("%w: RAREJOB_PASSWORD is not set", ErrInvalidOptions)
+	}
+	return cred, nil
+}
+
+// ReaderCredentials reads the password from the first line of R, e.g. the standard input.
+type ReaderCredentials struct {
+	Email string
+	R     io.Reader
+}
+
+func (p ReaderCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	password, err := readFirstLine(p.R)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to read password: %w", err)
+	}
+	return Credentials{Email: p.Email, Password: password}, nil
+}
+
+// FileCredentials reads the password from the first line of the file at Path.
+type FileCredentials struct {
+	Email string
+	Path  string
+}
+
+func (p FileCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	b, err := os.ReadFile(p.Path)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("%w: failed to read password file: %w", ErrInvalidOptions, err)
+	}
+	return ReaderCredentials{Email: p.Email, R: bytes.NewReader(b)}.Credentials(ctx)
+}
+
+// CommandCredentials runs Command with the shell and uses the first line of its output as the password,
+// which is the format of password managers like `pass show rarejob`.
+type CommandCredentials struct {
+	Email   string
+	Command string
+}
+
+func (p CommandCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", p.Command)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to run credential command: %w", err)
+	}
+	return ReaderCredentials{Email: p.Email, R: bytes.NewReader(out)}.Credentials(ctx)
+}
+
+// KeyringCredentials reads the password of Email from the OS keyring, i.e. Keychain on macOS,
+// Secret Service on Linux and Credential Manager on Windows.
+type KeyringCredentials struct {
+	// Service is the service name of the password. DefaultKeyringService is used if empty.
+	Service string
+	Email   string
+}
+
+func (p KeyringCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	password, err := keyring.Get(p.service(), p.Email)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return Credentials{}, fmt.Errorf("%w: no password of %s in the keyring", ErrInvalidOptions, p.Email)
+	}
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to get password from keyring: %w", err)
+	}
+	return Credentials{Email: p.Email, Password: password}, nil
+}
+
+// Store saves the password of Email into the OS keyring.
+func (p KeyringCredentials) Store(password string) error {
+	if err := keyring.Set(p.service(), p.Email, password); err != nil {
+		return fmt.Errorf("failed to save password into keyring: %w", err)
+	}
+	return nil
+}
+
+func (p KeyringCredentials) service() string {
+	if p.Service == "" {
+		return DefaultKeyringService
+	}
+	return p.Service
+}
+
+// PromptCredentials asks the user for the credentials on the terminal. The email is asked only if Email is empty,
+// and the password is read without echo.
+type PromptCredentials struct {
+	Email string
+	// In is the terminal to read from, and Out is where the prompts are written to.
+	In  *os.File
+	Out io.Writer
+}
+
+func (p PromptCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	fd := int(p.In.Fd())
+	if !term.IsTerminal(fd) {
+		return Credentials{}, fmt.Errorf("%w: cannot prompt for the password since the input is not a terminal", ErrInvalidOptions)
+	}
+	cred := Credentials{Email: p.Email}
+	if cred.Email == "" {
+		fmt.Fprint(p.Out, "Email: ")
+		email, err := readFirstLine(p.In)
+		if err != nil {
+			return cred, fmt.Errorf("failed to read email: %w", err)
+		}
+		cred.Email = email
+	}
+	fmt.Fprint(p.Out, "Password: ")
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(p.Out)
+	if err != nil {
+		return cred, fmt.Errorf("failed to read password: %w", err)
+	}
+	cred.Password = string(password)
+	return cred, nil
+}
+
+// readFirstLine reads the first line without the line break.
+func readFirstLine(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,206 @@
+package librarejob
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/tebeka/selenium"
+	"go.uber.org/zap"
+)
+
+// SelectorCheck is the result of checking whether a selector resolves on the page.
+type SelectorCheck struct {
+	Page  string `json:"page"`
+	Name  string `json:"name"`
+	By    string `json:"by"`
+	Value string `json:"value"`
+	OK    bool   `json:"ok"`
+	// Skipped is true if the selector could not be checked, e.g. there is no tutor to open the reservation page.
+	Skipped bool   `json:"skipped"`
+	Error   string `json:"error,omitempty"`
+}
+
+type selectorDef struct {
+	name, by, value string
+}
+
+// CheckSelectors logs in, visits the login, my page, account, lesson history, lesson report, search, tutor detail
+// and reservation pages, and checks whether the selectors the client requires on them resolve. It never confirms a reservation.
+// The selectors of the elements which may be missing for good, e.g. the link to the lesson room, and of the material page,
+// which needs a reservation with the material assigned, are not checked. The selectors of the list items are skipped
+// if nothing is listed. The search uses the window between from and from+margin, which should have an available tutor
+// to check the selectors of the tutor detail and reservation pages.
+func (c *client) CheckSelectors(ctx context.Context, username, password string, from time.Time, margin time.Duration) ([]SelectorCheck, error) {
+	unlock, err := c.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	defer c.l.Sync()
+
+	var checks []SelectorCheck
+
+	// -- login page --
+
+	if err := (loginPage{c: c}).open(ctx); err != nil {
+		return nil, err
+	}
+	checks = append(checks, c.checkSelectors("login", []selectorDef{
+		{"email", selenium.ByCSSSelector, c.sel.LoginEmail},
+		{"password", selenium.ByCSSSelector, c.sel.LoginPassword},
+		{"form", selenium.ByCSSSelector, c.sel.LoginForm},
+		{"submit", selenium.ByCSSSelector, c.sel.LoginSubmit},
+	})...)
+
+	if err := c.login(ctx, username, password); err != nil {
+		return checks, err
+	}
+
+	// -- my page --
+
+	if err := c.get(ctx, rarejobMyPageURL); err != nil {
+		return checks, fmt.Errorf("failed to get my page: %w", err)
+	}
+	checks = append(checks, c.checkItemSelectors("mypage", c.sel.ReservationItem, []selectorDef{
+		{"reservation_date", selenium.ByCSSSelector, fmt.Sprintf(c.sel.ReservationDate, 1)},
+		{"reservation_tutor", selenium.ByCSSSelector, fmt.Sprintf(c.sel.ReservationTutor, 1)},
+		{"reservation_cancel_button", selenium.ByCSSSelector, fmt.Sprintf(c.sel.ReservationCancelButton, 1)},
+	})...)
+
+	// -- account page --
+
+	if err := c.get(ctx, rarejobAccountURL); err != nil {
+		return checks, fmt.Errorf("failed to get account page: %w", err)
+	}
+	checks = append(checks, c.checkSelectors("account", []selectorDef{
+		{"account_plan", selenium.ByCSSSelector, c.sel.AccountPlan},
+	})...)
+
+	// -- lesson history and report pages --
+
+	if err := c.get(ctx, fmt.Sprintf(rarejobLessonHistoryURL, 1)); err != nil {
+		return checks, fmt.Errorf("failed to get lesson history: %w", err)
+	}
+	checks = append(checks, c.checkItemSelectors("lesson_history", c.sel.LessonHistoryItem, []selectorDef{
+		{"lesson_history_date", selenium.ByCSSSelector, fmt.Sprintf(c.sel.LessonHistoryDate, 1)},
+		{"lesson_history_report_link", selenium.ByCSSSelector, fmt.Sprintf(c.sel.LessonHistoryReportLink, 1)},
+	})...)
+	reportDefs := []selectorDef{
+		{"lesson_report_comment", selenium.ByCSSSelector, c.sel.LessonReportComment},
+	}
+	if lessonID := c.linkID(fmt.Sprintf(c.sel.LessonHistoryReportLink, 1)); lessonID == "" {
+		checks = append(checks, skipSelectors("lesson_report", reportDefs)...)
+	} else if err := c.get(ctx, fmt.Sprintf(rarejobLessonReportURL, url.PathEscape(lessonID))); err != nil {
+		return checks, fmt.Errorf("failed to get lesson report: %w", err)
+	} else {
+		checks = append(checks, c.checkSelectors("lesson_report", reportDefs)...)
+	}
+
+	// -- search page --
+
+	from = from.In(c.loc)
+	by := from.Add(margin)
+	search := tutorSearchPage{c: c, typ: ReservationTypeLesson, from: from, by: by}
+	// the checks below tell which selector is broken, so keep going even if the result is not recognized
+	if err := search.open(ctx); err != nil {
+		c.l.Warn("failed to open search result", zap.Error(err))
+	}
+	checks = append(checks, c.checkSelectors("search", []selectorDef{
+		{"search_result", selenium.ByCSSSelector, c.sel.searchResult()},
+	})...)
+
+	tutorDefs := []selectorDef{
+		{"tutor_list", selenium.ByCSSSelector, c.sel.TutorList},
+		{"tutor_list_item", selenium.ByCSSSelector, fmt.Sprintf(c.sel.TutorListItem, 1)},
+		{"tutor_name", selenium.ByCSSSelector, fmt.Sprintf(c.sel.TutorName, 1)},
+		{"tutor_rating", selenium.ByCSSSelector, fmt.Sprintf(c.sel.TutorRating, 1)},
+		{"tutor_link", selenium.ByCSSSelector, fmt.Sprintf(c.sel.TutorLink, 1)},
+		{"tutor_time_slot", selenium.ByCSSSelector, fmt.Sprintf(c.sel.TutorTimeSlot, 1)},
+		{"tutor_time_slot_button", selenium.ByCSSSelector, fmt.Sprintf(c.sel.TutorTimeSlotButton, 1, 1)},
+	}
+	reservationDefs := []selectorDef{
+		{"reservation_confirm", selenium.ByLinkText, c.sel.ReservationConfirmLinkText},
+	}
+	tutorDetailDefs := []selectorDef{
+		{"tutor_profile_name", selenium.ByCSSSelector, c.sel.TutorProfileName},
+		{"tutor_schedule", selenium.ByCSSSelector, c.sel.TutorSchedule},
+	}
+	if tutors, _ := c.wd.FindElements(selenium.ByCSSSelector, c.sel.TutorList); len(tutors) == 0 {
+		c.l.Info("no tutor is available in the window, skipping the checks which require a tutor")
+		checks = append(checks, skipSelectors("search", tutorDefs)...)
+		checks = append(checks, skipSelectors("reservation", reservationDefs)...)
+		checks = append(checks, skipSelectors("tutor_detail", tutorDetailDefs)...)
+		return checks, nil
+	}
+	checks = append(checks, c.checkSelectors("search", tutorDefs)...)
+	tutorID := c.linkID(fmt.Sprintf(c.sel.TutorLink, 1))
+
+	// -- reservation page --
+
+	if err := search.openSlot(ctx, 0, 0); err != nil {
+		checks = append(checks, skipSelectors("reservation", reservationDefs)...)
+	} else {
+		_ = newReservationConfirmationPage(c, ReservationTypeLesson).waitLoaded(ctx)
+		checks = append(checks, c.checkSelectors("reservation", reservationDefs)...)
+	}
+
+	// -- tutor detail page --
+
+	if tutorID == "" {
+		checks = append(checks, skipSelectors("tutor_detail", tutorDetailDefs)...)
+		return checks, nil
+	}
+	if err := c.get(ctx, fmt.Sprintf(rarejobTutorScheduleURL, url.PathEscape(tutorID), from.Year(), from.Month(), from.Day())); err != nil {
+		return checks, fmt.Errorf("failed to get tutor detail: %w", err)
+	}
+	checks = append(checks, c.checkSelectors("tutor_detail", tutorDetailDefs)...)
+
+	return checks, nil
+}
+
+// checkItemSelectors checks the selectors of the first item of the list matched by item, or skips them if nothing is listed.
+func (c *client) checkItemSelectors(page, item string, defs []selectorDef) []SelectorCheck {
+	if items, _ := c.wd.FindElements(selenium.ByCSSSelector, item); len(items) == 0 {
+		c.l.Info("nothing is listed, skipping the checks of the items", zap.String("page", page))
+		return skipSelectors(page, defs)
+	}
+	return c.checkSelectors(page, defs)
+}
+
+// linkID returns the id in the URL of the link matched by selector, or "" if the link is not found.
+func (c *client) linkID(selector string) string {
+	link, err := c.wd.FindElement(selenium.ByCSSSelector, selector)
+	if err != nil {
+		return ""
+	}
+	href, err := link.GetAttribute("href")
+	if err != nil {
+		return ""
+	}
+	return idFromURL(href)
+}
+
+func (c *client) checkSelectors(page string, defs []selectorDef) []SelectorCheck {
+	var checks []SelectorCheck
+	for _, d := range defs {
+		check := SelectorCheck{Page: page, Name: d.name, By: d.by, Value: d.value}
+		if _, err := c.wd.FindElement(d.by, d.value); err != nil {
+			check.Error = err.Error()
+		} else {
+			check.OK = true
+		}
+		c.l.Debug("checked selector", zap.String("page", page), zap.String("name", d.name), zap.Bool("ok", check.OK))
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+func skipSelectors(page string, defs []selectorDef) []SelectorCheck {
+	var checks []SelectorCheck
+	for _, d := range defs {
+		checks = append(checks, SelectorCheck{Page: page, Name: d.name, By: d.by, Value: d.value, Skipped: true})
+	}
+	return checks
+}
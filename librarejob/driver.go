@@ -0,0 +1,443 @@
+package librarejob
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// DriverPaths holds the locations of the binaries required to run a local selenium server.
+type DriverPaths struct {
+	SeleniumPath     string `json:"selenium_path"`
+	GeckoDriverPath  string `json:"geckodriver_path,omitempty"`
+	ChromeDriverPath string `json:"chromedriver_path,omitempty"`
+}
+
+// DriverOpts configures EnsureDrivers.
+type DriverOpts struct {
+	// CacheDir is the directory to store downloaded binaries. DefaultDriverCacheDir is used if empty.
+	CacheDir string
+	// BrowserName is the browser to prepare the driver for. Firefox is used if empty.
+	BrowserName string
+	// Logger is the logger to report the progress of downloads. Nothing is logged if nil.
+	Logger *zap.Logger
+	// Checksums is the SHA-256 in hex of the downloads by file name, e.g. geckodriver-v0.33.0-linux64.tar.gz,
+	// which is checked instead of the checksum published by the host. The download without either fails.
+	Checksums map[string]string
+}
+
+// DefaultDriverCacheDir returns the directory where the downloaded drivers are cached by default.
+func DefaultDriverCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user cache directory: %w", err)
+	}
+	return filepath.Join(dir, "rarejobctl", "drivers"), nil
+}
+
+// EnsureDrivers downloads the selenium server and the webdriver for the given browser into the cache directory
+// unless they are already there, and returns their paths.
+func EnsureDrivers(ctx context.Context, opts DriverOpts) (*DriverPaths, error) {
+	l := opts.Logger
+	if l == nil {
+		l = zap.NewNop()
+	}
+	defer l.Sync()
+
+	dir := opts.CacheDir
+	if dir == "" {
+		d, err := DefaultDriverCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = d
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create driver cache directory: %w", err)
+	}
+
+	browser, err := parseBrowserType(opts.BrowserName)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths DriverPaths
+	paths.SeleniumPath = filepath.Join(dir, fmt.Sprintf("selenium-server-standalone-%s.jar", seleniumServerVersion))
+	if !fileExists(paths.SeleniumPath) {
+		url := fmt.Sprintf(seleniumServerDownloadURL, seleniumServerVersion)
+		l.Info("downloading selenium server", zap.String("url", url))
+		b, err := downloadVerified(ctx, url, opts.Checksums)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download selenium server: %w", err)
+		}
+		if err := writeFileAtomic(paths.SeleniumPath, b, 0644); err != nil {
+			return nil, fmt.Errorf("failed to save selenium server: %w", err)
+		}
+	}
+
+	switch browser {
+	case browserTypeFirefox:
+		paths.GeckoDriverPath, err = ensureGeckoDriver(ctx, l, dir, opts.Checksums)
+	case browserTypeChrome:
+		paths.ChromeDriverPath, err = ensureChromeDriver(ctx, l, dir, opts.Checksums)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	l.Debug("drivers are ready", zap.String("selenium", paths.SeleniumPath), zap.String("geckodriver", paths.GeckoDriverPath), zap.String("chromedriver", paths.ChromeDriverPath))
+	return &paths, nil
+}
+
+func ensureGeckoDriver(ctx context.Context, l *zap.Logger, dir string, checksums map[string]string) (string, error) {
+	name := "geckodriver"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	p := filepath.Join(dir, "geckodriver-"+geckoDriverVersion, name)
+	if fileExists(p) {
+		return p, nil
+	}
+
+	var platform, ext string
+	switch runtime.GOOS + "/" + runtime.GOARCH {
+	case "linux/amd64":
+		platform, ext = "linux64", "tar.gz"
+	case "linux/arm64":
+		platform, ext = "linux-aarch64", "tar.gz"
+	case "darwin/amd64":
+		platform, ext = "macos", "tar.gz"
+	case "darwin/arm64":
+		platform, ext = "macos-aarch64", "tar.gz"
+	case "windows/amd64":
+		platform, ext = "win64", "zip"
+	default:
+		return "", fmt.Errorf("geckodriver is not available for %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+
+	url := fmt.Sprintf(geckoDriverDownloadURL, geckoDriverVersion, geckoDriverVersion, platform, ext)
+	l.Info("downloading geckodriver", zap.String("url", url))
+	b, err := downloadVerified(ctx, url, checksums)
+	if err != nil {
+		return "", fmt.Errorf("failed to download geckodriver: %w", err)
+	}
+	var bin []byte
+	if ext == "zip" {
+		bin, err = extractFromZip(b, name)
+	} else {
+		bin, err = extractFromTarGz(b, name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to extract geckodriver: %w", err)
+	}
+	if err := writeFileAtomic(p, bin, 0755); err != nil {
+		return "", fmt.Errorf("failed to save geckodriver: %w", err)
+	}
+	return p, nil
+}
+
+func ensureChromeDriver(ctx context.Context, l *zap.Logger, dir string, checksums map[string]string) (string, error) {
+	var platform string
+	switch runtime.GOOS + "/" + runtime.GOARCH {
+	case "linux/amd64":
+		platform = "linux64"
+	case "darwin/amd64":
+		platform = "mac-x64"
+	case "darwin/arm64":
+		platform = "mac-arm64"
+	case "windows/amd64":
+		platform = "win64"
+	default:
+		return "", fmt.Errorf("chromedriver is not available for %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+
+	name := "chromedriver"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+
+	// chromedriver must match the major version of the installed browser, which the cached one may already do
+	release := "STABLE"
+	if major := detectChromeMajorVersion(ctx); major != "" {
+		if p := cachedChromeDriver(dir, major, name); p != "" {
+			return p, nil
+		}
+		release = major
+	}
+	b, _, err := download(ctx, fmt.Sprintf(chromeDriverLatestReleaseURL, release))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve chromedriver version: %w", err)
+	}
+	version := strings.TrimSpace(string(b))
+
+	p := filepath.Join(dir, "chromedriver-"+version, name)
+	if fileExists(p) {
+		return p, nil
+	}
+
+	url := fmt.Sprintf(chromeDriverDownloadURL, version, platform, platform)
+	l.Info("downloading chromedriver", zap.String("url", url))
+	b, err = downloadVerified(ctx, url, checksums)
+	if err != nil {
+		return "", fmt.Errorf("failed to download chromedriver: %w", err)
+	}
+	bin, err := extractFromZip(b, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract chromedriver: %w", err)
+	}
+	if err := writeFileAtomic(p, bin, 0755); err != nil {
+		return "", fmt.Errorf("failed to save chromedriver: %w", err)
+	}
+	return p, nil
+}
+
+// cachedChromeDriver returns the latest chromedriver of the major version in the cache directory,
+// or empty string if not found.
+func cachedChromeDriver(dir, major, name string) string {
+	matches, _ := filepath.Glob(filepath.Join(dir, "chromedriver-"+major+".*", name))
+	latest, latestVersion := "", []int(nil)
+	for _, m := range matches {
+		v := parseVersion(strings.TrimPrefix(filepath.Base(filepath.Dir(m)), "chromedriver-"))
+		if v != nil && (latestVersion == nil || slices.Compare(v, latestVersion) > 0) {
+			latest, latestVersion = m, v
+		}
+	}
+	return latest
+}
+
+// parseVersion parses the dotted version, e.g. 120.0.6099.109. It returns nil if invalid.
+func parseVersion(s string) []int {
+	var v []int
+	for _, f := range strings.Split(s, ".") {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return nil
+		}
+		v = append(v, n)
+	}
+	return v
+}
+
+// detectChromeMajorVersion returns the major version of the installed chrome, or empty string if not found.
+func detectChromeMajorVersion(ctx context.Context) string {
+	for _, bin := range []string{"google-chrome", "google-chrome-stable", "chromium", "chromium-browser"} {
+		out, err := exec.CommandContext(ctx, bin, "--version").Output()
+		if err != nil {
+			continue
+		}
+		// e.g. "Google Chrome 120.0.6099.109"
+		fields := strings.Fields(string(out))
+		if len(fields) == 0 {
+			continue
+		}
+		return strings.Split(fields[len(fields)-1], ".")[0]
+	}
+	return ""
+}
+
+func download(ctx context.Context, url string) ([]byte, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, url)
+	}
+	b, err := io.ReadAll(resp.Body)
+	return b, resp.Header, err
+}
+
+// downloadVerified downloads the binary from url and verifies it with the SHA-256 of its file name in checksums,
+// or the checksum published by the host if not pinned, since the binary is executed.
+func downloadVerified(ctx context.Context, url string, checksums map[string]string) ([]byte, error) {
+	b, h, err := download(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyDownload(ctx, url, b, h, checksums); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// verifyDownload checks b downloaded from url against the SHA-256 pinned in checksums, or the MD5 which Cloud Storage
+// sends in X-Goog-Hash, or the SHA-256 digest of the asset of the GitHub release.
+func verifyDownload(ctx context.Context, url string, b []byte, h http.Header, checksums map[string]string) error {
+	name := path.Base(url)
+	if want, ok := checksums[name]; ok {
+		sum := sha256.Sum256(b)
+		if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, want) {
+			return fmt.Errorf("%w: sha256 of %s is %s, want %s", ErrDriverUnverified, name, got, want)
+		}
+		return nil
+	}
+
+	if want := googHash(h, "md5"); want != "" {
+		sum := md5.Sum(b)
+		if got := base64.StdEncoding.EncodeToString(sum[:]); got != want {
+			return fmt.Errorf("%w: md5 of %s is %s, want %s published by the storage", ErrDriverUnverified, name, got, want)
+		}
+		return nil
+	}
+
+	if owner, repo, tag, ok := githubRelease(url); ok {
+		want, err := githubAssetDigest(ctx, owner, repo, tag, name)
+		if err != nil {
+			return fmt.Errorf("failed to get the checksum of %s: %w", name, err)
+		}
+		if want != "" {
+			sum := sha256.Sum256(b)
+			if got := hex.EncodeToString(sum[:]); got != want {
+				return fmt.Errorf("%w: sha256 of %s is %s, want %s published by the release", ErrDriverUnverified, name, got, want)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: no checksum of %s is published, pin its sha256", ErrDriverUnverified, name)
+}
+
+// googHash returns the hash of the algorithm in the X-Goog-Hash header, e.g. "crc32c=n03x6A==, md5=Ojk9c3dhfxgoKVVHYwFbHQ==".
+func googHash(h http.Header, alg string) string {
+	for _, v := range h.Values("X-Goog-Hash") {
+		for _, kv := range strings.Split(v, ",") {
+			if k, hash, ok := strings.Cut(strings.TrimSpace(kv), "="); ok && k == alg {
+				return hash
+			}
+		}
+	}
+	return ""
+}
+
+// githubRelease parses the URL of the asset of the GitHub release, e.g.
+// https://github.com/mozilla/geckodriver/releases/download/v0.33.0/geckodriver-v0.33.0-linux64.tar.gz.
+func githubRelease(url string) (owner, repo, tag string, ok bool) {
+	rest, ok := strings.CutPrefix(url, "https://github.com/")
+	if !ok {
+		return "", "", "", false
+	}
+	parts := strings.Split(rest, "/")
+	if len(parts) != 6 || parts[2] != "releases" || parts[3] != "download" {
+		return "", "", "", false
+	}
+	return parts[0], parts[1], parts[4], true
+}
+
+// githubAssetDigest returns the SHA-256 in hex of the asset of the release, or empty string if GitHub has no digest of it.
+func githubAssetDigest(ctx context.Context, owner, repo, tag, name string) (string, error) {
+	b, _, err := download(ctx, fmt.Sprintf(githubReleaseAPIURL, owner, repo, tag))
+	if err != nil {
+		return "", err
+	}
+	var release struct {
+		Assets []struct {
+			Name   string `json:"name"`
+			Digest string `json:"digest"`
+		} `json:"assets"`
+	}
+	if err := json.Unmarshal(b, &release); err != nil {
+		return "", err
+	}
+	for _, a := range release.Assets {
+		if a.Name == name {
+			digest, _ := strings.CutPrefix(a.Digest, "sha256:")
+			return strings.ToLower(digest), nil
+		}
+	}
+	return "", nil
+}
+
+func extractFromTarGz(b []byte, name string) ([]byte, error) {
+	gr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if path.Base(h.Name) == name {
+			return io.ReadAll(tr)
+		}
+	}
+	return nil, fmt.Errorf("%s not found in archive", name)
+}
+
+func extractFromZip(b []byte, name string) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range zr.File {
+		if path.Base(f.Name) != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	return nil, fmt.Errorf("%s not found in archive", name)
+}
+
+// writeFileAtomic writes b into the temporary file in the same directory and renames it to p,
+// so that the other processes see either nothing or the whole file.
+func writeFileAtomic(p string, b []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(p), filepath.Base(p)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), p)
+}
+
+func fileExists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
@@ -0,0 +1,73 @@
+package librarejob
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyDownload(t *testing.T) {
+	b := []byte("driver")
+	sha := sha256.Sum256(b)
+	md := md5.Sum(b)
+	const url = "https://storage.googleapis.com/chrome-for-testing-public/120.0.6099.109/linux64/chromedriver-linux64.zip"
+	tests := []struct {
+		name      string
+		header    http.Header
+		checksums map[string]string
+		wantErr   bool
+	}{
+		{"pinned", nil, map[string]string{"chromedriver-linux64.zip": hex.EncodeToString(sha[:])}, false},
+		{"pinned mismatch", nil, map[string]string{"chromedriver-linux64.zip": hex.EncodeToString(make([]byte, 32))}, true},
+		{"pinned over published", http.Header{"X-Goog-Hash": {"md5=AAAAAAAAAAAAAAAAAAAAAA=="}}, map[string]string{"chromedriver-linux64.zip": hex.EncodeToString(sha[:])}, false},
+		{"published", http.Header{"X-Goog-Hash": {"crc32c=n03x6A==, md5=" + base64.StdEncoding.EncodeToString(md[:])}}, nil, false},
+		{"published in separate headers", http.Header{"X-Goog-Hash": {"crc32c=n03x6A==", "md5=" + base64.StdEncoding.EncodeToString(md[:])}}, nil, false},
+		{"published mismatch", http.Header{"X-Goog-Hash": {"md5=AAAAAAAAAAAAAAAAAAAAAA=="}}, nil, true},
+		{"no checksum", http.Header{"X-Goog-Hash": {"crc32c=n03x6A=="}}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyDownload(context.Background(), url, b, tt.header, tt.checksums)
+			if tt.wantErr {
+				if !errors.Is(err, ErrDriverUnverified) {
+					t.Errorf("verifyDownload() = %v, want ErrDriverUnverified", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("verifyDownload() = %v", err)
+			}
+		})
+	}
+}
+
+func TestCachedChromeDriver(t *testing.T) {
+	dir := t.TempDir()
+	for _, v := range []string{"120.0.6099.9", "120.0.6099.109", "121.0.6167.85", "120.0.beta"} {
+		if err := writeFileAtomic(filepath.Join(dir, "chromedriver-"+v, "chromedriver"), []byte("driver"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := cachedChromeDriver(dir, "120", "chromedriver"), filepath.Join(dir, "chromedriver-120.0.6099.109", "chromedriver"); got != want {
+		t.Errorf("cachedChromeDriver(120) = %s, want %s", got, want)
+	}
+	if got := cachedChromeDriver(dir, "12", "chromedriver"); got != "" {
+		t.Errorf("cachedChromeDriver(12) = %s, want none", got)
+	}
+
+	// writeFileAtomic leaves no temporary file
+	entries, err := os.ReadDir(filepath.Join(dir, "chromedriver-120.0.6099.109"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("entries = %v, want only chromedriver", entries)
+	}
+}
@@ -1,7 +1,91 @@
 package librarejob
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 var (
 	ErrSpreadAcrossTwoDays = errors.New("specified duration are spreading across 2 days")
+	ErrInvalidOptions      = errors.New("invalid client options")
+
+	ErrLoginFailed = errors.New("login failed")
+	// ErrInvalidCredentials and ErrAccountLocked are the reasons of the login failure told by the site.
+	// Both match ErrLoginFailed with errors.Is.
+	ErrInvalidCredentials = fmt.Errorf("%w: invalid email or password", ErrLoginFailed)
+	ErrAccountLocked      = fmt.Errorf("%w: account is locked", ErrLoginFailed)
+	// ErrSiteMaintenance is returned when the site is under maintenance.
+	ErrSiteMaintenance      = errors.New("site is under maintenance")
+	ErrNoTutorsAvailable    = errors.New("no tutors available")
+	ErrSlotTaken            = errors.New("the slot has been taken")
+	ErrSessionExpired       = errors.New("session expired")
+	ErrCancelDeadlinePassed = errors.New("cancellation deadline has passed")
+	ErrReservationNotFound  = errors.New("reservation not found")
+	ErrConflict             = errors.New("the slot overlaps another schedule")
+	// ErrSlotUnavailable is returned when no tutor offers the exact slot requested. It matches ErrNoTutorsAvailable with errors.Is.
+	ErrSlotUnavailable = fmt.Errorf("%w: the slot is not offered", ErrNoTutorsAvailable)
+	// ErrReservationUnverified is returned when the reservation confirmed is not found on my page as selected.
+	ErrReservationUnverified = errors.New("reservation could not be verified")
+	// ErrMaterialNotFound is returned when no material is assigned to the lesson yet.
+	ErrMaterialNotFound = errors.New("material not found")
+	// ErrHumanVerificationRequired is returned when the site asks for a CAPTCHA or an additional verification during login.
+	ErrHumanVerificationRequired = errors.New("human verification required")
+	// ErrDriverUnverified is returned when the checksum of the downloaded driver doesn't match or is not available.
+	ErrDriverUnverified = errors.New("downloaded driver could not be verified")
 )
+
+// NoTutorsAvailableError is returned when the search result has no tutor in the searched window.
+// It matches ErrNoTutorsAvailable with errors.Is.
+type NoTutorsAvailableError struct {
+	From time.Time
+	To   time.Time
+}
+
+func (e *NoTutorsAvailableError) Error() string {
+	return fmt.Sprintf("%s between %s and %s", ErrNoTutorsAvailable, e.From.Format(time.DateTime), e.To.Format(time.DateTime))
+}
+
+func (e *NoTutorsAvailableError) Is(target error) bool {
+	return target == ErrNoTutorsAvailable
+}
+
+// errorCodes maps the errors to stable codes which can be used in logs, metrics and machine-readable outputs.
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{ErrSpreadAcrossTwoDays, "spread_across_two_days"},
+	{ErrInvalidOptions, "invalid_options"},
+	// the specific login failures go first since they also match ErrLoginFailed
+	{ErrInvalidCredentials, "invalid_credentials"},
+	{ErrAccountLocked, "account_locked"},
+	{ErrLoginFailed, "login_failed"},
+	{ErrSiteMaintenance, "site_maintenance"},
+	// the exact slot goes first since it also matches ErrNoTutorsAvailable
+	{ErrSlotUnavailable, "slot_unavailable"},
+	{ErrNoTutorsAvailable, "no_tutors_available"},
+	{ErrSlotTaken, "slot_taken"},
+	{ErrSessionExpired, "session_expired"},
+	{ErrCancelDeadlinePassed, "cancel_deadline_passed"},
+	{ErrReservationNotFound, "reservation_not_found"},
+	{ErrConflict, "conflict"},
+	{ErrReservationUnverified, "reservation_unverified"},
+	{ErrMaterialNotFound, "material_not_found"},
+	{ErrHumanVerificationRequired, "human_verification_required"},
+	{ErrDriverUnverified, "driver_unverified"},
+}
+
+// ErrorCode returns the code of the failure class of err. It returns "" for nil, and "unknown" for errors
+// which don't belong to any class.
+func ErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	for _, e := range errorCodes {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,118 @@
+package librarejob
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// extractTutorsScript reads every tutor in the search result at once to save the round-trips of the webdriver.
+// It takes the list of selectors for each tutor, and the %d in the slot selector is replaced with the 1-origin slot index.
+const extractTutorsScript = `
+var text = function (sel) {
+	var e = document.querySelector(sel);
+	return e ? e.innerText.trim() : null;
+};
+var result = arguments[0].map(function (t) {
+	var slots = [];
+	var n = document.querySelectorAll(t.slots).length;
+	for (var i = 1; i <= n; i++) {
+		slots.push(text(t.slot.replace("%d", i)));
+	}
+	var link = document.querySelector(t.link);
+	return {name: text(t.name), rating: text(t.rating), link: link ? link.href : null, slots: slots};
+});
+return JSON.stringify(result);
+`
+
+// extractTutorArg is the selectors of a tutor passed to extractTutorsScript.
+type extractTutorArg struct {
+	Name   string `json:"name"`
+	Rating string `json:"rating"`
+	Slots  string `json:"slots"`
+	Slot   string `json:"slot"`
+	Link   string `json:"link"`
+}
+
+// extractedTutor is a tutor returned by extractTutorsScript. Missing elements are null.
+type extractedTutor struct {
+	Name   *string   `json:"name"`
+	Rating *string   `json:"rating"`
+	Link   *string   `json:"link"`
+	Slots  []*string `json:"slots"`
+}
+
+// extractTutors reads n tutors in the search result with a single script execution.
+func (p tutorSearchPage) extractTutors(ctx context.Context, n int) (Tutors, error) {
+	c, from := p.c, p.from
+	args := make([]extractTutorArg, n)
+	for i := range args {
+		tnum := i + 1
+		args[i] = extractTutorArg{
+			Name:   fmt.Sprintf(c.sel.TutorName, tnum),
+			Rating: fmt.Sprintf(c.sel.TutorRating, tnum),
+			Slots:  fmt.Sprintf(c.sel.TutorTimeSlot, tnum),
+			// only the tutor index is filled here, the slot index is filled by the script
+			Slot: strings.Replace(c.sel.TutorTimeSlotButton, "%d", strconv.Itoa(tnum), 1),
+			Link: fmt.Sprintf(c.sel.TutorLink, tnum),
+		}
+	}
+
+	out, err := retry(ctx, c.l, c.retry, "execute_script", func() (interface{}, error) {
+		if err := c.throttle.waitQuery(ctx); err != nil {
+			return nil, err
+		}
+		return c.wd.ExecuteScript(extractTutorsScript, []interface{}{args})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute script: %w", err)
+	}
+	s, ok := out.(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected result of script: %T", out)
+	}
+	var extracted []extractedTutor
+	if err := json.Unmarshal([]byte(s), &extracted); err != nil {
+		return nil, fmt.Errorf("failed to parse result of script: %w", err)
+	}
+	if len(extracted) != n {
+		return nil, fmt.Errorf("script returned %d tutors, expected %d", len(extracted), n)
+	}
+
+	tutors := make(Tutors, n)
+	for i, e := range extracted {
+		var t Tutor
+		if e.Name != nil {
+			t.Name = *e.Name
+		}
+		if e.Link != nil {
+			t.ID = idFromURL(*e.Link)
+		}
+		if e.Rating != nil {
+			t.Rating, _ = strconv.ParseFloat(*e.Rating, 64)
+		}
+		// if not parsable, leave the slot unparsed to preserve index
+		t.AvailableSlots = make([]Slot, len(e.Slots))
+		for j, slotText := range e.Slots {
+			t.AvailableSlots[j] = Slot{Status: SlotUnparsed}
+			if slotText == nil {
+				continue
+			}
+			t.AvailableSlots[j].Text = *slotText
+			h, m, err := parseTime(*slotText)
+			if err != nil {
+				continue
+			}
+			t.AvailableSlots[j] = AvailableSlot(time.Date(from.Year(), from.Month(), from.Day(), h, m, 0, 0, from.Location()))
+		}
+		tutors[i] = t
+		sendProgress(ctx, ProgressEvent{Type: ProgressTutorFound, Tutor: &tutors[i]})
+	}
+	c.l.Debug("extracted tutors with script", zap.Int("count", n))
+	return tutors, nil
+}
@@ -0,0 +1,116 @@
+package librarejob
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/tebeka/selenium"
+	"go.uber.org/zap"
+)
+
+// Lesson is a past lesson in the lesson history.
+type Lesson struct {
+	// ID is the id of the lesson to fetch its report. Empty if the lesson has no report.
+	ID        string    `json:"id"`
+	TutorName string    `json:"tutor_name"`
+	StartAt   time.Time `json:"start_at"`
+	Material  string    `json:"material"`
+	// Status is the status text shown on the site as is, e.g. completed or absent.
+	Status    string `json:"status"`
+	Completed bool   `json:"completed"`
+}
+
+var lessonDatePattern = regexp.MustCompile(`(\d{4})[/年-](\d{1,2})[/月-](\d{1,2})日?\D*?(\d{1,2}):(\d{2})`)
+
+// parseLessonDate parses the date of the lesson such as "2022/12/27 21:00" or "2022年12月27日(火) 21:00".
+func parseLessonDate(s string, loc *time.Location) (time.Time, error) {
+	m := lessonDatePattern.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, fmt.Errorf("invalid lesson date: %s", s)
+	}
+	return time.ParseInLocation("2006-1-2 15:04", fmt.Sprintf("%s-%s-%s %s:%s", m[1], m[2], m[3], m[4], m[5]), loc)
+}
+
+func (c *client) LessonHistory(ctx context.Context, since time.Time) (_ []Lesson, err error) {
+	unlock, err := c.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	defer c.l.Sync()
+	defer c.flushConsoleLogs()
+	defer func() { err = c.captureFailure("lesson_history", err) }()
+
+	var lessons []Lesson
+	// the history is listed from the newest, so stop at the page which reaches since
+	for page := 1; page <= maxLessonHistoryPages; page++ {
+		c.l.Debug("loading lesson history", zap.Int("page", page))
+		if err := c.get(ctx, fmt.Sprintf(rarejobLessonHistoryURL, page)); err != nil {
+			return nil, fmt.Errorf("failed to get lesson history: %w", err)
+		}
+		if c.isAt(rarejobLoginURL) {
+			return nil, ErrSessionExpired
+		}
+
+		items, err := c.findElements(ctx, selenium.ByCSSSelector, c.sel.LessonHistoryItem)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get lesson history: %w", err)
+		}
+		if len(items) == 0 {
+			break
+		}
+
+		reached := false
+		for n := 1; n <= len(items); n++ {
+			lesson, err := c.scrapeLesson(ctx, n)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read lesson #%d on page %d: %w", n, page, err)
+			}
+			if lesson.StartAt.Before(since) {
+				reached = true
+				continue
+			}
+			lessons = append(lessons, lesson)
+		}
+		if reached {
+			break
+		}
+	}
+
+	c.l.Info("found lessons in history", zap.Int("count", len(lessons)))
+	return lessons, nil
+}
+
+// scrapeLesson reads the lesson at the 1-origin index n in the lesson history page.
+func (c *client) scrapeLesson(ctx context.Context, n int) (Lesson, error) {
+	dateText, err := c.elementText(ctx, selenium.ByCSSSelector, fmt.Sprintf(c.sel.LessonHistoryDate, n))
+	if err != nil {
+		return Lesson{}, fmt.Errorf("failed to get date: %w", err)
+	}
+	startAt, err := parseLessonDate(dateText, c.loc)
+	if err != nil {
+		return Lesson{}, err
+	}
+	// the tutor and material are missing for some lessons, e.g. cancelled ones
+	name, _ := c.elementText(ctx, selenium.ByCSSSelector, fmt.Sprintf(c.sel.LessonHistoryTutor, n))
+	material, _ := c.elementText(ctx, selenium.ByCSSSelector, fmt.Sprintf(c.sel.LessonHistoryMaterial, n))
+	status, _ := c.elementText(ctx, selenium.ByCSSSelector, fmt.Sprintf(c.sel.LessonHistoryStatus, n))
+	status = strings.TrimSpace(status)
+	var id string
+	if link, err := c.wd.FindElement(selenium.ByCSSSelector, fmt.Sprintf(c.sel.LessonHistoryReportLink, n)); err == nil {
+		if href, err := link.GetAttribute("href"); err == nil {
+			id = idFromURL(href)
+		}
+	}
+	return Lesson{
+		ID:        id,
+		TutorName: strings.TrimSpace(name),
+		StartAt:   startAt,
+		Material:  strings.TrimSpace(material),
+		Status:    status,
+		Completed: strings.Contains(status, c.sel.LessonHistoryCompletedText),
+	}, nil
+}
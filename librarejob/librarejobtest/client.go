@@ -0,0 +1,560 @@
+// Package librarejobtest provides a fake librarejob.Client to test the applications embedding librarejob without selenium.
+package librarejobtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+)
+
+// durations are the length of the session for each reservation type, which are the same as the site.
+var durations = map[librarejob.ReservationType]time.Duration{
+	librarejob.ReservationTypeLesson:       25 * time.Minute,
+	librarejob.ReservationTypeCounseling:   25 * time.Minute,
+	librarejob.ReservationTypeSpeakingTest: 15 * time.Minute,
+}
+
+// slotInterval is the time between the starts of the slots next to each other.
+const slotInterval = 30 * time.Minute
+
+// Call is a recorded call of the client method.
+type Call struct {
+	Method string
+	// Args are the arguments except the context.
+	Args []interface{}
+}
+
+// Client is a fake librarejob.Client backed by the fields. Set the fields before using it, and use the methods
+// to inspect or change it while in use since the client is safe for concurrent use.
+type Client struct {
+	// Email and Password are the credentials accepted by Login. Any credentials are accepted if both are empty.
+	Email    string
+	Password string
+	// Tutors are searched and reserved. A reserved slot is removed from AvailableSlots.
+	Tutors librarejob.Tutors
+	// Reservations are the upcoming reservations. Reserve appends to them and Cancel removes from them.
+	Reservations []librarejob.Reserve
+	// Lessons are the lesson history, newest first.
+	Lessons []librarejob.Lesson
+	// Profiles are the tutors returned by GetTutorProfile by the id. The tutor in Tutors is returned if missing.
+	Profiles map[string]librarejob.Tutor
+	// Reports are the lesson reports by the lesson id.
+	Reports map[string]librarejob.LessonReport
+	// Materials are the lesson materials by the MaterialURL of the reservations.
+	Materials map[string]librarejob.Material
+	// Account is returned by AccountStatus.
+	Account librarejob.AccountStatus
+	// DryRun makes Reserve stop right before confirming the reservation as the real client does.
+	DryRun bool
+	// Now returns the current time to check the cancellation deadline. time.Now is used if nil.
+	Now func() time.Time
+
+	mu       sync.Mutex
+	loggedIn bool
+	calls    []Call
+	errs     map[string]error
+	nextErrs map[string][]error
+}
+
+var _ librarejob.Client = (*Client)(nil)
+
+// NewClient returns the fake client with the tutors.
+func NewClient(tutors librarejob.Tutors) *Client {
+	return &Client{Tutors: tutors}
+}
+
+// Fail makes every call of the method, e.g. "Reserve", return err. nil clears the error.
+func (c *Client) Fail(method string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.errs == nil {
+		c.errs = map[string]error{}
+	}
+	c.errs[method] = err
+}
+
+// FailNext makes the next calls of the method return errs in order. The calls after them behave as usual.
+func (c *Client) FailNext(method string, errs ...error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.nextErrs == nil {
+		c.nextErrs = map[string][]error{}
+	}
+	c.nextErrs[method] = append(c.nextErrs[method], errs...)
+}
+
+// Calls returns the recorded calls in order.
+func (c *Client) Calls() []Call {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Call(nil), c.calls...)
+}
+
+// CallsOf returns the recorded calls of the method in order.
+func (c *Client) CallsOf(method string) []Call {
+	var calls []Call
+	for _, call := range c.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// record records the call and returns the error injected to it. It must be called with mu held.
+func (c *Client) record(method string, args ...interface{}) error {
+	c.calls = append(c.calls, Call{Method: method, Args: args})
+	if errs := c.nextErrs[method]; len(errs) > 0 {
+		c.nextErrs[method] = errs[1:]
+		return errs[0]
+	}
+	return c.errs[method]
+}
+
+func (c *Client) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+func (c *Client) Login(ctx context.Context, username, password string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// the password is not recorded not to leak it into the test logs
+	if err := c.record("Login", username); err != nil {
+		return err
+	}
+	if (c.Email != "" || c.Password != "") && (username != c.Email || password != c.Password) {
+		return librarejob.ErrInvalidCredentials
+	}
+	c.loggedIn = true
+	return nil
+}
+
+// ExportSession returns the session cookie of the email logged in with. It returns ErrSessionExpired if not logged in.
+func (c *Client) ExportSession(ctx context.Context) ([]librarejob.Cookie, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record("ExportSession"); err != nil {
+		return nil, err
+	}
+	if err := c.checkSession(); err != nil {
+		return nil, err
+	}
+	return []librarejob.Cookie{{Name: sessionCookie, Value: c.Email}}, nil
+}
+
+// ResumeSession logs in if the cookies have the session cookie of Email as exported by ExportSession.
+// It returns ErrSessionExpired otherwise.
+func (c *Client) ResumeSession(ctx context.Context, cookies []librarejob.Cookie) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// the cookies are not recorded not to leak them into the test logs
+	if err := c.record("ResumeSession"); err != nil {
+		return err
+	}
+	for _, ck := range cookies {
+		if ck.Name == sessionCookie && ck.Value == c.Email {
+			c.loggedIn = true
+			return nil
+		}
+	}
+	return librarejob.ErrSessionExpired
+}
+
+func (c *Client) SearchTutors(ctx context.Context, from time.Time, margin time.Duration) (librarejob.Tutors, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record("SearchTutors", from, margin); err != nil {
+		return nil, err
+	}
+	return c.search(from, margin)
+}
+
+// search returns the tutors who have slots in the window with only those slots as the real search does.
+func (c *Client) search(from time.Time, margin time.Duration) (librarejob.Tutors, error) {
+	if err := c.checkSession(); err != nil {
+		return nil, err
+	}
+	by := from.Add(margin)
+	if !(margin < 24*time.Hour && from.Hour() <= by.Hour()) {
+		return nil, librarejob.ErrSpreadAcrossTwoDays
+	}
+	var tutors librarejob.Tutors
+	for _, t := range c.Tutors {
+		var slots []librarejob.Slot
+		for _, s := range t.AvailableSlots {
+			if s.Available() && !s.StartAt.Before(from) && !s.StartAt.After(by) {
+				slots = append(slots, s)
+			}
+		}
+		if len(slots) > 0 {
+			t.AvailableSlots = slots
+			tutors = append(tutors, t)
+		}
+	}
+	if len(tutors) == 0 {
+		return nil, &librarejob.NoTutorsAvailableError{From: from, To: by}
+	}
+	return tutors, nil
+}
+
+func (c *Client) checkSession() error {
+	if !c.loggedIn {
+		return librarejob.ErrSessionExpired
+	}
+	return nil
+}
+
+func (c *Client) CheckSelectors(ctx context.Context, username, password string, from time.Time, margin time.Duration) ([]librarejob.SelectorCheck, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record("CheckSelectors", username, from, margin); err != nil {
+		return nil, err
+	}
+	return []librarejob.SelectorCheck{}, nil
+}
+
+func (c *Client) ReserveTutor(ctx context.Context, pref librarejob.Preference) (*librarejob.Reserve, error) {
+	return c.Reserve(ctx, librarejob.ReserveRequest{Type: librarejob.ReservationTypeLesson, Preference: &pref})
+}
+
+// Reserve reserves the first slot in the order of Tutors which doesn't overlap the existing reservations or req.Busy
+// unless req.Force, except the one replaced by req.Replacing, or the best one of them if req.Preference is set. req.Schedule is the same as matching req.Tutor. The times of day of the preference are of
+// the location of its first window. It returns the existing reservation of the same type in the windows instead if any.
+func (c *Client) Reserve(ctx context.Context, req librarejob.ReserveRequest) (*librarejob.Reserve, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record("Reserve", req); err != nil {
+		return nil, err
+	}
+
+	typ, err := librarejob.ParseReservationType(string(req.Type))
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkSession(); err != nil {
+		return nil, err
+	}
+	pref := librarejob.Preference{Windows: []librarejob.Window{{From: req.From, Margin: req.Margin}}}
+	if req.Preference != nil {
+		pref = *req.Preference
+	}
+	if req.Exact {
+		if req.Preference != nil {
+			return nil, fmt.Errorf("%w: the exact slot can't be reserved with the preference", librarejob.ErrInvalidOptions)
+		}
+		pref = librarejob.Preference{Windows: []librarejob.Window{{From: req.From}}}
+	}
+	if err := pref.Validate(); err != nil {
+		return nil, err
+	}
+	loc := pref.Windows[0].From.Location()
+	var reservations []librarejob.Reserve
+	for _, r := range c.Reservations {
+		if req.Replacing.IsZero() || !r.StartAt.Equal(req.Replacing) {
+			reservations = append(reservations, r)
+		}
+	}
+	for _, r := range reservations {
+		if _, ok := pref.Rank(r.StartAt, loc); ok && r.Type == typ {
+			r.AlreadyExists = true
+			return &r, nil
+		}
+	}
+
+	var busy []librarejob.Interval
+	if !req.Force {
+		busy = append(busy, req.Busy...)
+		for _, r := range reservations {
+			busy = append(busy, librarejob.Interval{Start: r.StartAt, End: r.EndAt})
+		}
+	}
+
+	duration := durations[typ]
+	conflict := false
+	var best *librarejob.Reserve
+	bestRank := 0
+	for _, w := range pref.Windows {
+		tutors, err := c.search(w.From, w.Margin)
+		if errors.Is(err, librarejob.ErrNoTutorsAvailable) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tutors {
+			if req.Tutor != "" && req.Tutor != t.ID && req.Tutor != t.Name {
+				continue
+			}
+			for _, s := range t.OpenSlots() {
+				rank, ok := pref.Rank(s, loc)
+				if !ok || best != nil && rank >= bestRank {
+					continue
+				}
+				end := s.Add(duration)
+				if req.Consecutive {
+					if !c.hasSlot(t.Name, s.Add(slotInterval)) {
+						continue
+					}
+					end = s.Add(slotInterval + duration)
+				}
+				if overlaps(busy, s, end) {
+					conflict = true
+					continue
+				}
+				best = &librarejob.Reserve{Type: typ, Name: t.Name, StartAt: s, EndAt: end, DryRun: c.DryRun}
+				bestRank = rank
+			}
+		}
+	}
+	if best == nil {
+		if conflict {
+			return nil, fmt.Errorf("%w: every available slot overlaps the other schedule", librarejob.ErrConflict)
+		}
+		if req.Exact {
+			return nil, fmt.Errorf("%w at %s", librarejob.ErrSlotUnavailable, req.From.Format(time.DateTime))
+		}
+		last := pref.Windows[len(pref.Windows)-1]
+		return nil, &librarejob.NoTutorsAvailableError{From: pref.Windows[0].From, To: last.From.Add(last.Margin)}
+	}
+	if c.DryRun {
+		return best, nil
+	}
+	c.takeSlot(best.Name, best.StartAt)
+	if req.Consecutive {
+		c.takeSlot(best.Name, best.StartAt.Add(slotInterval))
+	}
+	c.Reservations = append(c.Reservations, *best)
+	sort.Slice(c.Reservations, func(i, j int) bool { return c.Reservations[i].StartAt.Before(c.Reservations[j].StartAt) })
+	return best, nil
+}
+
+func (c *Client) hasSlot(name string, startAt time.Time) bool {
+	for _, t := range c.Tutors {
+		if t.Name != name {
+			continue
+		}
+		for _, s := range t.AvailableSlots {
+			if s.Available() && s.StartAt.Equal(startAt) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *Client) takeSlot(name string, startAt time.Time) {
+	for i, t := range c.Tutors {
+		if t.Name != name {
+			continue
+		}
+		var slots []librarejob.Slot
+		for _, s := range t.AvailableSlots {
+			if !s.StartAt.Equal(startAt) {
+				slots = append(slots, s)
+			}
+		}
+		c.Tutors[i].AvailableSlots = slots
+	}
+}
+
+func overlaps(busy []librarejob.Interval, start, end time.Time) bool {
+	for _, b := range busy {
+		if b.Overlaps(start, end) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) LessonHistory(ctx context.Context, since time.Time) ([]librarejob.Lesson, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record("LessonHistory", since); err != nil {
+		return nil, err
+	}
+	if err := c.checkSession(); err != nil {
+		return nil, err
+	}
+	var lessons []librarejob.Lesson
+	for _, l := range c.Lessons {
+		if !l.StartAt.Before(since) {
+			lessons = append(lessons, l)
+		}
+	}
+	return lessons, nil
+}
+
+func (c *Client) GetTutorProfile(ctx context.Context, id string) (*librarejob.Tutor, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record("GetTutorProfile", id); err != nil {
+		return nil, err
+	}
+	if err := c.checkSession(); err != nil {
+		return nil, err
+	}
+	if t, ok := c.Profiles[id]; ok {
+		return &t, nil
+	}
+	for _, t := range c.Tutors {
+		if t.ID == id {
+			t.AvailableSlots = nil
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("tutor not found: %s", id)
+}
+
+// TutorSchedule returns the tutor in Tutors with the id and only the slots on the date in the location of date.
+func (c *Client) TutorSchedule(ctx context.Context, id string, date time.Time) (*librarejob.Tutor, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record("TutorSchedule", id, date); err != nil {
+		return nil, err
+	}
+	if err := c.checkSession(); err != nil {
+		return nil, err
+	}
+	y, m, d := date.Date()
+	for _, t := range c.Tutors {
+		if t.ID != id {
+			continue
+		}
+		slots := []librarejob.Slot{}
+		for _, s := range t.AvailableSlots {
+			if sy, sm, sd := s.StartAt.In(date.Location()).Date(); s.Available() && sy == y && sm == m && sd == d {
+				slots = append(slots, s)
+			}
+		}
+		t.AvailableSlots = slots
+		return &t, nil
+	}
+	return nil, fmt.Errorf("tutor not found: %s", id)
+}
+
+func (c *Client) FetchLessonReport(ctx context.Context, lessonID string) (*librarejob.LessonReport, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record("FetchLessonReport", lessonID); err != nil {
+		return nil, err
+	}
+	if err := c.checkSession(); err != nil {
+		return nil, err
+	}
+	r, ok := c.Reports[lessonID]
+	if !ok {
+		return nil, fmt.Errorf("lesson report not found: %s", lessonID)
+	}
+	return &r, nil
+}
+
+func (c *Client) AccountStatus(ctx context.Context) (*librarejob.AccountStatus, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record("AccountStatus"); err != nil {
+		return nil, err
+	}
+	if err := c.checkSession(); err != nil {
+		return nil, err
+	}
+	a := c.Account
+	return &a, nil
+}
+
+func (c *Client) ListReservations(ctx context.Context) ([]librarejob.Reserve, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record("ListReservations"); err != nil {
+		return nil, err
+	}
+	if err := c.checkSession(); err != nil {
+		return nil, err
+	}
+	return append([]librarejob.Reserve{}, c.Reservations...), nil
+}
+
+func (c *Client) LessonRoomURL(ctx context.Context, startAt time.Time) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record("LessonRoomURL", startAt); err != nil {
+		return "", err
+	}
+	if err := c.checkSession(); err != nil {
+		return "", err
+	}
+	for _, r := range c.Reservations {
+		if startAt.IsZero() || r.StartAt.Equal(startAt) {
+			if r.LessonRoomURL == "" {
+				return "", fmt.Errorf("lesson room of the reservation at %s is not open yet", r.StartAt.Format(time.DateTime))
+			}
+			return r.LessonRoomURL, nil
+		}
+	}
+	return "", librarejob.ErrReservationNotFound
+}
+
+func (c *Client) LessonMaterial(ctx context.Context, startAt time.Time) (*librarejob.Material, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record("LessonMaterial", startAt); err != nil {
+		return nil, err
+	}
+	if err := c.checkSession(); err != nil {
+		return nil, err
+	}
+	for _, r := range c.Reservations {
+		if startAt.IsZero() || r.StartAt.Equal(startAt) {
+			m, ok := c.Materials[r.MaterialURL]
+			if r.MaterialURL == "" || !ok {
+				return nil, fmt.Errorf("%w: no material is assigned to the lesson at %s", librarejob.ErrMaterialNotFound, r.StartAt.Format(time.DateTime))
+			}
+			m.URL, m.StartAt = r.MaterialURL, r.StartAt
+			return &m, nil
+		}
+	}
+	return nil, librarejob.ErrReservationNotFound
+}
+
+func (c *Client) Cancel(ctx context.Context, startAt time.Time, force bool) (*librarejob.Reserve, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record("Cancel", startAt, force); err != nil {
+		return nil, err
+	}
+	if err := c.checkSession(); err != nil {
+		return nil, err
+	}
+	for i, r := range c.Reservations {
+		if !r.StartAt.Equal(startAt) {
+			continue
+		}
+		if !force && !r.CancelDeadline.IsZero() && c.now().After(r.CancelDeadline) {
+			return nil, fmt.Errorf("%w: the deadline was %s", librarejob.ErrCancelDeadlinePassed, r.CancelDeadline.Format(time.DateTime))
+		}
+		if c.DryRun {
+			r.DryRun = true
+			return &r, nil
+		}
+		c.Reservations = append(c.Reservations[:i], c.Reservations[i+1:]...)
+		return &r, nil
+	}
+	return nil, librarejob.ErrReservationNotFound
+}
+
+func (c *Client) Teardown() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record("Teardown"); err != nil {
+		return err
+	}
+	c.loggedIn = false
+	return nil
+}
@@ -0,0 +1,353 @@
+package librarejobtest
+
+import (
+	"crypto/rand"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+)
+
+// fixtures are the pages written by hand after the rarejob pages, reduced to the elements the client reads with the default selectors.
+// They follow DefaultSelectors rather than the site, so they can't catch the changes of the site which break the selectors.
+//
+//go:embed fixtures/*.html
+var fixtures embed.FS
+
+var fixtureTemplates = template.Must(template.ParseFS(fixtures, "fixtures/*.html"))
+
+const (
+	sessionCookie = "PHPSESSID"
+	// fixtureAtFormat is the format of the slot time in the query of the fixture pages.
+	fixtureAtFormat = "200601021504"
+	// fixtureCancelDeadline is how long before the lesson it can be cancelled for free, the same as the site.
+	fixtureCancelDeadline = 30 * time.Minute
+)
+
+// searchPaths are the paths of the search pages of each reservation type. The reservation pages are under them.
+var searchPaths = map[string]librarejob.ReservationType{
+	"/reservation/":              librarejob.ReservationTypeLesson,
+	"/counseling/reservation/":   librarejob.ReservationTypeCounseling,
+	"/speakingtest/reservation/": librarejob.ReservationTypeSpeakingTest,
+}
+
+// FixtureTutor is a tutor listed by the fixture server.
+type FixtureTutor struct {
+	ID     string
+	Name   string
+	Rating float64
+	// Slots are the available slots formatted in HH:MM on any day. Every half hour is available if nil.
+	Slots []string
+}
+
+// DefaultFixtureTutors are the tutors listed by the fixture server unless specified.
+var DefaultFixtureTutors = []FixtureTutor{
+	{ID: "10001", Name: "Tutor A", Rating: 4.85},
+	{ID: "10002", Name: "Tutor B", Rating: 4.60, Slots: []string{"07:00", "07:30", "21:00", "21:30"}},
+	{ID: "10003", Name: "Tutor C", Rating: 4.92, Slots: []string{"22:00"}},
+}
+
+// Fixture serves the hand-written imitations of the login, my page, account, lesson history, tutor detail, search, reservation and
+// cancellation pages of rarejob, so that the client can run the whole flow with ClientOpts.BaseURL set to its URL
+// without the real site.
+// The reservations are kept in memory, and the times are in librarejob.DefaultLocation as the real site.
+type Fixture struct {
+	email    string
+	password string
+	tutors   []FixtureTutor
+
+	mu           sync.Mutex
+	sessions     map[string]bool
+	reservations []librarejob.Reserve
+}
+
+// NewFixture returns the fixture accepting the credentials and listing the tutors. DefaultFixtureTutors are listed if tutors is nil.
+func NewFixture(email, password string, tutors []FixtureTutor) *Fixture {
+	if tutors == nil {
+		tutors = DefaultFixtureTutors
+	}
+	return &Fixture{email: email, password: password, tutors: tutors, sessions: map[string]bool{}}
+}
+
+// Reservations returns the reservations made on the fixture, earliest first.
+func (f *Fixture) Reservations() []librarejob.Reserve {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]librarejob.Reserve{}, f.reservations...)
+}
+
+func (f *Fixture) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/account/login/" {
+		f.serveLogin(w, r)
+		return
+	}
+	if !f.loggedIn(r) {
+		http.Redirect(w, r, "/account/login/", http.StatusFound)
+		return
+	}
+
+	switch p := r.URL.Path; {
+	case p == "/mypage/":
+		f.serveMyPage(w, r)
+	case p == "/mypage/account/":
+		render(w, "account.html", struct{ Plan, Remaining string }{Plan: "毎日25分プラン", Remaining: "1"})
+	case p == "/mypage/lesson/history/":
+		render(w, "history.html", nil)
+	case strings.HasPrefix(p, "/teacher/detail/"):
+		f.serveTutorDetail(w, r, strings.Trim(strings.TrimPrefix(p, "/teacher/detail/"), "/"))
+	case p == "/reservation/cancel/":
+		f.serveCancel(w, r)
+	case p == "/reservation/cancel/finish/":
+		f.serveCancelFinish(w, r)
+	default:
+		for path, typ := range searchPaths {
+			switch p {
+			case path:
+				f.serveSearch(w, r, path)
+				return
+			case path + "reserve/":
+				f.serveReserve(w, r, path)
+				return
+			case path + "reserve/finish/":
+				f.serveReserveFinish(w, r, path, typ)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	}
+}
+
+func (f *Fixture) loggedIn(r *http.Request) bool {
+	c, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.sessions[c.Value]
+}
+
+func (f *Fixture) serveLogin(w http.ResponseWriter, r *http.Request) {
+	data := struct{ Action, Error string }{Action: "/account/login/"}
+	if r.Method == http.MethodPost {
+		if r.FormValue("RJ_LoginForm[email]") == f.email && r.FormValue("RJ_LoginForm[password]") == f.password {
+			b := make([]byte, 16)
+			rand.Read(b)
+			session := hex.EncodeToString(b)
+			f.mu.Lock()
+			f.sessions[session] = true
+			f.mu.Unlock()
+			http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: session, Path: "/"})
+			http.Redirect(w, r, "/mypage/", http.StatusFound)
+			return
+		}
+		data.Error = "メールアドレスまたはパスワードが違います。"
+	}
+	render(w, "login.html", data)
+}
+
+type fixtureReservation struct {
+	librarejob.Reserve
+	Label     string
+	CancelURL string
+}
+
+// reservationLabels are the labels of the types of the sessions on my page.
+var reservationLabels = map[librarejob.ReservationType]string{
+	librarejob.ReservationTypeLesson:       "レッスン",
+	librarejob.ReservationTypeCounseling:   "カウンセリング",
+	librarejob.ReservationTypeSpeakingTest: "スピーキングテスト",
+}
+
+func (f *Fixture) serveMyPage(w http.ResponseWriter, r *http.Request) {
+	var items []fixtureReservation
+	for _, res := range f.Reservations() {
+		items = append(items, fixtureReservation{Reserve: res, Label: reservationLabels[res.Type], CancelURL: "/reservation/cancel/?at=" + res.StartAt.Format(fixtureAtFormat)})
+	}
+	render(w, "mypage.html", items)
+}
+
+type fixtureSlot struct {
+	Time string
+	URL  string
+}
+
+type fixtureSearchTutor struct {
+	FixtureTutor
+	DetailURL string
+	Slots     []fixtureSlot
+}
+
+func (f *Fixture) serveSearch(w http.ResponseWriter, r *http.Request, path string) {
+	q := r.URL.Query()
+	year, _ := strconv.Atoi(q.Get("year"))
+	month, _ := strconv.Atoi(q.Get("month"))
+	day, _ := strconv.Atoi(q.Get("day"))
+	from, _ := strconv.Atoi(q.Get("lessonTime_from"))
+	to, _ := strconv.Atoi(q.Get("lessonTime_to"))
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, librarejob.DefaultLocation)
+	start := date.Add(time.Duration(from/100)*time.Hour + time.Duration(from%100)*time.Minute)
+	end := date.Add(time.Duration(to/100)*time.Hour + time.Duration(to%100)*time.Minute)
+
+	var tutors []fixtureSearchTutor
+	for _, t := range f.tutors {
+		st := fixtureSearchTutor{FixtureTutor: t, DetailURL: fmt.Sprintf("/teacher/detail/%s/", url.PathEscape(t.ID))}
+		for s := start; !s.After(end); s = s.Add(30 * time.Minute) {
+			if s.Minute()%30 != 0 || !f.available(t, s) {
+				continue
+			}
+			v := url.Values{"tutorId": {t.ID}, "at": {s.Format(fixtureAtFormat)}}
+			st.Slots = append(st.Slots, fixtureSlot{Time: s.Format("15:04"), URL: path + "reserve/?" + v.Encode()})
+		}
+		if len(st.Slots) > 0 {
+			tutors = append(tutors, st)
+		}
+	}
+	render(w, "search.html", struct{ Tutors []fixtureSearchTutor }{tutors})
+}
+
+// serveTutorDetail serves the detail page of the tutor with the schedule of the day in the query, or of today.
+func (f *Fixture) serveTutorDetail(w http.ResponseWriter, r *http.Request, id string) {
+	t, ok := f.tutor(id)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	date := time.Now().In(librarejob.DefaultLocation)
+	q := r.URL.Query()
+	if year, err := strconv.Atoi(q.Get("year")); err == nil {
+		month, _ := strconv.Atoi(q.Get("month"))
+		day, _ := strconv.Atoi(q.Get("day"))
+		date = time.Date(year, time.Month(month), day, 0, 0, 0, 0, librarejob.DefaultLocation)
+	}
+	date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, librarejob.DefaultLocation)
+
+	st := fixtureSearchTutor{FixtureTutor: t}
+	for s := date; s.Before(date.AddDate(0, 0, 1)); s = s.Add(30 * time.Minute) {
+		if !f.available(t, s) {
+			continue
+		}
+		v := url.Values{"tutorId": {t.ID}, "at": {s.Format(fixtureAtFormat)}}
+		st.Slots = append(st.Slots, fixtureSlot{Time: s.Format("15:04"), URL: "/reservation/reserve/?" + v.Encode()})
+	}
+	render(w, "tutor.html", st)
+}
+
+// available reports whether the tutor offers the slot and it's not reserved yet.
+func (f *Fixture) available(t FixtureTutor, s time.Time) bool {
+	if t.Slots != nil {
+		found := false
+		for _, hm := range t.Slots {
+			if hm == s.Format("15:04") {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, r := range f.reservations {
+		if r.Name == t.Name && r.StartAt.Equal(s) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *Fixture) tutor(id string) (FixtureTutor, bool) {
+	for _, t := range f.tutors {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return FixtureTutor{}, false
+}
+
+func (f *Fixture) serveReserve(w http.ResponseWriter, r *http.Request, path string) {
+	t, ok := f.tutor(r.URL.Query().Get("tutorId"))
+	at, err := time.ParseInLocation(fixtureAtFormat, r.URL.Query().Get("at"), librarejob.DefaultLocation)
+	if !ok || err != nil {
+		http.Error(w, "invalid slot", http.StatusBadRequest)
+		return
+	}
+	if !f.available(t, at) {
+		// the site doesn't offer the confirm button once someone else has taken the slot
+		render(w, "finish.html", "この枠は予約できません。")
+		return
+	}
+	render(w, "confirm.html", struct{ Title, Summary, ConfirmURL, LinkText string }{
+		Title:      "予約確認",
+		Summary:    fmt.Sprintf("%s %s", t.Name, at.Format("2006/01/02 15:04")),
+		ConfirmURL: path + "reserve/finish/?" + r.URL.RawQuery,
+		LinkText:   librarejob.DefaultSelectors().ReservationConfirmLinkText,
+	})
+}
+
+func (f *Fixture) serveReserveFinish(w http.ResponseWriter, r *http.Request, path string, typ librarejob.ReservationType) {
+	t, ok := f.tutor(r.URL.Query().Get("tutorId"))
+	at, err := time.ParseInLocation(fixtureAtFormat, r.URL.Query().Get("at"), librarejob.DefaultLocation)
+	if !ok || err != nil {
+		http.Error(w, "invalid slot", http.StatusBadRequest)
+		return
+	}
+	if !f.available(t, at) {
+		// back to the search page as the site does when someone else has taken the slot
+		http.Redirect(w, r, path, http.StatusFound)
+		return
+	}
+	f.mu.Lock()
+	f.reservations = append(f.reservations, librarejob.Reserve{
+		Type:           typ,
+		Name:           t.Name,
+		StartAt:        at,
+		EndAt:          at.Add(durations[typ]),
+		CancelDeadline: at.Add(-fixtureCancelDeadline),
+	})
+	sort.Slice(f.reservations, func(i, j int) bool { return f.reservations[i].StartAt.Before(f.reservations[j].StartAt) })
+	f.mu.Unlock()
+	render(w, "finish.html", "予約が完了しました。")
+}
+
+func (f *Fixture) serveCancel(w http.ResponseWriter, r *http.Request) {
+	render(w, "confirm.html", struct{ Title, Summary, ConfirmURL, LinkText string }{
+		Title:      "キャンセル確認",
+		Summary:    strings.TrimSpace(r.URL.Query().Get("at")),
+		ConfirmURL: "/reservation/cancel/finish/?" + r.URL.RawQuery,
+		LinkText:   librarejob.DefaultSelectors().CancelConfirmLinkText,
+	})
+}
+
+func (f *Fixture) serveCancelFinish(w http.ResponseWriter, r *http.Request) {
+	at, err := time.ParseInLocation(fixtureAtFormat, r.URL.Query().Get("at"), librarejob.DefaultLocation)
+	if err != nil {
+		http.Error(w, "invalid reservation", http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	for i, res := range f.reservations {
+		if res.StartAt.Equal(at) {
+			f.reservations = append(f.reservations[:i], f.reservations[i+1:]...)
+			break
+		}
+	}
+	f.mu.Unlock()
+	render(w, "finish.html", "キャンセルが完了しました。")
+}
+
+func render(w http.ResponseWriter, name string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := fixtureTemplates.ExecuteTemplate(w, name, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,265 @@
+package librarejobtest_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+	"github.com/musaprg/rarejobctl/librarejob/librarejobtest"
+)
+
+const (
+	fixtureEmail    = "fixture@example.com"
+	fixturePassword = "fixture"
+
+	// testSeleniumHostEnv is the host of the selenium server to run the whole flow of the client against the fixture,
+	// e.g. localhost for selenium/standalone-firefox listening on 4444.
+	testSeleniumHostEnv = "RAREJOB_TEST_SELENIUM_HOST"
+	// testFixtureHostEnv is the host by which the browser accesses the fixture server started by the test,
+	// e.g. host.docker.internal for the browser in a container. localhost is used if empty.
+	testFixtureHostEnv = "RAREJOB_TEST_FIXTURE_HOST"
+)
+
+// tomorrow returns the time of tomorrow in the timezone of the site.
+func tomorrow(hour, minute int) time.Time {
+	d := time.Now().In(librarejob.DefaultLocation).AddDate(0, 0, 1)
+	return time.Date(d.Year(), d.Month(), d.Day(), hour, minute, 0, 0, librarejob.DefaultLocation)
+}
+
+// get fetches the path of the fixture and returns the path redirected to and the body.
+func get(t *testing.T, c *http.Client, base, path string) (string, string) {
+	t.Helper()
+	return do(t, c, func() (*http.Response, error) { return c.Get(base + path) })
+}
+
+func do(t *testing.T, c *http.Client, f func() (*http.Response, error)) (string, string) {
+	t.Helper()
+	res, err := f()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("%s: status %d: %s", res.Request.URL, res.StatusCode, b)
+	}
+	return res.Request.URL.Path, string(b)
+}
+
+func TestFixtureHTTP(t *testing.T) {
+	f := librarejobtest.NewFixture(fixtureEmail, fixturePassword, nil)
+	s := httptest.NewServer(f)
+	defer s.Close()
+	jar, _ := cookiejar.New(nil)
+	c := &http.Client{Jar: jar}
+	at := tomorrow(21, 0)
+	slot := url.Values{"tutorId": {"10001"}, "at": {at.Format("200601021504")}}.Encode()
+
+	if path, _ := get(t, c, s.URL, "/mypage/"); path != "/account/login/" {
+		t.Errorf("my page before login redirected to %s, want the login page", path)
+	}
+
+	login := func(password string) (string, string) {
+		return do(t, c, func() (*http.Response, error) {
+			return c.PostForm(s.URL+"/account/login/", url.Values{"RJ_LoginForm[email]": {fixtureEmail}, "RJ_LoginForm[password]": {password}})
+		})
+	}
+	if path, body := login("wrong"); path != "/account/login/" || !strings.Contains(body, "p-login__error") {
+		t.Errorf("login with wrong password went to %s, want the error on the login page", path)
+	}
+	if path, _ := login(fixturePassword); path != "/mypage/" {
+		t.Fatalf("login went to %s, want my page", path)
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		contains    []string
+		notContains []string
+	}{
+		{
+			"search",
+			"/reservation/?" + url.Values{
+				"year": {at.Format("2006")}, "month": {at.Format("1")}, "day": {at.Format("2")},
+				"lessonTime_from": {"2100"}, "lessonTime_to": {"2130"},
+			}.Encode(),
+			[]string{"Tutor A", "Tutor B", "21:00", "21:30"},
+			[]string{"Tutor C"},
+		},
+		{"account", "/mypage/account/", []string{"毎日25分プラン"}, nil},
+		{"tutor detail", "/teacher/detail/10003/", []string{"Tutor C", "22:00"}, []string{"21:00"}},
+		{"reservation", "/reservation/reserve/?" + slot, []string{"Tutor A", librarejob.DefaultSelectors().ReservationConfirmLinkText}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, body := get(t, c, s.URL, tt.path)
+			for _, s := range tt.contains {
+				if !strings.Contains(body, s) {
+					t.Errorf("%s doesn't contain %q", tt.path, s)
+				}
+			}
+			for _, s := range tt.notContains {
+				if strings.Contains(body, s) {
+					t.Errorf("%s contains %q", tt.path, s)
+				}
+			}
+		})
+	}
+
+	get(t, c, s.URL, "/reservation/reserve/finish/?"+slot)
+	reservations := f.Reservations()
+	if len(reservations) != 1 || reservations[0].Name != "Tutor A" || !reservations[0].StartAt.Equal(at) ||
+		reservations[0].EndAt.Sub(at) != 25*time.Minute {
+		t.Fatalf("Reservations() = %+v, want Tutor A at %s", reservations, at)
+	}
+	if _, body := get(t, c, s.URL, "/mypage/"); !strings.Contains(body, "Tutor A") {
+		t.Errorf("my page doesn't list the reservation")
+	}
+	if _, body := get(t, c, s.URL, "/reservation/reserve/?"+slot); strings.Contains(body, librarejob.DefaultSelectors().ReservationConfirmLinkText) {
+		t.Errorf("the reserved slot is offered again")
+	}
+
+	get(t, c, s.URL, "/reservation/cancel/finish/?at="+at.Format("200601021504"))
+	if reservations := f.Reservations(); len(reservations) != 0 {
+		t.Errorf("Reservations() after cancel = %+v, want none", reservations)
+	}
+}
+
+// TestFixtureFlow runs the client against the fixture in the browser of the selenium server of testSeleniumHostEnv,
+// as the CLI does: checking the selectors, reserving in dry-run mode, reserving and cancelling.
+func TestFixtureFlow(t *testing.T) {
+	seleniumHost := os.Getenv(testSeleniumHostEnv)
+	if seleniumHost == "" {
+		t.Skipf("%s is not set", testSeleniumHostEnv)
+	}
+	fixtureHost := os.Getenv(testFixtureHostEnv)
+	if fixtureHost == "" {
+		fixtureHost = "localhost"
+	}
+
+	f := librarejobtest.NewFixture(fixtureEmail, fixturePassword, nil)
+	// listen on every interface for the browser in a container
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := httptest.NewUnstartedServer(f)
+	s.Listener = l
+	s.Start()
+	defer s.Close()
+	baseURL := "http://" + net.JoinHostPort(fixtureHost, strconv.Itoa(l.Addr().(*net.TCPAddr).Port))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	newClient := func(t *testing.T, dryRun bool) librarejob.Client {
+		t.Helper()
+		c, err := librarejob.NewClient(librarejob.ClientOpts{
+			SeleniumHost: seleniumHost,
+			BaseURL:      baseURL,
+			DryRun:       dryRun,
+		})
+		if err != nil {
+			t.Fatalf("NewClient() = %v", err)
+		}
+		t.Cleanup(func() { c.Teardown() })
+		if err := c.Login(ctx, fixtureEmail, fixturePassword); err != nil {
+			t.Fatalf("Login() = %v", err)
+		}
+		return c
+	}
+	from := tomorrow(21, 0)
+	req := librarejob.ReserveRequest{From: from, Margin: time.Hour}
+
+	t.Run("check selectors", func(t *testing.T) {
+		c := newClient(t, false)
+		checks, err := c.CheckSelectors(ctx, fixtureEmail, fixturePassword, from, time.Hour)
+		if err != nil {
+			t.Fatalf("CheckSelectors() = %v", err)
+		}
+		for _, ch := range checks {
+			if !ch.OK && !ch.Skipped {
+				t.Errorf("selector %s of %s is broken: %s", ch.Name, ch.Page, ch.Error)
+			}
+		}
+	})
+
+	t.Run("reserve in dry-run mode", func(t *testing.T) {
+		c := newClient(t, true)
+		r, err := c.Reserve(ctx, req)
+		if err != nil {
+			t.Fatalf("Reserve() = %v", err)
+		}
+		if !r.DryRun || r.Name != "Tutor A" {
+			t.Errorf("Reserve() = %+v, want dry-run reservation of Tutor A", r)
+		}
+		if reservations := f.Reservations(); len(reservations) != 0 {
+			t.Errorf("Reservations() = %+v, want none", reservations)
+		}
+	})
+
+	c := newClient(t, false)
+	t.Run("reserve", func(t *testing.T) {
+		r, err := c.Reserve(ctx, req)
+		if err != nil {
+			t.Fatalf("Reserve() = %v", err)
+		}
+		if r.DryRun || r.Name != "Tutor A" || !r.StartAt.Equal(from) {
+			t.Errorf("Reserve() = %+v, want reservation of Tutor A at %s", r, from)
+		}
+		reservations, err := c.ListReservations(ctx)
+		if err != nil {
+			t.Fatalf("ListReservations() = %v", err)
+		}
+		if len(reservations) != 1 || reservations[0].Name != "Tutor A" || reservations[0].Type != librarejob.ReservationTypeLesson {
+			t.Errorf("ListReservations() = %+v, want the lesson of Tutor A", reservations)
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		r, err := c.Cancel(ctx, from, false)
+		if err != nil {
+			t.Fatalf("Cancel() = %v", err)
+		}
+		if r.Name != "Tutor A" {
+			t.Errorf("Cancel() = %+v, want the reservation of Tutor A", r)
+		}
+		if reservations := f.Reservations(); len(reservations) != 0 {
+			t.Errorf("Reservations() = %+v, want none", reservations)
+		}
+	})
+
+	t.Run("resume session", func(t *testing.T) {
+		cookies, err := c.ExportSession(ctx)
+		if err != nil {
+			t.Fatalf("ExportSession() = %v", err)
+		}
+		other, err := librarejob.NewClient(librarejob.ClientOpts{SeleniumHost: seleniumHost, BaseURL: baseURL})
+		if err != nil {
+			t.Fatalf("NewClient() = %v", err)
+		}
+		t.Cleanup(func() { other.Teardown() })
+		if err := other.ResumeSession(ctx, nil); !errors.Is(err, librarejob.ErrSessionExpired) {
+			t.Errorf("ResumeSession() without cookies = %v, want ErrSessionExpired", err)
+		}
+		if err := other.ResumeSession(ctx, cookies); err != nil {
+			t.Fatalf("ResumeSession() = %v", err)
+		}
+		if _, err := other.ListReservations(ctx); err != nil {
+			t.Errorf("ListReservations() after resuming = %v", err)
+		}
+	})
+}
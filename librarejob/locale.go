@@ -0,0 +1,98 @@
+package librarejob
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Locale is the language of the RareJob UI, which is chosen in the account settings.
+// The CSS selectors are the same for both languages, but the link texts and the status texts differ.
+type Locale string
+
+const (
+	LocaleJapanese Locale = "ja"
+	LocaleEnglish  Locale = "en"
+)
+
+// ParseLocale returns the locale of the name. The empty name means to detect the language of the site.
+func ParseLocale(name string) (Locale, error) {
+	switch l := Locale(name); l {
+	case "", LocaleJapanese, LocaleEnglish:
+		return l, nil
+	default:
+		return "", fmt.Errorf("%w: invalid locale: %s", ErrInvalidOptions, name)
+	}
+}
+
+//go:embed selectors_en.json
+var englishTexts []byte
+
+// DefaultSelectorsFor returns the selectors shipped with the binary for the UI in the locale.
+func DefaultSelectorsFor(l Locale) Selectors {
+	s := DefaultSelectors()
+	if l == LocaleEnglish {
+		if err := json.Unmarshal(englishTexts, &s); err != nil {
+			panic(fmt.Sprintf("invalid embedded selectors: %v", err))
+		}
+	}
+	return s
+}
+
+// texts returns the pointers to the texts which differ between the locales.
+func (s *Selectors) texts() []*string {
+	return []*string{
+		&s.ReservationConfirmLinkText,
+		&s.CancelConfirmLinkText,
+		&s.LessonHistoryCompletedText,
+		&s.LoginLockedText,
+		&s.ReservationCounselingText,
+		&s.ReservationSpeakingTestText,
+	}
+}
+
+// localize replaces the texts of the default Japanese UI with the ones of l. The texts overridden by the user are kept.
+func (s Selectors) localize(l Locale) Selectors {
+	ja, to := DefaultSelectorsFor(LocaleJapanese), DefaultSelectorsFor(l)
+	jaTexts, toTexts := ja.texts(), to.texts()
+	for i, p := range s.texts() {
+		if *p == *jaTexts[i] {
+			*p = *toTexts[i]
+		}
+	}
+	return s
+}
+
+// detectLocale returns the language of the current page from the lang attribute of the html element.
+func (c *client) detectLocale(ctx context.Context) (Locale, error) {
+	out, err := retry(ctx, c.l, c.retry, "execute_script", func() (interface{}, error) {
+		return c.wd.ExecuteScript("return document.documentElement.lang;", nil)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get the language of the page: %w", err)
+	}
+	lang, _ := out.(string)
+	if strings.HasPrefix(strings.ToLower(lang), "en") {
+		return LocaleEnglish, nil
+	}
+	return LocaleJapanese, nil
+}
+
+// applyDetectedLocale localizes the selectors for the language of the current page. It does nothing if
+// the locale is given in the options, since the selectors are localized for it on creating the client.
+func (c *client) applyDetectedLocale(ctx context.Context) {
+	if c.locale != "" {
+		return
+	}
+	l, err := c.detectLocale(ctx)
+	if err != nil {
+		c.l.Warn("failed to detect the language of the site, assuming Japanese", zap.Error(err))
+		return
+	}
+	c.l.Debug("detected the language of the site", zap.String("locale", string(l)))
+	c.sel = c.sel.localize(l)
+}
@@ -0,0 +1,76 @@
+package librarejob
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tebeka/selenium"
+)
+
+// MaintenanceError is returned when the site shows the maintenance page. It matches ErrSiteMaintenance with errors.Is.
+type MaintenanceError struct {
+	// Message is the text of the maintenance page.
+	Message string
+	// Until is the end of the maintenance told by the page, or zero if the page doesn't tell it.
+	Until time.Time
+}
+
+func (e *MaintenanceError) Error() string {
+	msg := ErrSiteMaintenance.Error()
+	if !e.Until.IsZero() {
+		msg += " until " + e.Until.Format(time.DateTime)
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+func (e *MaintenanceError) Is(target error) bool {
+	return target == ErrSiteMaintenance
+}
+
+// maintenanceTimeRe matches the times in the maintenance message, e.g. "2022年12月27日(火) 2:00～6:00" or "12/27 2:00-6:00",
+// with the optional year and date.
+var maintenanceTimeRe = regexp.MustCompile(`(?:(?:(\d{4})[年/-])?(\d{1,2})[月/-](\d{1,2})日?\D{0,6}?)?(\d{1,2})[:：](\d{2})`)
+
+// maintenance returns *MaintenanceError if the current page is the maintenance page, otherwise nil.
+func (c *client) maintenance() error {
+	if c.sel.Maintenance == "" {
+		return nil
+	}
+	elms, _ := c.wd.FindElements(selenium.ByCSSSelector, c.sel.Maintenance)
+	if len(elms) == 0 {
+		return nil
+	}
+	text, _ := elms[0].Text()
+	text = strings.TrimSpace(text)
+	return &MaintenanceError{Message: text, Until: parseMaintenanceEnd(text, time.Now().In(c.loc))}
+}
+
+// parseMaintenanceEnd returns the last time in the maintenance message as the end of the maintenance, or zero if none.
+// The date defaults to the one of the previous time or today, and the end before the start is regarded as the next day.
+func parseMaintenanceEnd(text string, now time.Time) time.Time {
+	y, m, d := now.Date()
+	var end time.Time
+	for _, sm := range maintenanceTimeRe.FindAllStringSubmatch(text, -1) {
+		if sm[1] != "" {
+			y, _ = strconv.Atoi(sm[1])
+		}
+		if sm[2] != "" {
+			mm, _ := strconv.Atoi(sm[2])
+			m = time.Month(mm)
+			d, _ = strconv.Atoi(sm[3])
+		}
+		hour, _ := strconv.Atoi(sm[4])
+		minute, _ := strconv.Atoi(sm[5])
+		t := time.Date(y, m, d, hour, minute, 0, 0, now.Location())
+		if sm[2] == "" && !end.IsZero() && t.Before(end) {
+			t = t.AddDate(0, 0, 1)
+		}
+		end = t
+	}
+	return end
+}
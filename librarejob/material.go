@@ -0,0 +1,115 @@
+package librarejob
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tebeka/selenium"
+	"go.uber.org/zap"
+)
+
+// Material is the material assigned to a lesson, e.g. the article of Daily News Article.
+type Material struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+	// StartAt is the start time of the lesson the material is assigned to.
+	StartAt time.Time       `json:"start_at"`
+	Blocks  []MaterialBlock `json:"blocks"`
+}
+
+// MaterialBlockKind is the kind of a block of the material.
+type MaterialBlockKind string
+
+const (
+	MaterialHeading   MaterialBlockKind = "heading"
+	MaterialParagraph MaterialBlockKind = "paragraph"
+	MaterialListItem  MaterialBlockKind = "list_item"
+)
+
+// MaterialBlock is a heading, a paragraph or a list item of the material.
+type MaterialBlock struct {
+	Kind MaterialBlockKind `json:"kind"`
+	// Level is the level of the heading, e.g. 2 for h2. It is 0 for the other kinds.
+	Level int    `json:"level,omitempty"`
+	Text  string `json:"text"`
+}
+
+func (c *client) LessonMaterial(ctx context.Context, startAt time.Time) (_ *Material, err error) {
+	unlock, err := c.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	defer c.l.Sync()
+	defer c.flushConsoleLogs()
+	defer func() { err = c.captureFailure("lesson_material", err) }()
+
+	reservations, err := c.listReservations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var r *Reserve
+	for i := range reservations {
+		if startAt.IsZero() || reservations[i].StartAt.Equal(startAt) {
+			r = &reservations[i]
+			break
+		}
+	}
+	if r == nil {
+		return nil, ErrReservationNotFound
+	}
+	if r.MaterialURL == "" {
+		return nil, fmt.Errorf("%w: no material is assigned to the lesson at %s", ErrMaterialNotFound, r.StartAt.Format(time.DateTime))
+	}
+
+	c.l.Debug("loading lesson material", zap.String("url", r.MaterialURL))
+	if err := c.get(ctx, r.MaterialURL); err != nil {
+		return nil, fmt.Errorf("failed to get lesson material: %w", err)
+	}
+	if c.isAt(rarejobLoginURL) {
+		return nil, ErrSessionExpired
+	}
+	if err := c.waitUntilElementLoaded(ctx, c.waits.Default, selenium.ByCSSSelector, c.sel.MaterialTitle); err != nil {
+		return nil, fmt.Errorf("failed to load lesson material: %w", err)
+	}
+
+	m := &Material{URL: r.MaterialURL, StartAt: r.StartAt}
+	title, err := c.elementText(ctx, selenium.ByCSSSelector, c.sel.MaterialTitle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get title of material: %w", err)
+	}
+	m.Title = strings.TrimSpace(title)
+
+	elms, err := c.findElements(ctx, selenium.ByCSSSelector, c.sel.MaterialBlock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get material: %w", err)
+	}
+	for _, elm := range elms {
+		text, err := elm.Text()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get text of material: %w", err)
+		}
+		if text = strings.TrimSpace(text); text == "" {
+			continue
+		}
+		tag, _ := elm.TagName()
+		m.Blocks = append(m.Blocks, materialBlock(strings.ToLower(tag), text))
+	}
+
+	c.l.Info("fetched lesson material", zap.String("title", m.Title), zap.Int("blocks", len(m.Blocks)))
+	return m, nil
+}
+
+// materialBlock returns the block of the element with the tag name.
+func materialBlock(tag, text string) MaterialBlock {
+	switch {
+	case tag == "li":
+		return MaterialBlock{Kind: MaterialListItem, Text: text}
+	case len(tag) == 2 && tag[0] == 'h' && tag[1] >= '1' && tag[1] <= '6':
+		return MaterialBlock{Kind: MaterialHeading, Level: int(tag[1] - '0'), Text: text}
+	default:
+		return MaterialBlock{Kind: MaterialParagraph, Text: text}
+	}
+}
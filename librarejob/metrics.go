@@ -0,0 +1,84 @@
+package librarejob
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "rarejob"
+
+var (
+	loginAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "login_attempts_total",
+		Help:      "Number of login attempts by the result, which is success or the error code.",
+	}, []string{"result"})
+	reservations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "reservations_total",
+		Help:      "Number of reservation attempts by the reservation type and the result, which is success, dry_run, already_exists or the error code.",
+	}, []string{"type", "result"})
+	searchDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "search_duration_seconds",
+		Help:      "Time taken to search the available tutors by the reservation type.",
+		Buckets:   []float64{1, 2, 5, 10, 20, 30, 60, 120},
+	}, []string{"type"})
+	tutorsFound = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "tutors_found",
+		Help:      "Number of the tutors found by a successful search by the reservation type.",
+		Buckets:   []float64{0, 1, 2, 5, 10, 20, 50},
+	}, []string{"type"})
+)
+
+// RegisterMetrics registers the metrics of the clients to r. The metrics are collected whether registered or not.
+func RegisterMetrics(r prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{loginAttempts, reservations, searchDuration, tutorsFound} {
+		if err := r.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resultLabel is success for nil, or the error code of err.
+func resultLabel(err error) string {
+	if err == nil {
+		return "success"
+	}
+	return ErrorCode(err)
+}
+
+func observeLogin(err error) {
+	loginAttempts.WithLabelValues(resultLabel(err)).Inc()
+}
+
+// reserveOutcome is already_exists or dry_run if r is so, otherwise the result label of err.
+func reserveOutcome(r *Reserve, err error) string {
+	switch {
+	case err != nil:
+	case r.AlreadyExists:
+		return "already_exists"
+	case r.DryRun:
+		return "dry_run"
+	}
+	return resultLabel(err)
+}
+
+func observeReservation(typ ReservationType, r *Reserve, err error) {
+	if typ == "" {
+		typ = ReservationTypeLesson
+	}
+	reservations.WithLabelValues(string(typ), reserveOutcome(r, err)).Inc()
+}
+
+func observeSearch(typ ReservationType, start time.Time, tutors Tutors, err error) {
+	searchDuration.WithLabelValues(string(typ)).Observe(time.Since(start).Seconds())
+	// no tutors available is a successful search finding no one
+	if err == nil || errors.Is(err, ErrNoTutorsAvailable) {
+		tutorsFound.WithLabelValues(string(typ)).Observe(float64(len(tutors)))
+	}
+}
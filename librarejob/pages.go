@@ -0,0 +1,372 @@
+package librarejob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tebeka/selenium"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+// The pages below wrap the selectors and the actions of each page of RareJob, so that a change of the UI
+// only touches the page which has changed. The client composes them to implement the flows.
+
+// loginPage is the login form.
+type loginPage struct {
+	c *client
+}
+
+// open opens the login page and waits for the inputs to be shown.
+func (p loginPage) open(ctx context.Context) error {
+	c := p.c
+	c.l.Debug("loading login page", zap.String("url", c.getCurrentURL()))
+
+	// TODO(musaprg): Cache SESSIONID and reuse
+	if err := c.get(ctx, rarejobLoginURL); err != nil {
+		return fmt.Errorf("failed to access rarejob login page: %w", err)
+	}
+
+	_ = c.waitUntilElementLoaded(ctx, c.waits.Login, selenium.ByCSSSelector, c.sel.LoginEmail)
+	_ = c.waitUntilElementLoaded(ctx, c.waits.Login, selenium.ByCSSSelector, c.sel.LoginPassword)
+	c.saveCurrentScreenshot(rarejobctlTempDir, "login_page.png")
+	c.l.Debug("login page has been loaded", zap.String("url", c.getCurrentURL()))
+	return nil
+}
+
+// login fills in the form and submits it, and waits until redirected to my page.
+func (p loginPage) login(ctx context.Context, email, password string) error {
+	c := p.c
+	if emailInput, err := c.findElement(ctx, selenium.ByCSSSelector, c.sel.LoginEmail); err != nil {
+		return fmt.Errorf("failed to find the email input box: %w", err)
+	} else {
+		c.l.Debug("typing email", zap.String("url", c.getCurrentURL()))
+		err := emailInput.SendKeys(email)
+		if err != nil {
+			return fmt.Errorf("failed to type email: %w", err)
+		}
+	}
+
+	if passwordInput, err := c.findElement(ctx, selenium.ByCSSSelector, c.sel.LoginPassword); err != nil {
+		return fmt.Errorf("failed to find the password input box: %w", err)
+	} else {
+		c.l.Debug("typing password", zap.String("url", c.getCurrentURL()))
+		err := passwordInput.SendKeys(password)
+		if err != nil {
+			return fmt.Errorf("failed to type password: %w", err)
+		}
+	}
+
+	c.l.Debug("click submit button", zap.String("url", c.getCurrentURL()))
+	if err := c.clickElement(ctx, selenium.ByCSSSelector, c.sel.LoginSubmit); err != nil {
+		return fmt.Errorf("failed to submit login form: %w", err)
+	}
+
+	err := p.waitLoggedIn(ctx)
+	if errors.Is(err, ErrHumanVerificationRequired) && c.humanVerification != nil {
+		c.l.Info("waiting for the human verification to be completed", zap.String("url", c.getCurrentURL()))
+		if err := c.humanVerification(ctx, p.saveVerificationScreenshot()); err != nil {
+			return fmt.Errorf("%w: %w", ErrHumanVerificationRequired, err)
+		}
+		err = p.waitLoggedIn(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	c.l.Debug("login completed", zap.String("url", c.getCurrentURL()))
+	c.saveCurrentScreenshot(rarejobctlTempDir, "login_completed.png")
+	return nil
+}
+
+// waitLoggedIn waits until redirected to my page. It returns ErrHumanVerificationRequired as soon as
+// a CAPTCHA or an additional verification is shown, which never completes by waiting.
+func (p loginPage) waitLoggedIn(ctx context.Context) error {
+	c := p.c
+	if err := waitUntil(ctx, c.waits.Login, func() (bool, error) {
+		currentURL := c.getCurrentURL()
+		c.l.Debug("checking if the login has been completed", zap.String("url", currentURL))
+
+		if strings.HasPrefix(currentURL, c.url(rarejobMyPageURL)) {
+			return true, nil
+		}
+		if c.sel.HumanVerification != "" {
+			if elms, _ := c.wd.FindElements(selenium.ByCSSSelector, c.sel.HumanVerification); len(elms) > 0 {
+				return false, ErrHumanVerificationRequired
+			}
+		}
+		if err := p.failure(); err != nil {
+			return false, err
+		}
+
+		return false, nil
+	}); err != nil {
+		if errors.Is(err, ErrHumanVerificationRequired) || errors.Is(err, ErrLoginFailed) || errors.Is(err, ErrSiteMaintenance) {
+			return err
+		}
+		return fmt.Errorf("%w: %w", ErrLoginFailed, err)
+	}
+	return nil
+}
+
+// failure returns the reason of the login failure if the page tells it, or nil while the login is in progress.
+func (p loginPage) failure() error {
+	c := p.c
+	if err := c.maintenance(); err != nil {
+		return err
+	}
+	if c.sel.LoginError == "" {
+		return nil
+	}
+	elms, _ := c.wd.FindElements(selenium.ByCSSSelector, c.sel.LoginError)
+	if len(elms) == 0 {
+		return nil
+	}
+	text, _ := elms[0].Text()
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	c.l.Debug("login was rejected", zap.String("message", text))
+	if c.sel.LoginLockedText != "" && strings.Contains(text, c.sel.LoginLockedText) {
+		return fmt.Errorf("%w: %s", ErrAccountLocked, text)
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidCredentials, text)
+}
+
+// saveVerificationScreenshot saves the screenshot of the verification page to show the user, and returns its path.
+// It's saved into the artifacts directory if set, or a new temporary directory, readable only by the user since it shows
+// the account. It returns "" on failure, since the user can see the browser anyway in headful mode.
+func (p loginPage) saveVerificationScreenshot() string {
+	c := p.c
+	ss, err := c.wd.Screenshot()
+	if err != nil {
+		c.l.Warn("failed to take screenshot of the verification page", zap.Error(err))
+		return ""
+	}
+	var dir string
+	if c.artifactsDir != "" {
+		dir = c.artifactsDir
+		err = os.MkdirAll(dir, 0700)
+	} else {
+		// not a fixed path, which another user could have replaced with a symlink in the shared temporary directory
+		dir, err = os.MkdirTemp("", "rarejobctl-")
+	}
+	if err != nil {
+		c.l.Warn("failed to create directory for the screenshot", zap.String("dir", dir), zap.Error(err))
+		return ""
+	}
+	path := filepath.Join(dir, fmt.Sprintf("human_verification_%s.png", time.Now().Format("20060102T150405.000")))
+	if err := os.WriteFile(path, ss, 0600); err != nil {
+		c.l.Warn("failed to write screenshot of the verification page", zap.String("path", path), zap.Error(err))
+		return ""
+	}
+	return path
+}
+
+// tutorSearchPage is the search result of the tutors (or counselors/examiners for the other reservation types)
+// available between from and by.
+type tutorSearchPage struct {
+	c        *client
+	typ      ReservationType
+	from, by time.Time
+}
+
+// open opens the search result and waits for either the tutor list or the no-result message to be shown.
+func (p tutorSearchPage) open(ctx context.Context) error {
+	c := p.c
+	queryURL, err := generateSearchQuery(p.typ, p.from, p.by)
+	if err != nil {
+		return fmt.Errorf("failed to generate search query: %w", err)
+	}
+	if err := c.get(ctx, queryURL); err != nil {
+		return fmt.Errorf("failed to get availabe tutor list: %w", err)
+	}
+	if c.isAt(rarejobLoginURL) {
+		return ErrSessionExpired
+	}
+
+	if err := c.waitUntilElementLoaded(ctx, c.waits.Search, selenium.ByCSSSelector, c.sel.searchResult()); err != nil {
+		return fmt.Errorf("failed to load tutor search result: %w", err)
+	}
+	c.saveCurrentScreenshot(rarejobctlTempDir, "tutor_list.png")
+	return nil
+}
+
+// tutors reads the tutors in the search result in the order shown. It returns NoTutorsAvailableError
+// if the page says no tutor is found.
+func (p tutorSearchPage) tutors(ctx context.Context) (Tutors, error) {
+	c := p.c
+	tutorList, err := c.findElements(ctx, selenium.ByCSSSelector, c.sel.TutorList)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tutor info: %w", err)
+	}
+	if len(tutorList) == 0 {
+		if noResult, _ := c.wd.FindElements(selenium.ByCSSSelector, c.sel.TutorNoResult); len(noResult) == 0 {
+			return nil, fmt.Errorf("neither tutors nor no-result message found in the search result")
+		}
+		return nil, &NoTutorsAvailableError{From: p.from, To: p.by}
+	}
+
+	tutors, err := p.extractTutors(ctx, len(tutorList))
+	if err != nil {
+		// fall back to look up the elements one by one, which is slow but doesn't depend on javascript
+		c.l.Warn("failed to extract tutors with script, falling back to scraping", zap.Error(err))
+		if tutors, err = p.scrapeTutors(ctx, len(tutorList)); err != nil {
+			return nil, err
+		}
+	}
+	return tutors, nil
+}
+
+// scrapeTutors reads n tutors in the search result element by element.
+func (p tutorSearchPage) scrapeTutors(ctx context.Context, n int) (Tutors, error) {
+	// each tutor is scraped into its own index so the order is the same as the search result
+	tutors := make(Tutors, n)
+	errs := make([]error, n)
+	// the progress is sent one by one in the order the tutors are scraped
+	var mu sync.Mutex
+	var g errgroup.Group
+	g.SetLimit(tutorScrapeConcurrency)
+	for i := 0; i < n; i++ {
+		i := i
+		g.Go(func() error {
+			ctx, span := startSpan(ctx, "scrape_tutor", attribute.Int("number", i+1))
+			tutors[i], errs[i] = p.scrapeTutor(ctx, i+1)
+			endSpan(span, errs[i])
+			if errs[i] == nil {
+				mu.Lock()
+				sendProgress(ctx, ProgressEvent{Type: ProgressTutorFound, Tutor: &tutors[i]})
+				mu.Unlock()
+			}
+			return nil
+		})
+	}
+	g.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return tutors, nil
+}
+
+// scrapeTutor reads the tutor at the 1-origin index tnum in the search result.
+func (p tutorSearchPage) scrapeTutor(ctx context.Context, tnum int) (Tutor, error) {
+	c, from := p.c, p.from
+	c.l.Debug("getting tutor info", zap.Int("number", tnum))
+	name, _ := c.elementText(ctx, selenium.ByCSSSelector, fmt.Sprintf(c.sel.TutorName, tnum))
+	var rating float64
+	if ratingText, err := c.elementText(ctx, selenium.ByCSSSelector, fmt.Sprintf(c.sel.TutorRating, tnum)); err == nil {
+		rating, _ = strconv.ParseFloat(strings.TrimSpace(ratingText), 64)
+	}
+	slotElms, err := c.findElements(ctx, selenium.ByCSSSelector, fmt.Sprintf(c.sel.TutorTimeSlot, tnum))
+	if err != nil {
+		return Tutor{}, fmt.Errorf("failed to get time slots for tutor #%d: %w", tnum, err)
+	}
+	slots := make([]Slot, len(slotElms))
+	for snum := 1; snum <= len(slotElms); snum++ {
+		// if err, leave the slot unparsed to preserve index
+		slots[snum-1] = Slot{Status: SlotUnparsed}
+		slotText, err := c.elementText(ctx, selenium.ByCSSSelector, fmt.Sprintf(c.sel.TutorTimeSlotButton, tnum, snum))
+		if err != nil {
+			continue
+		}
+		slots[snum-1].Text = slotText
+		h, m, err := parseTime(slotText)
+		if err != nil {
+			continue
+		}
+		slots[snum-1] = AvailableSlot(time.Date(from.Year(), from.Month(), from.Day(), h, m, 0, 0, from.Location()))
+	}
+	var id string
+	if link, err := c.wd.FindElement(selenium.ByCSSSelector, fmt.Sprintf(c.sel.TutorLink, tnum)); err == nil {
+		if href, err := link.GetAttribute("href"); err == nil {
+			id = idFromURL(href)
+		}
+	}
+	return Tutor{
+		ID:             id,
+		Name:           name,
+		Rating:         rating,
+		AvailableSlots: slots,
+	}, nil
+}
+
+// openSlot clicks the si-th slot of the ti-th tutor (0-origin), which opens the confirmation page.
+func (p tutorSearchPage) openSlot(ctx context.Context, ti, si int) error {
+	c := p.c
+	timeSlotButtonSelector := fmt.Sprintf(c.sel.TutorTimeSlotButton, ti+1, si+1)
+	c.waitUntilElementLoaded(ctx, c.waits.Reservation, selenium.ByCSSSelector, timeSlotButtonSelector)
+	c.saveCurrentScreenshot(rarejobctlTempDir, "tutor_reservation.png")
+	{
+		text, err := c.elementText(ctx, selenium.ByCSSSelector, timeSlotButtonSelector)
+		if err != nil {
+			return fmt.Errorf("failed to find time slot button: %w", err)
+		}
+		c.l.Debug("found time slot button", zap.String("button_text", text))
+	}
+	if err := c.clickElement(ctx, selenium.ByCSSSelector, timeSlotButtonSelector); err != nil {
+		return fmt.Errorf("failed to click time slot button: %w", err)
+	}
+	return nil
+}
+
+// confirmationPage is the page to confirm a reservation or a cancellation, which redirects to finishURL once confirmed.
+type confirmationPage struct {
+	c *client
+	// name prefixes the screenshots of the page.
+	name      string
+	linkText  string
+	finishURL string
+}
+
+// newReservationConfirmationPage returns the confirmation page of the reservation of typ.
+func newReservationConfirmationPage(c *client, typ ReservationType) confirmationPage {
+	return confirmationPage{c: c, name: "reservation", linkText: c.sel.ReservationConfirmLinkText, finishURL: reservationFlows[typ].finishURL}
+}
+
+// newCancelConfirmationPage returns the confirmation page of the cancellation.
+func newCancelConfirmationPage(c *client) confirmationPage {
+	return confirmationPage{c: c, name: "cancel", linkText: c.sel.CancelConfirmLinkText, finishURL: rarejobCancelFinishURL}
+}
+
+// waitLoaded waits for the confirm button to be shown.
+func (p confirmationPage) waitLoaded(ctx context.Context) error {
+	c := p.c
+	c.l.Debug("loading confirmation page", zap.String("page", p.name), zap.String("url", c.getCurrentURL()))
+	err := c.waitUntilElementLoaded(ctx, c.waits.Reservation, selenium.ByLinkText, p.linkText)
+	c.saveCurrentScreenshot(rarejobctlTempDir, p.name+"_page.png")
+	c.l.Debug("loaded confirmation page", zap.String("page", p.name), zap.String("url", c.getCurrentURL()))
+	return err
+}
+
+// confirm clicks the confirm button exactly once, since the click which failed, e.g. timed out, may have been accepted
+// by the site and clicking again could reserve or cancel twice. Only the lookup of the button is retried.
+// sent tells whether the click was sent, in which case the result must be checked on the finish page or my page.
+func (p confirmationPage) confirm(ctx context.Context) (sent bool, err error) {
+	elm, err := p.c.findElement(ctx, selenium.ByLinkText, p.linkText)
+	if err != nil {
+		return false, err
+	}
+	err = elm.Click()
+	p.c.recordStep()
+	return true, err
+}
+
+// waitFinished waits until redirected to the finish page.
+func (p confirmationPage) waitFinished(ctx context.Context) error {
+	c := p.c
+	if err := c.waitUntilURLChanged(ctx, c.waits.Reservation, p.finishURL); err != nil {
+		return err
+	}
+	c.saveCurrentScreenshot(rarejobctlTempDir, p.name+"_completed.png")
+	c.recordStep()
+	return nil
+}
@@ -0,0 +1,58 @@
+package librarejob
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// sameUser reports whether the peer of conn on the loopback is a socket of the user running this process,
+// looking up the owner of the socket in /proc/net/tcp and /proc/net/tcp6.
+func sameUser(conn net.Conn) (bool, error) {
+	local, ok := conn.LocalAddr().(*net.TCPAddr)
+	if !ok {
+		return false, fmt.Errorf("not a tcp connection: %s", conn.LocalAddr())
+	}
+	remote := conn.RemoteAddr().(*net.TCPAddr)
+	for _, name := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		b, err := os.ReadFile(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		// the peer is the socket from the remote port to the local port, e.g.
+		//   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid ...
+		//    0: 0100007F:A1B2 0100007F:C3D4 01 00000000:00000000 00:00000000 00000000  1000 ...
+		for _, line := range strings.Split(string(b), "\n")[1:] {
+			fields := strings.Fields(line)
+			if len(fields) < 8 || procPort(fields[1]) != remote.Port || procPort(fields[2]) != local.Port {
+				continue
+			}
+			uid, err := strconv.Atoi(fields[7])
+			if err != nil {
+				return false, fmt.Errorf("invalid uid in %s: %w", name, err)
+			}
+			return uid == os.Getuid(), nil
+		}
+	}
+	return false, fmt.Errorf("the socket of %s is not found", remote)
+}
+
+// procPort parses the port of the address in /proc/net/tcp, e.g. 0100007F:1F90. It returns -1 if invalid.
+func procPort(addr string) int {
+	_, port, ok := strings.Cut(addr, ":")
+	if !ok {
+		return -1
+	}
+	p, err := strconv.ParseUint(port, 16, 16)
+	if err != nil {
+		return -1
+	}
+	return int(p)
+}
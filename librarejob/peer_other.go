@@ -0,0 +1,11 @@
+//go:build !linux
+
+package librarejob
+
+import "net"
+
+// sameUser reports whether the peer of conn is a socket of the user running this process. The owner of the socket
+// can't be looked up on this platform, so every local connection is accepted.
+func sameUser(conn net.Conn) (bool, error) {
+	return true, nil
+}
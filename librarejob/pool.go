@@ -0,0 +1,402 @@
+package librarejob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrPoolClosed is returned by Pool.Acquire after the pool is closed.
+var ErrPoolClosed = errors.New("session pool is closed")
+
+// PoolOpts configures Pool.
+type PoolOpts struct {
+	// Size is the number of the browser sessions. 1 is used if not positive.
+	Size int
+	// New creates the client of the i-th session (0-origin), e.g. with its own port of the local selenium server.
+	// It's also called to recycle the session when it dies.
+	New func(i int) (Client, error)
+	// Credentials are used to log in the sessions.
+	Credentials Credentials
+	// KeepAlive is the interval of the health check of the idle sessions, which also keeps them logged in.
+	// It is checked every 5 minutes if zero, and the health check is disabled if negative.
+	KeepAlive time.Duration
+	// MaintenanceBackoff is the wait before trying again when the site is under maintenance and the page doesn't tell
+	// when it ends. 15 minutes is used if not positive.
+	MaintenanceBackoff time.Duration
+	// Logger is the logger used by the pool. Nothing is logged if nil.
+	Logger *zap.Logger
+}
+
+// Pool keeps the warm, logged-in browser sessions to share between the calls, e.g. of the gRPC server,
+// instead of starting the browser for each of them. The sessions are checked periodically while idle,
+// logged in again when the login has expired, and recreated when they die.
+// While the site is under maintenance, the pool fails the calls fast and waits for the maintenance to end
+// before checking the sessions again.
+type Pool struct {
+	opts PoolOpts
+	l    *zap.Logger
+	idle chan *pooledSession
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	closed bool
+	// inUse maps the acquired clients to their sessions.
+	inUse map[Client]*pooledSession
+	// status is the result of the last check of each session.
+	status []SessionStatus
+	// maintenance is the last maintenance found, which lasts until its Until.
+	maintenance *MaintenanceError
+}
+
+// SessionStatus is the state of a session in the pool found by its last check, which is done when it's started,
+// every KeepAlive while idle, and after a call fails for a reason which may break the session.
+type SessionStatus struct {
+	// Session is the 0-origin index of the session.
+	Session int `json:"session"`
+	// Alive reports whether the browser responded.
+	Alive bool `json:"alive"`
+	// LoggedIn reports whether the RareJob session was still logged in, or logged in again.
+	LoggedIn bool `json:"logged_in"`
+	// InUse reports whether the session is serving a call now.
+	InUse     bool      `json:"in_use"`
+	CheckedAt time.Time `json:"checked_at"`
+	// Error is the error of the last check if any.
+	Error string `json:"error,omitempty"`
+}
+
+type pooledSession struct {
+	i  int
+	rc Client
+}
+
+// NewPool starts and logs in every session. The sessions started so far are torn down if any of them fails.
+func NewPool(ctx context.Context, opts PoolOpts) (*Pool, error) {
+	if opts.New == nil {
+		return nil, fmt.Errorf("%w: New of the pool is required", ErrInvalidOptions)
+	}
+	if opts.Size <= 0 {
+		opts.Size = 1
+	}
+	if opts.KeepAlive == 0 {
+		opts.KeepAlive = defaultPoolKeepAlive
+	}
+	if opts.MaintenanceBackoff <= 0 {
+		opts.MaintenanceBackoff = defaultMaintenanceBackoff
+	}
+	l := opts.Logger
+	if l == nil {
+		l = zap.NewNop()
+	}
+
+	pctx, cancel := context.WithCancel(context.Background())
+	p := &Pool{
+		opts:   opts,
+		l:      l,
+		idle:   make(chan *pooledSession, opts.Size),
+		ctx:    pctx,
+		cancel: cancel,
+		inUse:  map[Client]*pooledSession{},
+		status: make([]SessionStatus, opts.Size),
+	}
+	for i := range p.status {
+		p.status[i].Session = i
+	}
+	for i := 0; i < opts.Size; i++ {
+		s, err := p.start(ctx, i)
+		for errors.Is(err, ErrSiteMaintenance) {
+			if werr := p.waitMaintenance(ctx, err); werr != nil {
+				break
+			}
+			s, err = p.start(ctx, i)
+		}
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		p.idle <- s
+	}
+	if opts.KeepAlive > 0 {
+		p.wg.Add(1)
+		go p.keepAlive()
+	}
+	return p, nil
+}
+
+// start creates the client of the i-th session and logs it in.
+func (p *Pool) start(ctx context.Context, i int) (*pooledSession, error) {
+	rc, err := p.opts.New(i)
+	if err != nil {
+		p.setStatus(i, false, false, err)
+		return nil, fmt.Errorf("failed to create client of session %d: %w", i, err)
+	}
+	if err := rc.Login(ctx, p.opts.Credentials.Email, p.opts.Credentials.Password); err != nil {
+		p.setStatus(i, true, false, err)
+		p.teardown(rc)
+		return nil, fmt.Errorf("failed to login session %d: %w", i, err)
+	}
+	p.setStatus(i, true, true, nil)
+	p.l.Info("started browser session", zap.Int("session", i))
+	return &pooledSession{i: i, rc: rc}, nil
+}
+
+// Acquire waits for an idle session and returns its client. Pass the client to Release when done with it.
+// It returns *MaintenanceError without waiting while the site is under maintenance.
+func (p *Pool) Acquire(ctx context.Context) (Client, error) {
+	if m := p.Maintenance(); m != nil {
+		return nil, m
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-p.ctx.Done():
+		return nil, ErrPoolClosed
+	case s := <-p.idle:
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.closed {
+			p.teardown(s.rc)
+			return nil, ErrPoolClosed
+		}
+		p.inUse[s.rc] = s
+		return s.rc, nil
+	}
+}
+
+// Release returns the client acquired by Acquire to the pool. err is the error of the last call with the client,
+// and the session is checked before reused if it suggests that the session may be broken.
+func (p *Pool) Release(rc Client, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s, ok := p.inUse[rc]
+	if !ok {
+		return
+	}
+	delete(p.inUse, rc)
+	if p.closed {
+		p.teardown(rc)
+		return
+	}
+
+	if errors.Is(err, ErrSiteMaintenance) {
+		p.setMaintenance(err)
+	}
+	// the failures told by the site, e.g. no tutors available, leave the session as is
+	if code := ErrorCode(err); code != "unknown" && code != "session_expired" {
+		p.idle <- s
+		return
+	}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.l.Info("checking browser session after failure", zap.Int("session", s.i), zap.Error(err))
+		p.revive(s)
+	}()
+}
+
+// keepAlive checks the idle sessions every KeepAlive.
+func (p *Pool) keepAlive() {
+	defer p.wg.Done()
+	t := time.NewTicker(p.opts.KeepAlive)
+	defer t.Stop()
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-t.C:
+		}
+		// the sessions can't be checked until the maintenance ends
+		if p.Maintenance() != nil {
+			continue
+		}
+		// check only the sessions idle now, the others are checked in the next round
+		for n := len(p.idle); n > 0; n-- {
+			select {
+			case s := <-p.idle:
+				p.revive(s)
+			default:
+			}
+		}
+	}
+}
+
+// revive checks the session and returns it to the idle sessions. It logs in again if the login has expired,
+// and recreates the session if it's dead, retrying every KeepAlive until it succeeds or the pool is closed.
+// The session is kept during the maintenance, and checked again after it.
+func (p *Pool) revive(s *pooledSession) {
+	err := p.check(s)
+	for errors.Is(err, ErrSiteMaintenance) {
+		if p.waitMaintenance(p.ctx, err) != nil {
+			p.release(s)
+			return
+		}
+		err = p.check(s)
+	}
+	for err != nil {
+		p.l.Warn("browser session is dead, recreating", zap.Int("session", s.i), zap.Error(err))
+		p.teardown(s.rc)
+		var ns *pooledSession
+		if ns, err = p.start(p.ctx, s.i); err == nil {
+			s = ns
+			break
+		}
+		s = &pooledSession{i: s.i}
+		if errors.Is(err, ErrSiteMaintenance) {
+			if p.waitMaintenance(p.ctx, err) != nil {
+				return
+			}
+			continue
+		}
+		retry := p.opts.KeepAlive
+		if retry <= 0 {
+			retry = defaultPoolKeepAlive
+		}
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-time.After(retry):
+		}
+	}
+	p.release(s)
+}
+
+// release returns the session to the idle sessions, or tears it down if the pool is closed.
+func (p *Pool) release(s *pooledSession) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		p.teardown(s.rc)
+		return
+	}
+	p.idle <- s
+}
+
+// check opens a page of the account to see if the session is alive, and logs in again if the login has expired.
+func (p *Pool) check(s *pooledSession) error {
+	if s.rc == nil {
+		return errors.New("session has not been created")
+	}
+	ctx, cancel := context.WithTimeout(p.ctx, defaultPoolCheckTimeout)
+	defer cancel()
+	_, err := s.rc.AccountStatus(ctx)
+	if errors.Is(err, ErrSiteMaintenance) {
+		// the browser is alive since it showed the maintenance page
+		p.setStatus(s.i, true, false, err)
+		return err
+	}
+	if errors.Is(err, ErrSessionExpired) {
+		p.l.Info("browser session has been logged out, logging in again", zap.Int("session", s.i))
+		err = s.rc.Login(ctx, p.opts.Credentials.Email, p.opts.Credentials.Password)
+		// the browser is alive since it showed the login page
+		p.setStatus(s.i, true, err == nil, err)
+		return err
+	}
+	p.setStatus(s.i, err == nil, err == nil, err)
+	return err
+}
+
+func (p *Pool) setStatus(i int, alive, loggedIn bool, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	st := SessionStatus{Session: i, Alive: alive, LoggedIn: loggedIn, CheckedAt: time.Now()}
+	if err != nil {
+		st.Error = err.Error()
+	}
+	p.status[i] = st
+}
+
+// setMaintenance records the maintenance found by err until it ends, or for MaintenanceBackoff if the end is unknown
+// or has passed, and returns the wait until then.
+func (p *Pool) setMaintenance(err error) time.Duration {
+	m := &MaintenanceError{}
+	var me *MaintenanceError
+	if errors.As(err, &me) {
+		*m = *me
+	}
+	now := time.Now()
+	if m.Until.Before(now) {
+		m.Until = now.Add(p.opts.MaintenanceBackoff)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.maintenance = m
+	return m.Until.Sub(now)
+}
+
+// waitMaintenance waits until the maintenance found by err ends.
+func (p *Pool) waitMaintenance(ctx context.Context, err error) error {
+	d := p.setMaintenance(err)
+	p.l.Warn("site is under maintenance, waiting for it to end", zap.Duration("wait", d), zap.Error(err))
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.ctx.Done():
+		return ErrPoolClosed
+	case <-t.C:
+		return nil
+	}
+}
+
+// Maintenance returns the maintenance of the site which the pool is waiting for to end, or nil if not in maintenance.
+func (p *Pool) Maintenance() *MaintenanceError {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.maintenance == nil || !time.Now().Before(p.maintenance.Until) {
+		return nil
+	}
+	m := *p.maintenance
+	return &m
+}
+
+// Status returns the state of the sessions found by their last checks.
+func (p *Pool) Status() []SessionStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	status := make([]SessionStatus, len(p.status))
+	copy(status, p.status)
+	for _, s := range p.inUse {
+		status[s.i].InUse = true
+	}
+	return status
+}
+
+func (p *Pool) teardown(rc Client) {
+	if rc == nil {
+		return
+	}
+	if err := rc.Teardown(); err != nil {
+		p.l.Warn("failed to tear down browser session", zap.Error(err))
+	}
+}
+
+// Close tears down the idle sessions and stops the health check. The sessions in use are torn down when released.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	p.cancel()
+	p.wg.Wait()
+	for {
+		select {
+		case s := <-p.idle:
+			p.teardown(s.rc)
+		default:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package librarejob
+
+import (
+	"fmt"
+	"time"
+)
+
+// Window is the window of the start time of the acceptable slots, from From to From+Margin inclusive.
+// It must be in a day of the timezone of the client, as the search of the site is.
+type Window struct {
+	From   time.Time     `json:"from"`
+	Margin time.Duration `json:"margin"`
+}
+
+func (w Window) contains(t time.Time) bool {
+	return !t.Before(w.From) && !t.After(w.From.Add(w.Margin))
+}
+
+// Preference describes which slots are acceptable and which of them are better, to reserve the best slot
+// instead of the first one found. The times of day and the weekdays are of the timezone of the client.
+type Preference struct {
+	// Windows are the windows of the acceptable slots, the preferred one first.
+	Windows []Window `json:"windows"`
+	// Earliest and Latest bound the start time of the acceptable slots if not zero.
+	Earliest time.Time `json:"earliest,omitempty"`
+	Latest   time.Time `json:"latest,omitempty"`
+	// PreferredTimes are the start times of day formatted in HH:MM which are preferred over the others,
+	// the most preferred first. They are preferred over the order of Windows.
+	PreferredTimes []string `json:"preferred_times,omitempty"`
+	// Weekdays restricts the acceptable slots to the weekdays if not empty.
+	Weekdays []time.Weekday `json:"weekdays,omitempty"`
+}
+
+// Validate returns ErrInvalidOptions if the preference has no window or any of the preferred times is malformed.
+func (p Preference) Validate() error {
+	if len(p.Windows) == 0 {
+		return fmt.Errorf("%w: preference has no window", ErrInvalidOptions)
+	}
+	for _, w := range p.Windows {
+		if w.Margin < 0 {
+			return fmt.Errorf("%w: negative margin of the window from %s", ErrInvalidOptions, w.From.Format(time.DateTime))
+		}
+	}
+	for _, s := range p.PreferredTimes {
+		if _, err := time.Parse("15:04", s); err != nil {
+			return fmt.Errorf("%w: invalid preferred time: %s", ErrInvalidOptions, s)
+		}
+	}
+	return nil
+}
+
+// Rank returns the rank of the slot starting at start, the lower the better, and false if the slot is not acceptable.
+// The slots at the preferred times come first in the order of PreferredTimes, then the slots in the earlier windows.
+func (p Preference) Rank(start time.Time, loc *time.Location) (int, bool) {
+	if !p.Earliest.IsZero() && start.Before(p.Earliest) || !p.Latest.IsZero() && start.After(p.Latest) {
+		return 0, false
+	}
+	start = start.In(loc)
+	if !p.onWeekday(start) {
+		return 0, false
+	}
+	window := -1
+	for i, w := range p.Windows {
+		if w.contains(start) {
+			window = i
+			break
+		}
+	}
+	if window < 0 {
+		return 0, false
+	}
+	preferred := len(p.PreferredTimes)
+	for i, s := range p.PreferredTimes {
+		if t, err := time.Parse("15:04", s); err == nil && t.Hour() == start.Hour() && t.Minute() == start.Minute() {
+			preferred = i
+			break
+		}
+	}
+	return preferred*len(p.Windows) + window, true
+}
+
+func (p Preference) onWeekday(t time.Time) bool {
+	if len(p.Weekdays) == 0 {
+		return true
+	}
+	for _, d := range p.Weekdays {
+		if t.Weekday() == d {
+			return true
+		}
+	}
+	return false
+}
+
+// searchWindows returns the windows to search, which are Windows clipped by Earliest and Latest, in the same order.
+// The windows on the other weekdays or out of the bounds have a negative margin not to be searched.
+func (p Preference) searchWindows(loc *time.Location) []Window {
+	windows := make([]Window, len(p.Windows))
+	for i, w := range p.Windows {
+		from, by := w.From, w.From.Add(w.Margin)
+		if !p.Earliest.IsZero() && from.Before(p.Earliest) {
+			from = p.Earliest
+		}
+		if !p.Latest.IsZero() && by.After(p.Latest) {
+			by = p.Latest
+		}
+		if !p.onWeekday(from.In(loc)) {
+			by = from.Add(-time.Nanosecond)
+		}
+		windows[i] = Window{From: from, Margin: by.Sub(from)}
+	}
+	return windows
+}
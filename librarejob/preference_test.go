@@ -0,0 +1,165 @@
+package librarejob
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWindowContains(t *testing.T) {
+	from := time.Date(2022, 12, 27, 21, 0, 0, 0, DefaultLocation)
+	w := Window{From: from, Margin: time.Hour}
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{"before from", from.Add(-time.Minute), false},
+		{"at from", from, true},
+		{"in the window", from.Add(30 * time.Minute), true},
+		{"at the end", from.Add(time.Hour), true},
+		{"after the end", from.Add(time.Hour + time.Minute), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := w.contains(tt.t); got != tt.want {
+				t.Errorf("contains(%s) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreferenceRank(t *testing.T) {
+	// 2022-12-27 is a Tuesday
+	day := func(d, hour, minute int) time.Time {
+		return time.Date(2022, 12, d, hour, minute, 0, 0, DefaultLocation)
+	}
+	windows := []Window{
+		{From: day(27, 21, 0), Margin: time.Hour},
+		{From: day(27, 7, 0), Margin: time.Hour},
+	}
+	tests := []struct {
+		name     string
+		pref     Preference
+		start    time.Time
+		wantRank int
+		wantOK   bool
+	}{
+		{"first window", Preference{Windows: windows}, day(27, 21, 30), 0, true},
+		{"second window", Preference{Windows: windows}, day(27, 7, 30), 1, true},
+		{"out of the windows", Preference{Windows: windows}, day(27, 12, 0), 0, false},
+		{"before earliest", Preference{Windows: windows, Earliest: day(27, 21, 30)}, day(27, 21, 0), 0, false},
+		{"at earliest", Preference{Windows: windows, Earliest: day(27, 21, 30)}, day(27, 21, 30), 0, true},
+		{"after latest", Preference{Windows: windows, Latest: day(27, 21, 30)}, day(27, 22, 0), 0, false},
+		{"on the weekday", Preference{Windows: windows, Weekdays: []time.Weekday{time.Tuesday}}, day(27, 21, 0), 0, true},
+		{"on another weekday", Preference{Windows: windows, Weekdays: []time.Weekday{time.Monday}}, day(27, 21, 0), 0, false},
+		{
+			"preferred time in the second window",
+			Preference{Windows: windows, PreferredTimes: []string{"07:30"}},
+			day(27, 7, 30), 1, true,
+		},
+		{
+			"not preferred time in the first window",
+			Preference{Windows: windows, PreferredTimes: []string{"07:30"}},
+			day(27, 21, 0), 2, true,
+		},
+		{
+			"second preferred time",
+			Preference{Windows: windows, PreferredTimes: []string{"07:30", "21:30"}},
+			day(27, 21, 30), 2, true,
+		},
+		{
+			"in the timezone of the client",
+			Preference{Windows: windows},
+			day(27, 21, 30).UTC(), 0, true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rank, ok := tt.pref.Rank(tt.start, DefaultLocation)
+			if ok != tt.wantOK || ok && rank != tt.wantRank {
+				t.Errorf("Rank(%s) = %d, %v, want %d, %v", tt.start, rank, ok, tt.wantRank, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestPreferenceValidate(t *testing.T) {
+	from := time.Date(2022, 12, 27, 21, 0, 0, 0, DefaultLocation)
+	tests := []struct {
+		name    string
+		pref    Preference
+		wantErr bool
+	}{
+		{"valid", Preference{Windows: []Window{{From: from, Margin: time.Hour}}, PreferredTimes: []string{"21:30"}}, false},
+		{"no window", Preference{}, true},
+		{"negative margin", Preference{Windows: []Window{{From: from, Margin: -time.Hour}}}, true},
+		{"malformed preferred time", Preference{Windows: []Window{{From: from}}, PreferredTimes: []string{"9pm"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.pref.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidOptions) {
+				t.Errorf("Validate() = %v, want ErrInvalidOptions", err)
+			}
+		})
+	}
+}
+
+func TestPreferenceSearchWindows(t *testing.T) {
+	day := func(d, hour, minute int) time.Time {
+		return time.Date(2022, 12, d, hour, minute, 0, 0, DefaultLocation)
+	}
+	tests := []struct {
+		name string
+		pref Preference
+		want []Window
+	}{
+		{
+			"as is",
+			Preference{Windows: []Window{{From: day(27, 21, 0), Margin: time.Hour}}},
+			[]Window{{From: day(27, 21, 0), Margin: time.Hour}},
+		},
+		{
+			"clipped by earliest and latest",
+			Preference{
+				Windows:  []Window{{From: day(27, 21, 0), Margin: time.Hour}},
+				Earliest: day(27, 21, 15),
+				Latest:   day(27, 21, 45),
+			},
+			[]Window{{From: day(27, 21, 15), Margin: 30 * time.Minute}},
+		},
+		{
+			"out of the bounds",
+			Preference{
+				Windows:  []Window{{From: day(27, 21, 0), Margin: time.Hour}},
+				Earliest: day(28, 0, 0),
+			},
+			[]Window{{From: day(28, 0, 0), Margin: -2 * time.Hour}},
+		},
+		{
+			"on another weekday",
+			Preference{
+				Windows:  []Window{{From: day(27, 21, 0), Margin: time.Hour}, {From: day(28, 21, 0), Margin: time.Hour}},
+				Weekdays: []time.Weekday{time.Wednesday},
+			},
+			[]Window{{From: day(27, 21, 0), Margin: -time.Nanosecond}, {From: day(28, 21, 0), Margin: time.Hour}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.pref.searchWindows(DefaultLocation)
+			if len(got) != len(tt.want) {
+				t.Fatalf("searchWindows() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].From.Equal(tt.want[i].From) || got[i].Margin != tt.want[i].Margin {
+					t.Errorf("searchWindows()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
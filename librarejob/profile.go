@@ -0,0 +1,66 @@
+package librarejob
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/tebeka/selenium"
+	"go.uber.org/zap"
+)
+
+func (c *client) GetTutorProfile(ctx context.Context, id string) (_ *Tutor, err error) {
+	unlock, err := c.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	defer c.l.Sync()
+	defer c.flushConsoleLogs()
+	defer func() { err = c.captureFailure("get_tutor_profile", err) }()
+
+	if id == "" {
+		return nil, fmt.Errorf("%w: tutor id is empty", ErrInvalidOptions)
+	}
+	if err := c.get(ctx, fmt.Sprintf(rarejobTutorDetailURL, url.PathEscape(id))); err != nil {
+		return nil, fmt.Errorf("failed to get tutor detail page: %w", err)
+	}
+	if c.isAt(rarejobLoginURL) {
+		return nil, ErrSessionExpired
+	}
+	if err := c.waitUntilElementLoaded(ctx, c.waits.Default, selenium.ByCSSSelector, c.sel.TutorProfileName); err != nil {
+		return nil, fmt.Errorf("failed to load tutor detail page: %w", err)
+	}
+
+	t := &Tutor{ID: id}
+	name, err := c.elementText(ctx, selenium.ByCSSSelector, c.sel.TutorProfileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tutor name: %w", err)
+	}
+	t.Name = strings.TrimSpace(name)
+	if text, err := c.elementText(ctx, selenium.ByCSSSelector, c.sel.TutorProfileRating); err == nil {
+		t.Rating, _ = strconv.ParseFloat(strings.TrimSpace(text), 64)
+	}
+	if text, err := c.elementText(ctx, selenium.ByCSSSelector, c.sel.TutorProfileTotalLessons); err == nil {
+		// e.g. "12,345回"
+		t.TotalLessons, _ = parseNumber(strings.ReplaceAll(text, ",", ""))
+	}
+	if text, err := c.elementText(ctx, selenium.ByCSSSelector, c.sel.TutorProfileIntroduction); err == nil {
+		t.Introduction = strings.TrimSpace(text)
+	}
+	specialties, _ := c.wd.FindElements(selenium.ByCSSSelector, c.sel.TutorProfileSpecialty)
+	for _, e := range specialties {
+		if text, err := e.Text(); err == nil && strings.TrimSpace(text) != "" {
+			t.Specialties = append(t.Specialties, strings.TrimSpace(text))
+		}
+	}
+	// not every tutor has the introduction video
+	if video, err := c.wd.FindElement(selenium.ByCSSSelector, c.sel.TutorProfileVideo); err == nil {
+		t.IntroVideoURL, _ = video.GetAttribute("src")
+	}
+
+	c.l.Debug("fetched tutor profile", zap.Object("tutor", t))
+	return t, nil
+}
@@ -0,0 +1,70 @@
+package librarejob
+
+import (
+	"context"
+	"time"
+)
+
+// ProgressEventType is the kind of the progress of the client.
+type ProgressEventType string
+
+const (
+	// ProgressPhaseStarted is sent when a phase of the browser flow, e.g. login or search, starts.
+	ProgressPhaseStarted ProgressEventType = "phase_started"
+	// ProgressTutorFound is sent for each tutor found by the search as soon as it's read from the search result,
+	// so the tutors can be processed before the search finishes. The search may still fail after it.
+	ProgressTutorFound ProgressEventType = "tutor_found"
+	// ProgressSlotSelected is sent when the slot to reserve is selected.
+	ProgressSlotSelected ProgressEventType = "slot_selected"
+	// ProgressReserved is sent when the reservation is made, including dry-run and the existing one.
+	ProgressReserved ProgressEventType = "reserved"
+	// ProgressFailed is sent when the reservation fails.
+	ProgressFailed ProgressEventType = "failed"
+)
+
+// Phases of the browser flow sent with ProgressPhaseStarted.
+const (
+	PhaseLogin             = "login"
+	PhaseCheckReservations = "check_reservations"
+	PhaseSearch            = "search"
+	PhaseOpenSlot          = "open_slot"
+	PhaseConfirm           = "confirm"
+	PhaseWaitConfirmation  = "wait_confirmation"
+	// PhaseVerify checks the reservation is listed on my page after the confirmation.
+	PhaseVerify = "verify"
+)
+
+// ProgressEvent is the progress of the client. The fields other than Type are set depending on the type.
+type ProgressEvent struct {
+	Type ProgressEventType `json:"type"`
+	// Phase is set for ProgressPhaseStarted.
+	Phase string `json:"phase,omitempty"`
+	// Tutor is set for ProgressTutorFound and ProgressSlotSelected.
+	Tutor *Tutor `json:"tutor,omitempty"`
+	// Slot is set for ProgressSlotSelected.
+	Slot time.Time `json:"slot,omitempty"`
+	// Reserve is set for ProgressReserved.
+	Reserve *Reserve `json:"reserve,omitempty"`
+	// Err is set for ProgressFailed.
+	Err error `json:"-"`
+}
+
+// ProgressFunc receives the progress. It is called synchronously, so it should return quickly.
+type ProgressFunc func(ProgressEvent)
+
+type progressKey struct{}
+
+// WithProgress returns the context to pass to the client methods to receive their progress with fn.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+func sendProgress(ctx context.Context, ev ProgressEvent) {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok && fn != nil {
+		fn(ev)
+	}
+}
+
+func phaseStarted(ctx context.Context, phase string) {
+	sendProgress(ctx, ProgressEvent{Type: ProgressPhaseStarted, Phase: phase})
+}
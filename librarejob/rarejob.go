@@ -2,13 +2,15 @@ package librarejob
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"strings"
 	"time"
 
 	"github.com/tebeka/selenium"
 	"github.com/tebeka/selenium/log"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -19,19 +21,105 @@ import (
 //  once rarejob_onetime_key and PHPSESSID are deleted, session is closed and we're redirected to login page.
 
 type Reserve struct {
-	Name    string
-	StartAt time.Time
-	EndAt   time.Time
+	Type    ReservationType `json:"type"`
+	Name    string          `json:"name"`
+	StartAt time.Time       `json:"start_at"`
+	EndAt   time.Time       `json:"end_at"`
+	// DryRun is true if the reservation was not actually made because the client is in dry-run mode.
+	DryRun bool `json:"dry_run"`
+	// AlreadyExists is true if the reservation had been made before Reserve was called, so nothing was reserved.
+	AlreadyExists bool `json:"already_exists"`
+	// CancelDeadline is the deadline to cancel the reservation for free, which is set by ListReservations.
+	// Cancelling after it consumes the lesson.
+	CancelDeadline time.Time `json:"cancel_deadline"`
+	// LessonRoomURL is the URL to enter the lesson room, which is set by ListReservations once the room is open.
+	LessonRoomURL string `json:"lesson_room_url,omitempty"`
+	// MaterialURL is the URL of the material assigned to the lesson, which is set by ListReservations if assigned.
+	MaterialURL string `json:"material_url,omitempty"`
 }
 
 type Tutor struct {
-	Name           string
-	AvailableSlots []time.Time
+	// ID is the id of the tutor to get the profile with GetTutorProfile.
+	ID     string  `json:"id"`
+	Name   string  `json:"name"`
+	Rating float64 `json:"rating"`
+	// AvailableSlots are the slots in the order shown, including the ones failed to be read to preserve the index.
+	AvailableSlots []Slot `json:"available_slots"`
+
+	// The profile below is set only by GetTutorProfile.
+	TotalLessons  int      `json:"total_lessons,omitempty"`
+	Introduction  string   `json:"introduction,omitempty"`
+	Specialties   []string `json:"specialties,omitempty"`
+	IntroVideoURL string   `json:"intro_video_url,omitempty"`
 }
 
 func (t Tutor) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("id", t.ID)
 	enc.AddString("name", t.Name)
-	// TODO(musaprg): output availableslots
+	enc.AddFloat64("rating", t.Rating)
+	if t.AvailableSlots != nil {
+		enc.AddArray("available_slots", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+			for _, s := range t.AvailableSlots {
+				enc.AppendObject(s)
+			}
+			return nil
+		}))
+	}
+	return nil
+}
+
+// OpenSlots returns the start times of the available slots in the order shown.
+func (t Tutor) OpenSlots() []time.Time {
+	var slots []time.Time
+	for _, s := range t.AvailableSlots {
+		if s.Available() {
+			slots = append(slots, s.StartAt)
+		}
+	}
+	return slots
+}
+
+// SlotStatus is the status of a slot shown for the tutor.
+type SlotStatus string
+
+const (
+	// SlotAvailable is the slot which can be reserved.
+	SlotAvailable SlotStatus = "available"
+	// SlotUnparsed is the slot whose time failed to be read. It can't be reserved, but is kept to preserve the index
+	// of the slots on the page.
+	SlotUnparsed SlotStatus = "unparsed"
+	// SlotOutOfWindow is the slot shown on the page but out of the window to reserve in, which is kept to preserve
+	// the index of the slots on the page.
+	SlotOutOfWindow SlotStatus = "out_of_window"
+)
+
+// Slot is a slot of the tutor shown on the search result or the schedule.
+type Slot struct {
+	// StartAt is zero if the slot is unparsed.
+	StartAt time.Time  `json:"start_at"`
+	Status  SlotStatus `json:"status"`
+	// Text is the text shown for the slot which failed to be parsed, to tell why.
+	Text string `json:"text,omitempty"`
+}
+
+// AvailableSlot returns the available slot starting at startAt.
+func AvailableSlot(startAt time.Time) Slot {
+	return Slot{StartAt: startAt, Status: SlotAvailable}
+}
+
+// Available reports whether the slot can be reserved.
+func (s Slot) Available() bool {
+	return s.Status == SlotAvailable
+}
+
+func (s Slot) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("status", string(s.Status))
+	if !s.StartAt.IsZero() {
+		enc.AddTime("start_at", s.StartAt)
+	}
+	if s.Text != "" {
+		enc.AddString("text", s.Text)
+	}
 	return nil
 }
 
@@ -44,9 +132,45 @@ func (ts Tutors) MarshalLogArray(enc zapcore.ArrayEncoder) error {
 	return nil
 }
 
+// Client drives a browser session of RareJob. The client is safe for concurrent use, but the calls are run one by one
+// since they share the session: a call waits for the running one to complete, or fails with the error of its context
+// if the context is done while waiting. Teardown doesn't wait, and fails the running call. Use Pool to run the calls
+// in parallel with the isolated sessions.
 type Client interface {
+	// Login logs in with the email and the password, which can be resolved with a CredentialProvider.
 	Login(ctx context.Context, username, password string) error
-	ReserveTutor(ctx context.Context, from time.Time, by time.Duration) (*Reserve, error)
+	// ExportSession returns the cookies of the logged-in session to resume it later without the password.
+	ExportSession(ctx context.Context) ([]Cookie, error)
+	// ResumeSession resumes the session of the cookies exported by ExportSession. It returns ErrSessionExpired
+	// if the session has been logged out, when Login must be called instead.
+	ResumeSession(ctx context.Context, cookies []Cookie) error
+	SearchTutors(ctx context.Context, from time.Time, margin time.Duration) (Tutors, error)
+	CheckSelectors(ctx context.Context, username, password string, from time.Time, margin time.Duration) ([]SelectorCheck, error)
+	// ReserveTutor reserves the best available slot of the regular lesson according to pref.
+	ReserveTutor(ctx context.Context, pref Preference) (*Reserve, error)
+	// Reserve reserves the first available slot of the session described by req, or the best one if req.Preference is set.
+	Reserve(ctx context.Context, req ReserveRequest) (*Reserve, error)
+	// LessonHistory returns the past lessons which started at or after since, newest first.
+	LessonHistory(ctx context.Context, since time.Time) ([]Lesson, error)
+	// GetTutorProfile returns the tutor with the profile from the tutor detail page. AvailableSlots is not set.
+	GetTutorProfile(ctx context.Context, id string) (*Tutor, error)
+	// TutorSchedule returns the tutor with the open slots on the date from the schedule on the tutor detail page.
+	// The profile is not set.
+	TutorSchedule(ctx context.Context, id string, date time.Time) (*Tutor, error)
+	// FetchLessonReport returns the report of the lesson with the id, which is found in the lesson history.
+	FetchLessonReport(ctx context.Context, lessonID string) (*LessonReport, error)
+	// AccountStatus returns the current plan and the lesson tickets.
+	AccountStatus(ctx context.Context) (*AccountStatus, error)
+	// ListReservations returns the upcoming reservations, earliest first.
+	ListReservations(ctx context.Context) ([]Reserve, error)
+	// LessonRoomURL returns the URL to enter the lesson room of the reservation starting at startAt,
+	// or of the next reservation if startAt is zero.
+	LessonRoomURL(ctx context.Context, startAt time.Time) (string, error)
+	// LessonMaterial returns the material assigned to the reservation starting at startAt,
+	// or to the next reservation if startAt is zero.
+	LessonMaterial(ctx context.Context, startAt time.Time) (*Material, error)
+	// Cancel cancels the reservation starting at startAt. It refuses to cancel after the free cancellation deadline unless force is true.
+	Cancel(ctx context.Context, startAt time.Time, force bool) (*Reserve, error)
 	Teardown() error
 }
 
@@ -58,65 +182,179 @@ const (
 )
 
 type client struct {
-	s       *selenium.Service
-	wd      selenium.WebDriver
-	browser browserType
-	debug   bool
+	l        *zap.Logger
+	s        *selenium.Service
+	wd       selenium.WebDriver
+	proxy    *proxyForwarder
+	browser  browserType
+	waits    WaitConfig
+	sel      Selectors
+	retry    RetryOpts
+	throttle *throttle
+	audit    *auditLog
+	debug    bool
+	dryRun   bool
+
+	artifactsDir string
+	recorder     *recorder
+	baseURL      string
+	loc          *time.Location
+	locale       Locale
+
+	humanVerification func(ctx context.Context, screenshot string) error
+
+	// busy is held by the running call, since the calls sharing the browser session would corrupt each other's navigation.
+	busy chan struct{}
+}
+
+// lock waits for the running call to complete, and returns the function to let the next call run.
+// It returns the error of ctx if ctx is done while waiting.
+func (c *client) lock(ctx context.Context) (func(), error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case c.busy <- struct{}{}:
+		return func() { <-c.busy }, nil
+	}
 }
 
 type ClientOpts struct {
+	// Logger is the logger used by the client. Nothing is logged if nil.
+	Logger              *zap.Logger
 	SeleniumHost        string
 	SeleniumPort        *int
 	SeleniumBrowserName string
 	SeleniumDebug       bool
 	ClientDebug         bool
+	// Headful shows the browser window on the desktop instead of running it in an X frame buffer,
+	// which helps to develop the selectors and the flows. It only affects the selenium server started locally.
+	Headful bool
+	// DriverCacheDir is the directory to download selenium server and webdrivers into when they are not installed.
+	// DefaultDriverCacheDir is used if empty.
+	DriverCacheDir string
+	// DriverChecksums is the SHA-256 of the downloaded drivers by file name as DriverOpts.Checksums.
+	DriverChecksums map[string]string
+	// Browser overrides the user agent, the window size and the other capabilities of the browser.
+	Browser BrowserOpts
+	// Proxy is the proxy configuration for the browser session. No proxy is used if nil.
+	Proxy *ProxyOpts
+	// Waits configures the timeouts and polling intervals for each operation.
+	Waits WaitConfig
+	// Retry configures the retry of flaky element lookups, clicks and navigations.
+	Retry RetryOpts
+	// Throttle configures the min intervals and the rate limit of the navigations and the element queries.
+	Throttle ThrottleOpts
+	// AuditLog is where the logins, the searches, the reservations and the cancellations are recorded as JSON lines
+	// of AuditEntry, separately from Logger. Nothing is recorded if nil.
+	AuditLog io.Writer
+	// ArtifactsDir is the directory to save debug artifacts into when an operation fails. Disabled if empty.
+	ArtifactsDir string
+	// Record configures the screenshots of the whole session. Disabled if Dir is empty.
+	Record RecordOpts
+	// DryRun makes Reserve stop right before confirming the reservation.
+	DryRun bool
+	// HumanVerification is called when the site asks for a CAPTCHA or an additional verification during login,
+	// with the path of the screenshot of the page, which is empty if it couldn't be saved. The login waits for
+	// the verification to be completed in the browser after it returns nil. ErrHumanVerificationRequired is returned if nil.
+	HumanVerification func(ctx context.Context, screenshot string) error
+	// Locale is the language of the RareJob UI of the account. The language of the login page is used if empty.
+	Locale Locale
+	// Selectors overrides the selectors to find the elements. DefaultSelectors is used if nil.
+	Selectors *Selectors
+	// Location is the timezone of the times shown on the site, which is used to build the search queries and
+	// to parse the slots and the dates. DefaultLocation is used if nil.
+	Location *time.Location
+	// BaseURL replaces https://www.rarejob.com in the URLs to access, e.g. to run against the fixture server of librarejobtest.
+	// The real site is used if empty.
+	BaseURL string
 }
 
-func NewClient(opts ClientOpts) (Client, error) {
-	defer zap.L().Sync()
+// DefaultLocation is the timezone of RareJob, Asia/Tokyo. It is a fixed zone, since Japan has no daylight saving time
+// and it works without the timezone database, e.g. in a distroless container.
+var DefaultLocation = time.FixedZone("Asia/Tokyo", 9*60*60)
+
+func NewClient(opts ClientOpts) (_ Client, err error) {
+	l := opts.Logger
+	if l == nil {
+		l = zap.NewNop()
+	}
+	defer l.Sync()
+
+	var (
+		s     *selenium.Service
+		proxy *proxyForwarder
+		wd    selenium.WebDriver
+	)
+	// release what has been started if it fails halfway
+	defer func() {
+		if err == nil {
+			return
+		}
+		if wd != nil {
+			wd.Quit()
+		}
+		if proxy != nil {
+			proxy.Close()
+		}
+		if s != nil {
+			s.Stop()
+		}
+	}()
 
-	var s *selenium.Service
-	var err error
 	url := "127.0.0.1"
 	port := 4444
-	browserName := browserTypeFirefox
+	browserName, err := parseBrowserType(opts.SeleniumBrowserName)
+	if err != nil {
+		return nil, err
+	}
+	locale, err := ParseLocale(string(opts.Locale))
+	if err != nil {
+		return nil, err
+	}
 	if opts.SeleniumHost == "" {
 		if opts.SeleniumPort != nil {
 			port = *opts.SeleniumPort
 		}
-		s, err = startLocalSelenium(port, opts.SeleniumDebug)
+		paths, err := resolveDriverPaths(context.TODO(), l, browserName, opts.DriverCacheDir, opts.DriverChecksums)
+		if err != nil {
+			return nil, fmt.Errorf("failed to prepare drivers: %w", err)
+		}
+		s, err = startLocalSelenium(port, opts.SeleniumDebug, opts.Headful, paths)
 		if err != nil {
 			return nil, err
 		}
 	}
 	if opts.SeleniumHost != "" {
 		url = opts.SeleniumHost
+		if opts.Headful {
+			l.Warn("headful mode is ignored since the browser runs on the remote selenium server")
+		}
 	}
 	if opts.SeleniumPort != nil {
 		port = *opts.SeleniumPort
 	}
 
-	if opts.SeleniumBrowserName != "" {
-		switch opts.SeleniumBrowserName {
-		case string(browserTypeFirefox):
-			browserName = browserTypeFirefox
-		case string(browserTypeChrome):
-			browserName = browserTypeChrome
-		default:
-			return nil, fmt.Errorf("invalid browser name: %s", opts.SeleniumBrowserName)
-		}
-	}
-
 	urlPrefix := fmt.Sprintf("http://%s:%d/wd/hub", url, port)
 	caps := selenium.Capabilities{"browserName": string(browserName)}
 	caps.SetLogLevel(log.Browser, log.All)
+	if err := addBrowserCapabilities(caps, browserName, opts.Browser); err != nil {
+		return nil, err
+	}
+
+	if opts.Proxy != nil && opts.Proxy.URL != "" {
+		p, f, err := newProxyCapability(l, *opts.Proxy, opts.SeleniumHost == "")
+		if err != nil {
+			return nil, err
+		}
+		caps.AddProxy(*p)
+		proxy = f
+	}
 
 	// Connect to the WebDriver instance running locally.
-	var wd selenium.WebDriver
 	for i := 0; i < maxSeleniumHealthCheckBackoffLimit; i++ {
 		wd, err = selenium.NewRemote(caps, urlPrefix)
 		if err != nil {
-			zap.L().Warn("failed to access to the selenium server, retrying...", zap.Error(err))
+			l.Warn("failed to access to the selenium server, retrying...", zap.Error(err))
 			time.Sleep(time.Second * seleniumHealthCheckRetrySecond)
 		} else {
 			break
@@ -132,229 +370,530 @@ func NewClient(opts ClientOpts) (Client, error) {
 		}
 	}
 
+	var rec *recorder
+	if opts.Record.Dir != "" {
+		rec, err = startRecorder(l, wd, opts.Record)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	loc := DefaultLocation
+	if opts.Location != nil {
+		loc = opts.Location
+	}
+
+	sel := DefaultSelectors()
+	if opts.Selectors != nil {
+		sel = *opts.Selectors
+	}
+	if locale != "" {
+		sel = sel.localize(locale)
+	}
+
 	return &client{
-		s:     s,
-		wd:    wd,
-		debug: opts.ClientDebug,
+		l:        l,
+		s:        s,
+		wd:       wd,
+		proxy:    proxy,
+		browser:  browserName,
+		waits:    opts.Waits.resolve(),
+		sel:      sel,
+		retry:    opts.Retry.resolve(),
+		throttle: newThrottle(opts.Throttle),
+		audit:    &auditLog{l: l, w: opts.AuditLog},
+		debug:    opts.ClientDebug,
+		dryRun:   opts.DryRun,
+
+		artifactsDir: opts.ArtifactsDir,
+		recorder:     rec,
+		baseURL:      opts.BaseURL,
+		loc:          loc,
+		locale:       locale,
+
+		humanVerification: opts.HumanVerification,
+
+		busy: make(chan struct{}, 1),
 	}, nil
 }
 
-func startLocalSelenium(port int, debug bool) (*selenium.Service, error) {
+func parseBrowserType(name string) (browserType, error) {
+	switch name {
+	case "", string(browserTypeFirefox):
+		return browserTypeFirefox, nil
+	case string(browserTypeChrome):
+		return browserTypeChrome, nil
+	default:
+		return "", fmt.Errorf("%w: invalid browser name: %s", ErrInvalidOptions, name)
+	}
+}
+
+// resolveDriverPaths returns the preinstalled binaries if they exist, otherwise downloads them into the cache directory.
+func resolveDriverPaths(ctx context.Context, l *zap.Logger, browser browserType, cacheDir string, checksums map[string]string) (*DriverPaths, error) {
+	if browser == browserTypeFirefox && fileExists(systemSeleniumPath) && fileExists(systemGeckoDriverPath) {
+		return &DriverPaths{
+			SeleniumPath:    systemSeleniumPath,
+			GeckoDriverPath: systemGeckoDriverPath,
+		}, nil
+	}
+	l.Info("drivers are not installed, downloading them", zap.String("browser", string(browser)))
+	return EnsureDrivers(ctx, DriverOpts{
+		CacheDir:    cacheDir,
+		BrowserName: string(browser),
+		Logger:      l,
+		Checksums:   checksums,
+	})
+}
+
+func startLocalSelenium(port int, debug, headful bool, paths *DriverPaths) (*selenium.Service, error) {
 	// Start a Selenium WebDriver server instance (if one is not already
 	// running).
-	const (
-		// These paths will be different on your system.
-		seleniumPath    = "/opt/selenium/selenium-server-standalone.jar"
-		geckoDriverPath = "/usr/bin/geckodriver"
-	)
-	so := []selenium.ServiceOption{
-		selenium.StartFrameBuffer(),           // Start an X frame buffer for the browser to run in.
-		selenium.GeckoDriver(geckoDriverPath), // Specify the path to GeckoDriver in order to use Firefox.
+	var so []selenium.ServiceOption
+	if !headful {
+		so = append(so, selenium.StartFrameBuffer()) // Start an X frame buffer for the browser to run in.
+	}
+	if paths.GeckoDriverPath != "" {
+		so = append(so, selenium.GeckoDriver(paths.GeckoDriverPath)) // Specify the path to GeckoDriver in order to use Firefox.
+	}
+	if paths.ChromeDriverPath != "" {
+		so = append(so, selenium.ChromeDriver(paths.ChromeDriverPath)) // Specify the path to ChromeDriver in order to use Chrome.
 	}
 	if debug {
 		so = append(so, selenium.Output(os.Stdout))
 		selenium.SetDebug(debug)
 	}
-	return selenium.NewSeleniumService(seleniumPath, port, so...)
+	return selenium.NewSeleniumService(paths.SeleniumPath, port, so...)
 }
 
 func (c *client) Login(ctx context.Context, username, password string) error {
-	defer zap.L().Sync()
-
-	zap.L().Debug("loading login page", zap.String("url", c.getCurrentURL()))
-
-	// TODO(musaprg): Cache SESSIONID and reuse
-	if err := c.wd.Get(rarejobLoginURL); err != nil {
-		return fmt.Errorf("failed to access rarejob login page: %w", err)
+	unlock, err := c.lock(ctx)
+	if err != nil {
+		return err
 	}
+	defer unlock()
+	return c.login(ctx, username, password)
+}
 
-	_ = waitUntilElementLoaded(c.wd, selenium.ByCSSSelector, loginPageEmailSelector)
-	_ = waitUntilElementLoaded(c.wd, selenium.ByCSSSelector, loginPagePasswordSelector)
-	c.saveCurrentScreenshot(rarejobctlTempDir, "login_page.png")
-	zap.L().Debug("login page has been loaded", zap.String("url", c.getCurrentURL()))
-
-	if emailInput, err := c.wd.FindElement(selenium.ByCSSSelector, loginPageEmailSelector); err != nil {
-		return fmt.Errorf("failed to find the email input box: %w", err)
-	} else {
-		zap.L().Debug("typing email", zap.String("url", c.getCurrentURL()))
-		err := emailInput.SendKeys(os.Getenv("RAREJOB_EMAIL"))
-		if err != nil {
-			return fmt.Errorf("failed to type email: %w", err)
-		}
+// login logs in with the lock held, which is also used by CheckSelectors.
+func (c *client) login(ctx context.Context, username, password string) (err error) {
+	defer c.l.Sync()
+	defer func() { observeLogin(err) }()
+	defer func(start time.Time) {
+		c.audit.record("login", start, map[string]interface{}{"email": username}, "", nil, err)
+	}(time.Now())
+	defer func() { err = c.captureFailure("login", err) }()
+	ctx, span := startSpan(ctx, "login")
+	defer func() { endSpan(span, err) }()
+	phaseStarted(ctx, PhaseLogin)
+
+	if username == "" || password == "" {
+		return fmt.Errorf("%w: email and password are required", ErrInvalidOptions)
 	}
-
-	if passwordInput, err := c.wd.FindElement(selenium.ByCSSSelector, loginPagePasswordSelector); err != nil {
-		return fmt.Errorf("failed to find the password input box: %w", err)
-	} else {
-		zap.L().Debug("typing password", zap.String("url", c.getCurrentURL()))
-		err := passwordInput.SendKeys(os.Getenv("RAREJOB_PASSWORD"))
-		if err != nil {
-			return fmt.Errorf("failed to type password: %w", err)
-		}
+	page := loginPage{c: c}
+	if err := page.open(ctx); err != nil {
+		return err
 	}
+	c.applyDetectedLocale(ctx)
+	return page.login(ctx, username, password)
+}
 
-	if submit, err := c.wd.FindElement(selenium.ByCSSSelector, "input[type='submit']"); err != nil {
-		return fmt.Errorf("failed to find submit button: %w", err)
-	} else {
-		zap.L().Debug("click submit button", zap.String("url", c.getCurrentURL()))
-		err := submit.Click()
-		if err != nil {
-			return fmt.Errorf("failed to submit login form: %w", err)
-		}
+func (c *client) SearchTutors(ctx context.Context, from time.Time, margin time.Duration) (_ Tutors, err error) {
+	unlock, err := c.lock(ctx)
+	if err != nil {
+		return nil, err
 	}
+	defer unlock()
+	defer c.l.Sync()
+	defer c.flushConsoleLogs()
+	defer func() { err = c.captureFailure("search_tutors", err) }()
 
-	if err := c.wd.Wait(func(wd selenium.WebDriver) (bool, error) {
-		currentURL := c.getCurrentURL()
-		zap.L().Debug("checking if the login has been completed", zap.String("url", currentURL))
+	return c.searchTutors(ctx, ReservationTypeLesson, from, margin)
+}
 
-		if strings.HasPrefix(currentURL, rarejobMyPageURL) {
-			return true, nil
-		}
+// searchTutors opens the search result of the tutors (or counselors/examiners for the other reservation types)
+// available between from and from+margin, and scrapes them.
+func (c *client) searchTutors(ctx context.Context, typ ReservationType, from time.Time, margin time.Duration) (tutors Tutors, err error) {
+	start := time.Now()
+	defer func() { observeSearch(typ, start, tutors, err) }()
+	defer func() {
+		params := map[string]interface{}{"type": typ, "from": from, "margin": margin.String()}
+		c.audit.record("search", start, params, "", map[string]interface{}{"tutors_found": len(tutors)}, err)
+	}()
+	ctx, span := startSpan(ctx, "search",
+		attribute.String("type", string(typ)),
+		attribute.String("from", from.Format(time.RFC3339)),
+		attribute.String("margin", margin.String()),
+	)
+	defer func() {
+		span.SetAttributes(attribute.Int("tutors_found", len(tutors)))
+		endSpan(span, err)
+	}()
+	phaseStarted(ctx, PhaseSearch)
+
+	from = from.In(c.loc)
+	by := from.Add(margin)
+	if !(margin < 24*time.Hour && from.Hour() <= by.Hour()) {
+		return nil, ErrSpreadAcrossTwoDays
+	}
 
-		return false, nil
-	}); err != nil {
-		return fmt.Errorf("login failed: %w", err)
+	page := tutorSearchPage{c: c, typ: typ, from: from, by: by}
+	if err := page.open(ctx); err != nil {
+		return nil, err
+	}
+	if tutors, err = page.tutors(ctx); err != nil {
+		return nil, err
 	}
 
-	zap.L().Debug("login completed", zap.String("url", c.getCurrentURL()))
-	c.saveCurrentScreenshot(rarejobctlTempDir, "login_completed.png")
+	c.l.Info("found tutors", zap.Array("tutors", tutors))
+	return tutors, nil
+}
 
-	return nil
+// ReserveTutor reserves the best available slot of the regular lesson according to pref.
+func (c *client) ReserveTutor(ctx context.Context, pref Preference) (*Reserve, error) {
+	return c.Reserve(ctx, ReserveRequest{Type: ReservationTypeLesson, Preference: &pref})
 }
 
-func (c *client) ReserveTutor(ctx context.Context, from time.Time, margin time.Duration) (*Reserve, error) {
-	defer zap.L().Sync()
+func (c *client) Reserve(ctx context.Context, req ReserveRequest) (r *Reserve, err error) {
+	unlock, err := c.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	defer c.l.Sync()
 	defer c.flushConsoleLogs()
+	defer func() { observeReservation(req.Type, r, err) }()
+	defer func(start time.Time) {
+		params := map[string]interface{}{
+			"type":        req.Type,
+			"from":        req.From,
+			"margin":      req.Margin.String(),
+			"busy":        req.Busy,
+			"force":       req.Force,
+			"consecutive": req.Consecutive,
+			"tutor":       req.Tutor,
+			"schedule":    req.Schedule,
+			"preference":  req.Preference,
+			"exact":       req.Exact,
+			"replacing":   req.Replacing,
+		}
+		c.audit.record("reserve", start, params, reserveOutcome(r, err), r, err)
+	}(time.Now())
+	defer func() { err = c.captureFailure("reserve_tutor", err) }()
+	ctx, span := startSpan(ctx, "reserve", attribute.String("type", string(req.Type)), attribute.Bool("consecutive", req.Consecutive))
+	defer func() { endSpan(span, err) }()
+	defer func() {
+		if err != nil {
+			sendProgress(ctx, ProgressEvent{Type: ProgressFailed, Err: err})
+		} else {
+			sendProgress(ctx, ProgressEvent{Type: ProgressReserved, Reserve: r})
+		}
+	}()
 
-	// TODO(musaprg): split this function into two
+	typ, err := ParseReservationType(string(req.Type))
+	if err != nil {
+		return nil, err
+	}
+	flow := reservationFlows[typ]
+	var src slotSource = searchSource{c: c, typ: typ}
+	if req.Schedule {
+		if typ != ReservationTypeLesson || req.Tutor == "" {
+			return nil, fmt.Errorf("%w: the schedule is only of the regular lesson of the tutor specified by id", ErrInvalidOptions)
+		}
+		src = scheduleSource{c: c, id: req.Tutor}
+	}
+	pref := Preference{Windows: []Window{{From: req.From, Margin: req.Margin}}}
+	if req.Preference != nil {
+		pref = *req.Preference
+	}
+	if req.Exact {
+		if req.Preference != nil {
+			return nil, fmt.Errorf("%w: the exact slot can't be reserved with the preference", ErrInvalidOptions)
+		}
+		pref = Preference{Windows: []Window{{From: req.From}}}
+	}
+	if err := pref.Validate(); err != nil {
+		return nil, err
+	}
 
-	// -- Search available tutors --
+	phaseStarted(ctx, PhaseCheckReservations)
+	reservations, err := c.listReservations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing reservations: %w", err)
+	}
+	reservations = withoutReplaced(reservations, req.Replacing)
+	// return the existing one not to double-book when the caller retries after a failure which actually succeeded
+	for _, r := range reservations {
+		if _, ok := pref.Rank(r.StartAt, c.loc); ok && r.Type == typ {
+			c.l.Info("already reserved in the window", zap.String("tutor", r.Name), zap.Time("start_at", r.StartAt))
+			r.AlreadyExists = true
+			return &r, nil
+		}
+	}
 
-	by := from.Local().Add(margin)
-	if !(margin < 24*time.Hour && from.Hour() <= by.Hour()) {
-		return nil, ErrSpreadAcrossTwoDays
+	var busy []Interval
+	if !req.Force {
+		busy = append(busy, req.Busy...)
+		for _, r := range reservations {
+			busy = append(busy, Interval{Start: r.StartAt, End: r.EndAt})
+		}
 	}
 
-	queryURL, err := generateTutorSearchQuery(from, by)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate search query: %w", err)
+	// search the windows in order until no later window can have a better slot than the one selected,
+	// since the rank of the slots in a window is not less than the index of the window
+	var (
+		tutors             Tutors
+		ti, si, rank       = -1, -1, 0
+		selected, searched = -1, -1
+		conflictErr        error
+		searchedWindows    []Window
+	)
+	for wi, w := range pref.searchWindows(c.loc) {
+		if w.Margin < 0 {
+			continue
+		}
+		if selected >= 0 && rank <= wi {
+			break
+		}
+		searchedWindows = append(searchedWindows, w)
+		margin := w.Margin
+		if req.Exact {
+			// search the length of the slot as reserveNextSlot does, the slots starting later are not acceptable by pref
+			margin = flow.duration
+		}
+		found, err := src.tutors(ctx, w.From, margin)
+		searched = wi
+		if errors.Is(err, ErrNoTutorsAvailable) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var order []int
+		if len(req.History) > 0 {
+			_, order = rankTutors(found, req.History)
+		}
+		order = matchTutors(found, order, req.Tutor)
+		t, sl, rk, err := selectSlot(found, order, flow, req.Consecutive, busy, pref, c.loc)
+		if errors.Is(err, ErrConflict) {
+			conflictErr = err
+			continue
+		}
+		if errors.Is(err, ErrNoTutorsAvailable) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if selected < 0 || rk < rank {
+			tutors, ti, si, rank, selected = found, t, sl, rk, wi
+		}
+	}
+	switch {
+	case selected >= 0:
+	case conflictErr != nil:
+		return nil, conflictErr
+	case len(searchedWindows) == 0:
+		return nil, fmt.Errorf("%w: no window of the preference is in the bounds and on the weekdays", ErrInvalidOptions)
+	case req.Exact:
+		return nil, fmt.Errorf("%w at %s", ErrSlotUnavailable, req.From.In(c.loc).Format(time.DateTime))
+	default:
+		last := searchedWindows[len(searchedWindows)-1]
+		return nil, &NoTutorsAvailableError{From: searchedWindows[0].From, To: last.From.In(c.loc).Add(last.Margin)}
+	}
+	tutor, slot := tutors[ti], tutors[ti].AvailableSlots[si].StartAt
+	c.l.Debug("selected slot", zap.String("tutor", tutor.Name), zap.Time("start_at", slot))
+	sendProgress(ctx, ProgressEvent{Type: ProgressSlotSelected, Tutor: &tutor, Slot: slot})
+
+	var reserve *Reserve
+	if selected == searched {
+		reserve, err = c.reserveSlot(ctx, src, typ, tutor.Name, ti, si, slot)
+	} else {
+		// the search result of the window of the slot has been replaced by the later windows
+		reserve, err = c.reserveNextSlot(ctx, src, typ, tutor.Name, slot)
 	}
-	if err := c.wd.Get(queryURL); err != nil {
-		return nil, fmt.Errorf("failed to get availabe tutor list: %w", err)
+	if err != nil || !req.Consecutive || reserve.DryRun {
+		return reserve, err
 	}
 
-	waitUntilElementLoaded(c.wd, selenium.ByCSSSelector, tutorListSelector)
-	c.saveCurrentScreenshot(rarejobctlTempDir, "tutor_list.png")
-	tutorList, err := c.wd.FindElements(selenium.ByCSSSelector, tutorListSelector)
+	// the site has no 50-minute lesson, so book the next slot of the same tutor, and cancel the first one if it fails
+	next := slot.Add(flow.interval)
+	second, err := c.reserveNextSlot(ctx, src, typ, tutor.Name, next)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get tutor info: %w", err)
+		// the second one often fails because ctx is done, which must not leave the first one reserved
+		rollbackCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+		defer cancel()
+		if _, cancelErr := c.cancel(rollbackCtx, slot, false); cancelErr != nil {
+			return nil, fmt.Errorf("failed to reserve the second slot, and the first slot at %s is still reserved since cancelling it failed: %w", slot.Format(time.DateTime), errors.Join(err, cancelErr))
+		}
+		return nil, fmt.Errorf("failed to reserve the second slot, the first slot was cancelled: %w", err)
 	}
+	reserve.EndAt = second.EndAt
+	return reserve, nil
+}
 
-	var tutors Tutors
-	// TODO(musaprg): parallelize with goroutine and use errgroup to aggregate error
-	for tnum := 1; tnum <= len(tutorList); tnum++ {
-		zap.L().Debug("getting tutor info", zap.Int("number", tnum), zap.String("url", c.getCurrentURL()))
-		nameElm, _ := c.wd.FindElement(selenium.ByCSSSelector, fmt.Sprintf(tutorNameSelector, tnum))
-		name, _ := nameElm.Text()
-		slotElms, err := c.wd.FindElements(selenium.ByCSSSelector, fmt.Sprintf(tutorTimeSlotSelector, tnum))
-		if err != nil {
-			return nil, fmt.Errorf("failed to get time slots for tutor #%d: %w", tnum, err)
+// reserveNextSlot finds the tutor again in src and reserves the slot starting at startAt.
+func (c *client) reserveNextSlot(ctx context.Context, src slotSource, typ ReservationType, name string, startAt time.Time) (*Reserve, error) {
+	tutors, err := src.tutors(ctx, startAt, reservationFlows[typ].duration)
+	if err != nil && !errors.Is(err, ErrNoTutorsAvailable) {
+		return nil, err
+	}
+	for ti, t := range tutors {
+		if t.Name != name {
+			continue
 		}
-		var slots []time.Time
-		for snum := 1; snum <= len(slotElms); snum++ {
-			slotElm, err := c.wd.FindElement(selenium.ByCSSSelector, fmt.Sprintf(tutorTimeSlotButtonSelector, tnum, snum))
-			if err != nil { // if err, fill zero time to preserve index
-				slots = append(slots, time.Time{})
+		for si, s := range t.AvailableSlots {
+			if s.Available() && s.StartAt.Equal(startAt) {
+				return c.reserveSlot(ctx, src, typ, name, ti, si, s.StartAt)
 			}
-			slotText, _ := slotElm.Text()
-			h, m, err := parseTime(slotText)
-			if err != nil {
-				slots = append(slots, time.Time{})
-			}
-			slots = append(slots, time.Date(from.Year(), from.Month(), from.Day(), h, m, 0, 0, time.Local))
 		}
-		tutors = append(tutors, Tutor{
-			Name:           name,
-			AvailableSlots: slots,
-		})
 	}
+	return nil, fmt.Errorf("%w: %s is no longer available at %s", ErrSlotTaken, name, startAt.Format(time.DateTime))
+}
 
-	zap.L().Info("found tutors", zap.Array("tutors", tutors))
+// reserveSlot clicks the si-th slot of the ti-th tutor (0-origin) in src, and confirms the reservation.
+func (c *client) reserveSlot(ctx context.Context, src slotSource, typ ReservationType, name string, ti, si int, slot time.Time) (*Reserve, error) {
+	flow := reservationFlows[typ]
+	phaseStarted(ctx, PhaseOpenSlot)
 
-	// -- Do reservation --
+	if err := src.openSlot(ctx, ti, si); err != nil {
+		return nil, err
+	}
 
-	timeSlotButtonSelector := fmt.Sprintf(tutorTimeSlotButtonSelector, 1, 1)
-	waitUntilElementLoaded(c.wd, selenium.ByCSSSelector, timeSlotButtonSelector)
-	c.saveCurrentScreenshot(rarejobctlTempDir, "tutor_reservation.png")
-	// TODO(musaprg): Implement to select tutor, not hard-coded
-	timeSlot, err := c.wd.FindElement(selenium.ByCSSSelector, timeSlotButtonSelector)
-	if err != nil {
-		return nil, fmt.Errorf("failed to find time slot button: %w", err)
+	reserve := &Reserve{
+		Type:    typ,
+		Name:    name,
+		StartAt: slot,
+		EndAt:   slot.Add(flow.duration),
 	}
-	{
-		text, _ := timeSlot.Text()
-		zap.L().Debug("found time slot button", zap.String("button_text", text))
+	page := newReservationConfirmationPage(c, typ)
+	_ = page.waitLoaded(ctx)
+
+	if c.dryRun {
+		c.l.Info("dry-run mode, skipping the confirmation of the reservation", zap.String("tutor", reserve.Name), zap.Time("start_at", reserve.StartAt))
+		reserve.DryRun = true
+		return reserve, nil
 	}
-	if err := timeSlot.Click(); err != nil {
-		return nil, fmt.Errorf("failed to click time slot button: %w", err)
+	phaseStarted(ctx, PhaseConfirm)
+	_, confirmSpan := startSpan(ctx, "confirm", attribute.String("tutor", name), attribute.String("start_at", slot.Format(time.RFC3339)))
+	sent, err := page.confirm(ctx)
+	if !sent {
+		c.l.Debug("failed to find reserve button", zap.Error(err), zap.String("url", c.getCurrentURL()))
+		// the reservation page doesn't offer the button once someone else has taken the slot
+		err = fmt.Errorf("%w: failed to click reserve button: %w", ErrSlotTaken, err)
+		endSpan(confirmSpan, err)
+		return nil, err
 	}
+	endSpan(confirmSpan, err)
 
-	zap.L().Debug("loading reservation page", zap.String("url", c.getCurrentURL()))
-	waitUntilElementLoaded(c.wd, selenium.ByLinkText, "予約する")
-	c.saveCurrentScreenshot(rarejobctlTempDir, "reservation_page.png")
-	zap.L().Debug("loaded reservation page", zap.String("url", c.getCurrentURL()))
-	reserveButton, err := c.wd.FindElement(selenium.ByLinkText, "予約する")
+	waitErr := err
 	if err != nil {
-		zap.L().Debug("failed to get reserve button", zap.Error(err), zap.String("url", c.getCurrentURL()))
-		return nil, fmt.Errorf("failed to get reserve button: %w", err)
+		c.l.Warn("failed to click reserve button, checking my page for the result", zap.Error(err))
+	} else {
+		c.l.Debug("waiting for completion of reservation")
+		phaseStarted(ctx, PhaseWaitConfirmation)
+		_, waitSpan := startSpan(ctx, "wait_confirmation")
+		waitErr = page.waitFinished(ctx)
+		endSpan(waitSpan, waitErr)
 	}
-	if err := reserveButton.Click(); err != nil {
-		return nil, fmt.Errorf("failed to click reserve button: %w", err)
+
+	// the finish page may time out even if the reservation is made, and the search result may be stale,
+	// so the reservation is told by my page
+	phaseStarted(ctx, PhaseVerify)
+	verified, err := c.verifyReservation(ctx, reserve)
+	switch {
+	case waitErr != nil && err != nil:
+		// neither the finish page nor my page tells the result, so retrying may reserve another slot
+		return nil, fmt.Errorf("%w: the result of the reservation is unknown: %w", ErrReservationUnverified, errors.Join(waitErr, err))
+	case waitErr != nil && verified == nil:
+		return nil, fmt.Errorf("%w: reservation was not completed: %w", ErrSlotTaken, waitErr)
+	case err != nil:
+		return nil, err
+	case verified == nil:
+		return nil, fmt.Errorf("%w: the reservation was completed but is not listed on my page", ErrReservationUnverified)
+	case waitErr != nil:
+		c.l.Warn("reservation is listed on my page though the finish page was not shown", zap.Error(waitErr))
 	}
+	c.l.Debug("reservation completed", zap.String("tutor", verified.Name), zap.Time("start_at", verified.StartAt))
 
-	zap.L().Debug("waiting for completion of reservation")
-	waitUntilURLChanged(c.wd, rarejobReservationFinishURL)
-	c.saveCurrentScreenshot(rarejobctlTempDir, "reservation_completed.png")
-	zap.L().Debug("reservation completed")
+	return verified, nil
+}
 
-	return &Reserve{
-		Name:    tutors[0].Name,
-		StartAt: tutors[0].AvailableSlots[0],
-		EndAt:   tutors[0].AvailableSlots[0].Add(25 * time.Minute),
-	}, nil
+// verifyReservation returns the reservation on my page starting at the start of expected, with the type and the end
+// of expected. It returns nil if no reservation starts then, and ErrReservationUnverified if another tutor is reserved.
+func (c *client) verifyReservation(ctx context.Context, expected *Reserve) (_ *Reserve, err error) {
+	_, span := startSpan(ctx, "verify", attribute.String("tutor", expected.Name), attribute.String("start_at", expected.StartAt.Format(time.RFC3339)))
+	defer func() { endSpan(span, err) }()
+
+	reservations, err := c.listReservations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to list reservations: %w", ErrReservationUnverified, err)
+	}
+	for _, r := range reservations {
+		if !r.StartAt.Equal(expected.StartAt) {
+			continue
+		}
+		if r.Name != expected.Name {
+			return nil, fmt.Errorf("%w: %s is reserved at %s instead of %s", ErrReservationUnverified, r.Name, r.StartAt.Format(time.DateTime), expected.Name)
+		}
+		r.Type, r.EndAt = expected.Type, expected.EndAt
+		return &r, nil
+	}
+	return nil, nil
+}
+
+// recordStep adds the current page to the recording if enabled. It must be called holding the lock of c.
+func (c *client) recordStep() {
+	if c.recorder != nil {
+		c.recorder.step()
+	}
 }
 
 func (c *client) Teardown() error {
-	defer zap.L().Sync()
+	defer c.l.Sync()
 
+	if c.recorder != nil {
+		path, err := c.recorder.Stop()
+		if err != nil {
+			c.l.Warn("failed to save recording", zap.Error(err))
+		} else if path != "" {
+			c.l.Info("saved recording of the session", zap.String("path", path))
+		}
+	}
+	// run every step even if the former fails, not to leave the browser, the selenium server or the proxy running
+	var errs []error
 	if c.wd != nil {
-		zap.L().Debug("quitting current webdriver session")
+		c.l.Debug("quitting current webdriver session")
 		if err := c.wd.Quit(); err != nil {
-			return fmt.Errorf("failed to quit current webdriver session: %w", err)
+			errs = append(errs, fmt.Errorf("failed to quit current webdriver session: %w", err))
 		}
 	}
 	if c.s != nil {
 		if err := c.s.Stop(); err != nil {
-			return fmt.Errorf("failed to quit current webdriver session: %w", err)
+			errs = append(errs, fmt.Errorf("failed to stop selenium server: %w", err))
 		}
 	}
-	return nil
+	if c.proxy != nil {
+		if err := c.proxy.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close proxy forwarder: %w", err))
+		}
+	}
+	return errors.Join(errs...)
 }
 
 func (c *client) flushConsoleLogs() {
-	defer zap.L().Sync()
+	defer c.l.Sync()
 
 	if c.browser != browserTypeChrome {
-		zap.L().Warn("console log is only available for chrome browser")
+		c.l.Warn("console log is only available for chrome browser")
 		return
 	}
 
 	// output console log
 	clog, err := c.wd.Log(log.Browser)
 	if err != nil {
-		zap.L().Warn("failed to get console log", zap.Error(err))
+		c.l.Warn("failed to get console log", zap.Error(err))
 	}
 	for _, l := range clog {
-		zap.L().Debug(l.Message, zap.Time("timestamp", l.Timestamp), zap.String("level", string(l.Level)))
+		c.l.Debug(l.Message, zap.Time("timestamp", l.Timestamp), zap.String("level", string(l.Level)))
 	}
 }
@@ -1,6 +1,7 @@
 package librarejob
 
 import (
+	"context"
 	"fmt"
 	"io/fs"
 	"io/ioutil"
@@ -9,40 +10,59 @@ import (
 	"strings"
 	"time"
 
-	"github.com/tebeka/selenium"
 	"go.uber.org/zap"
 )
 
+// waitUntil polls cond until it returns true, the wait times out, or ctx is done.
+func waitUntil(ctx context.Context, w WaitOpts, cond func() (bool, error)) error {
+	ctx, cancel := context.WithTimeout(ctx, w.Timeout)
+	defer cancel()
+
+	t := time.NewTicker(w.Interval)
+	defer t.Stop()
+	for {
+		ok, err := cond()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
 // TODO(musaprg): dirty logic, needs to be refactored
-func waitUntilElementLoaded(wd selenium.WebDriver, by, value string) error {
-	if waitErr := wd.WaitWithTimeoutAndInterval(func(wd selenium.WebDriver) (bool, error) {
-		elm, err := wd.FindElement(by, value)
-		zap.L().Debug("checking if the element has been loaded", zap.String("by", by), zap.String("value", value))
+func (c *client) waitUntilElementLoaded(ctx context.Context, w WaitOpts, by, value string) error {
+	return waitUntil(ctx, w, func() (bool, error) {
+		elm, err := c.wd.FindElement(by, value)
+		c.l.Debug("checking if the element has been loaded", zap.String("by", by), zap.String("value", value))
 		if err == nil {
 			text, _ := elm.Text()
-			zap.L().Debug("element has been loaded", zap.String("by", by), zap.String("value", value), zap.String("text", text), zap.Error(err))
+			c.l.Debug("element has been loaded", zap.String("by", by), zap.String("value", value), zap.String("text", text), zap.Error(err))
+			return true, nil
 		}
-		return err == nil, nil
-	}, defaultWaitTimeout, defaultWaitInterval); waitErr != nil {
-		return waitErr
-	}
-	return nil
+		// the site may switch to the maintenance page in the middle of the flow, e.g. after submitting a form
+		return false, c.maintenance()
+	})
 }
 
-func waitUntilURLChanged(wd selenium.WebDriver, url string) error {
-	var err error
-	u := ""
-	if waitErr := wd.WaitWithTimeoutAndInterval(func(wd selenium.WebDriver) (bool, error) {
-		u, err = wd.CurrentURL()
+func (c *client) waitUntilURLChanged(ctx context.Context, w WaitOpts, url string) error {
+	return waitUntil(ctx, w, func() (bool, error) {
+		u, err := c.wd.CurrentURL()
 		if err != nil {
 			return false, err
 		}
-		zap.L().Debug("checking if the url has been changed", zap.String("url", u))
-		return u == url, nil
-	}, defaultWaitTimeout, defaultWaitInterval); waitErr != nil {
-		return err
-	}
-	return nil
+		c.l.Debug("checking if the url has been changed", zap.String("url", u))
+		if strings.HasPrefix(u, c.url(url)) {
+			return true, nil
+		}
+		return false, c.maintenance()
+	})
 }
 
 func generateTutorSearchQuery(from, by time.Time) (string, error) {
@@ -58,7 +78,7 @@ func generateTutorSearchQuery(from, by time.Time) (string, error) {
 	onlyFilipinoTutor := 1
 	// TODO(musaprg): make this configurable via flag
 	characteristics := "4"
-	return fmt.Sprintf(rarejobTutorSearchURL, from.Local().Year(), from.Local().Month(), from.Local().Day(), s, e, int(onlyFilipinoTutor), characteristics), nil
+	return fmt.Sprintf(rarejobTutorSearchURL, from.Year(), from.Month(), from.Day(), s, e, int(onlyFilipinoTutor), characteristics), nil
 }
 
 func parseTime(s string) (h, m int, err error) {
@@ -75,27 +95,40 @@ func parseTime(s string) (h, m int, err error) {
 	return
 }
 
+// url replaces the origin of u with the base URL if configured.
+func (c *client) url(u string) string {
+	if c.baseURL == "" {
+		return u
+	}
+	return strings.TrimSuffix(c.baseURL, "/") + strings.TrimPrefix(u, rarejobBaseURL)
+}
+
+// isAt reports whether the current page is u or under it.
+func (c *client) isAt(u string) bool {
+	return strings.HasPrefix(c.getCurrentURL(), c.url(u))
+}
+
 func (c *client) getCurrentURL() string {
 	url, err := c.wd.CurrentURL()
 	if err != nil {
-		zap.L().Debug("current url is empty", zap.Error(err))
+		c.l.Debug("current url is empty", zap.Error(err))
 	}
 	return url
 }
 
 func (c *client) saveCurrentScreenshot(dirPath string, name string) error {
-	zap.L().Debug("saving screenshot", zap.String("dir_path", dirPath), zap.String("name", name))
+	c.l.Debug("saving screenshot", zap.String("dir_path", dirPath), zap.String("name", name))
 	if c.debug {
 		ss, err := c.wd.Screenshot()
 		if err != nil {
 			return fmt.Errorf("failed to take screenshot: %w", err)
 		}
-		zap.L().Debug("took screenshot", zap.String("dir_path", dirPath), zap.String("name", name))
+		c.l.Debug("took screenshot", zap.String("dir_path", dirPath), zap.String("name", name))
 		path := filepath.Join(dirPath, name)
 		if err := ioutil.WriteFile(path, ss, fs.FileMode(0644)); err != nil {
 			return fmt.Errorf("failed to write screenshot: %w", err)
 		}
-		zap.L().Debug("saved screenshot", zap.String("dir_path", dirPath), zap.String("name", name))
+		c.l.Debug("saved screenshot", zap.String("dir_path", dirPath), zap.String("name", name))
 	}
 	return nil
 }
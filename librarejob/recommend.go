@@ -0,0 +1,107 @@
+package librarejob
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// familiarityWeight is the score added per the log of the completed lessons with the tutor.
+	familiarityWeight = 1.0
+	// similarityWeight is the score added for the tutor whose specialties are the same as the favorite tutors.
+	similarityWeight = 1.0
+	// minFavoriteLessons is the number of the completed lessons to regard the tutor as a favorite.
+	minFavoriteLessons = 2
+)
+
+// Recommendation is a tutor recommended from the lesson history.
+type Recommendation struct {
+	Tutor Tutor   `json:"tutor"`
+	Score float64 `json:"score"`
+	// PastLessons is the number of the completed lessons with the tutor.
+	PastLessons int `json:"past_lessons"`
+	// Favorite is true if the tutor is one of the favorite tutors, who have taught at least a few lessons.
+	Favorite bool `json:"favorite"`
+}
+
+// RecommendTutors ranks the candidates with available slots by the rating, how many lessons were completed with them in history,
+// and how similar their specialties are to the favorite ones among the candidates. The specialties are compared only if
+// they are filled, e.g. by GetTutorProfile. The best one comes first.
+func RecommendTutors(candidates Tutors, history []Lesson) []Recommendation {
+	recs, _ := rankTutors(candidates, history)
+	return recs
+}
+
+// rankTutors returns the recommendations and the 0-origin indexes of the candidates they are for.
+func rankTutors(candidates Tutors, history []Lesson) ([]Recommendation, []int) {
+	past := map[string]int{}
+	for _, l := range history {
+		if l.Completed {
+			past[l.TutorName]++
+		}
+	}
+
+	favoriteSpecialties := map[string]bool{}
+	for _, t := range candidates {
+		if past[t.Name] >= minFavoriteLessons {
+			for _, s := range t.Specialties {
+				favoriteSpecialties[s] = true
+			}
+		}
+	}
+
+	var recs []Recommendation
+	var order []int
+	for i, t := range candidates {
+		if !hasAvailableSlot(t) {
+			continue
+		}
+		n := past[t.Name]
+		score := t.Rating + familiarityWeight*math.Log1p(float64(n)) + similarityWeight*similarity(t.Specialties, favoriteSpecialties)
+		recs = append(recs, Recommendation{Tutor: t, Score: score, PastLessons: n, Favorite: n >= minFavoriteLessons})
+		order = append(order, i)
+	}
+	sort.Stable(byScore{recs, order})
+	return recs, order
+}
+
+// byScore sorts the recommendations and their indexes together, the best first.
+type byScore struct {
+	recs  []Recommendation
+	order []int
+}
+
+func (s byScore) Len() int           { return len(s.recs) }
+func (s byScore) Less(i, j int) bool { return s.recs[i].Score > s.recs[j].Score }
+func (s byScore) Swap(i, j int) {
+	s.recs[i], s.recs[j] = s.recs[j], s.recs[i]
+	s.order[i], s.order[j] = s.order[j], s.order[i]
+}
+
+func hasAvailableSlot(t Tutor) bool {
+	for _, s := range t.AvailableSlots {
+		if s.Available() {
+			return true
+		}
+	}
+	return false
+}
+
+// similarity is the Jaccard index of the specialties and the favorite ones.
+func similarity(specialties []string, favorites map[string]bool) float64 {
+	if len(specialties) == 0 || len(favorites) == 0 {
+		return 0
+	}
+	union := map[string]bool{}
+	for s := range favorites {
+		union[s] = true
+	}
+	common := 0
+	for _, s := range specialties {
+		if favorites[s] {
+			common++
+		}
+		union[s] = true
+	}
+	return float64(common) / float64(len(union))
+}
@@ -0,0 +1,122 @@
+package librarejob
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRankTutors(t *testing.T) {
+	at := time.Date(2022, 12, 27, 21, 0, 0, 0, DefaultLocation)
+	tutor := func(name string, rating float64, specialties ...string) Tutor {
+		return Tutor{Name: name, Rating: rating, AvailableSlots: []Slot{AvailableSlot(at)}, Specialties: specialties}
+	}
+	lessons := func(name string, n int, completed bool) []Lesson {
+		ls := make([]Lesson, n)
+		for i := range ls {
+			ls[i] = Lesson{TutorName: name, Completed: completed}
+		}
+		return ls
+	}
+	tests := []struct {
+		name       string
+		candidates Tutors
+		history    []Lesson
+		wantNames  []string
+		wantOrder  []int
+	}{
+		{
+			"by rating without history",
+			Tutors{tutor("A", 4.5), tutor("B", 4.9), tutor("C", 4.7)},
+			nil,
+			[]string{"B", "C", "A"}, []int{1, 2, 0},
+		},
+		{
+			"the same rating in the order shown",
+			Tutors{tutor("A", 4.5), tutor("B", 4.5)},
+			nil,
+			[]string{"A", "B"}, []int{0, 1},
+		},
+		{
+			"familiar tutor first",
+			Tutors{tutor("A", 4.9), tutor("B", 4.5)},
+			lessons("B", 3, true),
+			[]string{"B", "A"}, []int{1, 0},
+		},
+		{
+			"absent lessons are not counted",
+			Tutors{tutor("A", 4.9), tutor("B", 4.5)},
+			lessons("B", 3, false),
+			[]string{"A", "B"}, []int{0, 1},
+		},
+		{
+			"similar to the favorite",
+			Tutors{tutor("A", 4.5, "business"), tutor("B", 4.5, "kids"), tutor("F", 3.0, "business")},
+			lessons("F", 2, true),
+			[]string{"A", "F", "B"}, []int{0, 2, 1},
+		},
+		{
+			"without available slots",
+			Tutors{{Name: "A", Rating: 5.0, AvailableSlots: []Slot{{Status: SlotUnparsed}}}, tutor("B", 4.5)},
+			nil,
+			[]string{"B"}, []int{1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recs, order := rankTutors(tt.candidates, tt.history)
+			var names []string
+			for _, r := range recs {
+				names = append(names, r.Tutor.Name)
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("rankTutors() = %v, want %v", names, tt.wantNames)
+			}
+			if !reflect.DeepEqual(order, tt.wantOrder) {
+				t.Errorf("rankTutors() order = %v, want %v", order, tt.wantOrder)
+			}
+		})
+	}
+}
+
+func TestRankTutorsScore(t *testing.T) {
+	at := time.Date(2022, 12, 27, 21, 0, 0, 0, DefaultLocation)
+	candidates := Tutors{{Name: "A", Rating: 4.5, AvailableSlots: []Slot{AvailableSlot(at)}}}
+	history := []Lesson{{TutorName: "A", Completed: true}, {TutorName: "A", Completed: true}, {TutorName: "A"}}
+	recs, _ := rankTutors(candidates, history)
+	if len(recs) != 1 {
+		t.Fatalf("rankTutors() = %v, want 1 recommendation", recs)
+	}
+	r := recs[0]
+	if want := 4.5 + familiarityWeight*math.Log1p(2); math.Abs(r.Score-want) > 1e-9 {
+		t.Errorf("Score = %v, want %v", r.Score, want)
+	}
+	if r.PastLessons != 2 || !r.Favorite {
+		t.Errorf("PastLessons, Favorite = %d, %v, want 2, true", r.PastLessons, r.Favorite)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	favorites := map[string]bool{"business": true, "kids": true}
+	tests := []struct {
+		name        string
+		specialties []string
+		favorites   map[string]bool
+		want        float64
+	}{
+		{"same", []string{"business", "kids"}, favorites, 1},
+		{"half", []string{"business"}, favorites, 0.5},
+		{"one in three", []string{"business", "travel"}, favorites, 1.0 / 3},
+		{"disjoint", []string{"travel"}, favorites, 0},
+		{"no specialties", nil, favorites, 0},
+		{"no favorites", []string{"business"}, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := similarity(tt.specialties, tt.favorites); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("similarity(%v) = %v, want %v", tt.specialties, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package librarejob
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tebeka/selenium"
+	"go.uber.org/zap"
+)
+
+// RecordOpts configures the recording of the browser session.
+type RecordOpts struct {
+	// Dir is the directory to save the recordings into. Recording is disabled if empty.
+	// The recordings are readable only by the user since they show the logged-in pages.
+	Dir string
+	// Interval is the minimum interval between screenshots, which are taken after the navigations and the clicks.
+	Interval time.Duration
+}
+
+// recorder takes screenshots between the steps of the session, and stitches them into an animated GIF when stopped.
+// It doesn't run in background but is called by the client holding its lock, as the WebDriver session is not safe for concurrent use.
+type recorder struct {
+	l        *zap.Logger
+	wd       selenium.WebDriver
+	dir      string
+	interval time.Duration
+
+	frames []frame
+}
+
+// frame is a screenshot saved by the recorder.
+type frame struct {
+	path string
+	at   time.Time
+}
+
+func startRecorder(l *zap.Logger, wd selenium.WebDriver, opts RecordOpts) (*recorder, error) {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = defaultRecordInterval
+	}
+	dir := filepath.Join(opts.Dir, time.Now().Format("20060102T150405"))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+
+	r := &recorder{
+		l:        l,
+		wd:       wd,
+		dir:      dir,
+		interval: interval,
+	}
+	l.Debug("started recording", zap.String("dir", dir), zap.Duration("interval", interval))
+	return r, nil
+}
+
+// step takes a screenshot unless the last one is taken within the interval.
+func (r *recorder) step() {
+	now := time.Now()
+	if len(r.frames) > 0 && now.Sub(r.frames[len(r.frames)-1].at) < r.interval {
+		return
+	}
+	ss, err := r.wd.Screenshot()
+	if err != nil {
+		r.l.Debug("failed to take screenshot for recording", zap.Error(err))
+		return
+	}
+	path := filepath.Join(r.dir, fmt.Sprintf("frame_%05d.png", len(r.frames)))
+	if err := os.WriteFile(path, ss, 0600); err != nil {
+		r.l.Debug("failed to write recording frame", zap.String("path", path), zap.Error(err))
+		return
+	}
+	r.frames = append(r.frames, frame{path: path, at: now})
+}
+
+// Stop stops the recording and returns the path of the stitched recording.
+// Each frame is shown as long as it was on the screen, until the next frame.
+func (r *recorder) Stop() (string, error) {
+	if len(r.frames) == 0 {
+		return "", nil
+	}
+
+	anim := &gif.GIF{}
+	for i, f := range r.frames {
+		b, err := os.ReadFile(f.path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read recording frame: %w", err)
+		}
+		img, err := png.Decode(bytes.NewReader(b))
+		if err != nil {
+			return "", fmt.Errorf("failed to decode recording frame %s: %w", f.path, err)
+		}
+		shown := r.interval
+		if i+1 < len(r.frames) {
+			shown = r.frames[i+1].at.Sub(f.at)
+		}
+		p := image.NewPaletted(img.Bounds(), palette.Plan9)
+		draw.FloydSteinberg.Draw(p, img.Bounds(), img, image.Point{})
+		anim.Image = append(anim.Image, p)
+		anim.Delay = append(anim.Delay, int(shown/(10*time.Millisecond)))
+	}
+
+	path := filepath.Join(r.dir, "recording.gif")
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create recording: %w", err)
+	}
+	defer out.Close()
+	if err := gif.EncodeAll(out, anim); err != nil {
+		return "", fmt.Errorf("failed to encode recording: %w", err)
+	}
+	return path, nil
+}
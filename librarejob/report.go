@@ -0,0 +1,96 @@
+package librarejob
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/tebeka/selenium"
+	"go.uber.org/zap"
+)
+
+// LessonReport is the report of a completed lesson written by the tutor.
+type LessonReport struct {
+	LessonID    string       `json:"lesson_id"`
+	TutorName   string       `json:"tutor_name"`
+	StartAt     time.Time    `json:"start_at"`
+	Material    string       `json:"material"`
+	Comment     string       `json:"comment"`
+	Corrections []Correction `json:"corrections"`
+}
+
+// Correction is a correction of what was said in the lesson.
+type Correction struct {
+	Original  string `json:"original"`
+	Corrected string `json:"corrected"`
+}
+
+// idFromURL extracts the id of the lesson or the tutor from the URL of its page.
+func idFromURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return ""
+	}
+	for _, k := range []string{"lessonId", "lesson_id", "tutorId", "tutor_id", "id"} {
+		if v := u.Query().Get(k); v != "" {
+			return v
+		}
+	}
+	return path.Base(strings.TrimSuffix(u.Path, "/"))
+}
+
+func (c *client) FetchLessonReport(ctx context.Context, lessonID string) (_ *LessonReport, err error) {
+	unlock, err := c.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	defer c.l.Sync()
+	defer c.flushConsoleLogs()
+	defer func() { err = c.captureFailure("fetch_lesson_report", err) }()
+
+	if lessonID == "" {
+		return nil, fmt.Errorf("%w: lesson id is empty", ErrInvalidOptions)
+	}
+
+	c.l.Debug("loading lesson report", zap.String("lesson_id", lessonID))
+	if err := c.get(ctx, fmt.Sprintf(rarejobLessonReportURL, url.PathEscape(lessonID))); err != nil {
+		return nil, fmt.Errorf("failed to get lesson report: %w", err)
+	}
+	if c.isAt(rarejobLoginURL) {
+		return nil, ErrSessionExpired
+	}
+	if err := c.waitUntilElementLoaded(ctx, c.waits.Default, selenium.ByCSSSelector, c.sel.LessonReportComment); err != nil {
+		return nil, fmt.Errorf("failed to load lesson report: %w", err)
+	}
+
+	report := &LessonReport{LessonID: lessonID}
+	report.TutorName, _ = c.elementText(ctx, selenium.ByCSSSelector, c.sel.LessonReportTutor)
+	report.Material, _ = c.elementText(ctx, selenium.ByCSSSelector, c.sel.LessonReportMaterial)
+	if report.Comment, err = c.elementText(ctx, selenium.ByCSSSelector, c.sel.LessonReportComment); err != nil {
+		return nil, fmt.Errorf("failed to get comment: %w", err)
+	}
+	if dateText, err := c.elementText(ctx, selenium.ByCSSSelector, c.sel.LessonReportDate); err == nil {
+		report.StartAt, _ = parseLessonDate(dateText, c.loc)
+	}
+
+	// not every lesson has corrections
+	items, _ := c.wd.FindElements(selenium.ByCSSSelector, c.sel.LessonReportCorrection)
+	for n := 1; n <= len(items); n++ {
+		original, _ := c.elementText(ctx, selenium.ByCSSSelector, fmt.Sprintf(c.sel.LessonReportCorrectionOriginal, n))
+		corrected, _ := c.elementText(ctx, selenium.ByCSSSelector, fmt.Sprintf(c.sel.LessonReportCorrectionCorrected, n))
+		report.Corrections = append(report.Corrections, Correction{
+			Original:  strings.TrimSpace(original),
+			Corrected: strings.TrimSpace(corrected),
+		})
+	}
+	report.TutorName = strings.TrimSpace(report.TutorName)
+	report.Material = strings.TrimSpace(report.Material)
+	report.Comment = strings.TrimSpace(report.Comment)
+
+	c.l.Info("fetched lesson report", zap.String("lesson_id", lessonID), zap.Int("corrections", len(report.Corrections)))
+	return report, nil
+}
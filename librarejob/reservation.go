@@ -0,0 +1,228 @@
+package librarejob
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ReservationType is the type of the session to reserve. Each type has its own search and reservation pages.
+type ReservationType string
+
+const (
+	// ReservationTypeLesson is the regular lesson with a tutor.
+	ReservationTypeLesson ReservationType = "lesson"
+	// ReservationTypeCounseling is the session with a Japanese counselor.
+	ReservationTypeCounseling ReservationType = "counseling"
+	// ReservationTypeSpeakingTest is the speaking test.
+	ReservationTypeSpeakingTest ReservationType = "speaking_test"
+)
+
+// ParseReservationType parses the name of the reservation type. The regular lesson is used if empty.
+func ParseReservationType(s string) (ReservationType, error) {
+	switch t := ReservationType(s); t {
+	case "":
+		return ReservationTypeLesson, nil
+	case ReservationTypeLesson, ReservationTypeCounseling, ReservationTypeSpeakingTest:
+		return t, nil
+	default:
+		return "", fmt.Errorf("%w: invalid reservation type: %s", ErrInvalidOptions, s)
+	}
+}
+
+// reservationFlow is the pages and the length of the session for each reservation type.
+type reservationFlow struct {
+	searchURL string
+	finishURL string
+	duration  time.Duration
+	// interval is the time between the starts of the slots next to each other.
+	interval time.Duration
+}
+
+var reservationFlows = map[ReservationType]reservationFlow{
+	ReservationTypeLesson: {
+		searchURL: rarejobTutorSearchURL,
+		finishURL: rarejobReservationFinishURL,
+		duration:  25 * time.Minute,
+		interval:  30 * time.Minute,
+	},
+	ReservationTypeCounseling: {
+		searchURL: rarejobCounselingSearchURL,
+		finishURL: rarejobCounselingFinishURL,
+		duration:  25 * time.Minute,
+		interval:  30 * time.Minute,
+	},
+	ReservationTypeSpeakingTest: {
+		searchURL: rarejobSpeakingTestSearchURL,
+		finishURL: rarejobSpeakingTestFinishURL,
+		duration:  15 * time.Minute,
+		interval:  30 * time.Minute,
+	},
+}
+
+// ReserveRequest describes what to reserve.
+type ReserveRequest struct {
+	// Type is the type of the session. The regular lesson is reserved if empty.
+	Type ReservationType
+	// From and Margin is the window to search the available slots in.
+	From   time.Time
+	Margin time.Duration
+	// Preference is the acceptable windows and the preferred slots to reserve the best slot instead of the first one
+	// in the window of From and Margin, which are ignored if Preference is set.
+	Preference *Preference
+	// Exact reserves only a slot starting exactly at From instead of the first one in the window, ignoring Margin,
+	// e.g. to follow the fixed times of an external calendar. It returns ErrSlotUnavailable if no tutor offers the slot.
+	Exact bool
+	// Busy is the time the slot must not overlap in addition to the existing reservations, e.g. from the calendar.
+	Busy []Interval
+	// Force reserves the slot even if it overlaps the existing reservations or Busy.
+	Force bool
+	// Replacing is the start time of the existing reservation the new one replaces, e.g. by rebooking,
+	// which is ignored as if it had been cancelled since it is kept in dry-run mode.
+	Replacing time.Time
+	// Consecutive reserves two slots in a row with the same tutor to emulate a 50-minute lesson.
+	// It succeeds only if both are reserved, and the returned Reserve spans both slots.
+	Consecutive bool
+	// Tutor restricts the reservation to the tutor whose ID or name is Tutor, e.g. the one picked from SearchTutors.
+	// Any tutor is reserved if empty.
+	Tutor string
+	// Schedule finds the slots on the schedule of the tutor whose ID is Tutor instead of the search result, which is
+	// faster and more precise to reserve a specific tutor. Only the regular lesson is supported.
+	Schedule bool
+	// History is the lesson history to try the tutors in the order of RecommendTutors instead of the search result.
+	// The search result order is used if empty.
+	History []Lesson
+}
+
+// withoutReplaced returns the reservations except the one starting at replacing. It returns reservations as is if replacing is zero.
+func withoutReplaced(reservations []Reserve, replacing time.Time) []Reserve {
+	if replacing.IsZero() {
+		return reservations
+	}
+	var rs []Reserve
+	for _, r := range reservations {
+		if !r.StartAt.Equal(replacing) {
+			rs = append(rs, r)
+		}
+	}
+	return rs
+}
+
+// Interval is a period of time from Start to End, excluding End.
+type Interval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Overlaps reports whether the interval overlaps the one from start to end.
+func (i Interval) Overlaps(start, end time.Time) bool {
+	return i.Start.Before(end) && start.Before(i.End)
+}
+
+// selectSlot returns the 0-origin indexes of the best available slot which doesn't overlap busy, and its rank by pref.
+// The slots not acceptable by pref are skipped. Among the slots of the same rank, the first one is selected trying
+// the tutors in the order of their indexes in order, or in the search result order if order is nil.
+// If consecutive, the slot must be followed by another available slot of the same tutor.
+// It returns ErrConflict if every available slot overlaps busy.
+func selectSlot(tutors Tutors, order []int, flow reservationFlow, consecutive bool, busy []Interval, pref Preference, loc *time.Location) (tutor, slot, rank int, err error) {
+	if order == nil {
+		order = make([]int, len(tutors))
+		for i := range order {
+			order[i] = i
+		}
+	}
+	var conflict *Interval
+	tutor, slot, rank = -1, -1, 0
+	for _, ti := range order {
+		t := tutors[ti]
+		for si, sl := range t.AvailableSlots {
+			if !sl.Available() {
+				continue
+			}
+			s := sl.StartAt
+			r, ok := pref.Rank(s, loc)
+			if !ok || tutor >= 0 && r >= rank {
+				continue
+			}
+			end := s.Add(flow.duration)
+			if consecutive {
+				if !hasSlot(t, s.Add(flow.interval)) {
+					continue
+				}
+				end = s.Add(flow.interval + flow.duration)
+			}
+			if b := overlapping(busy, s, end); b != nil {
+				conflict = b
+				continue
+			}
+			tutor, slot, rank = ti, si, r
+		}
+	}
+	if tutor >= 0 {
+		return tutor, slot, rank, nil
+	}
+	if conflict != nil {
+		return 0, 0, 0, fmt.Errorf("%w: every available slot overlaps the one from %s to %s", ErrConflict, conflict.Start.Format(time.DateTime), conflict.End.Format(time.DateTime))
+	}
+	return 0, 0, 0, ErrNoTutorsAvailable
+}
+
+// matchTutors returns the indexes in order of the tutors whose ID or name is tutor.
+// It returns order as is if tutor is empty.
+func matchTutors(tutors Tutors, order []int, tutor string) []int {
+	if tutor == "" {
+		return order
+	}
+	if order == nil {
+		order = make([]int, len(tutors))
+		for i := range order {
+			order[i] = i
+		}
+	}
+	matched := []int{}
+	for _, ti := range order {
+		if tutors[ti].ID == tutor || tutors[ti].Name == tutor {
+			matched = append(matched, ti)
+		}
+	}
+	return matched
+}
+
+func hasSlot(t Tutor, startAt time.Time) bool {
+	for _, s := range t.AvailableSlots {
+		if s.Available() && s.StartAt.Equal(startAt) {
+			return true
+		}
+	}
+	return false
+}
+
+func overlapping(busy []Interval, start, end time.Time) *Interval {
+	for i := range busy {
+		if busy[i].Overlaps(start, end) {
+			return &busy[i]
+		}
+	}
+	return nil
+}
+
+// generateSearchQuery returns the URL of the search result of the slots available between from and by,
+// which must be in the timezone of the site.
+func generateSearchQuery(typ ReservationType, from, by time.Time) (string, error) {
+	if typ == ReservationTypeLesson {
+		return generateTutorSearchQuery(from, by)
+	}
+	flow, ok := reservationFlows[typ]
+	if !ok {
+		return "", fmt.Errorf("%w: invalid reservation type: %s", ErrInvalidOptions, typ)
+	}
+	s, err := strconv.Atoi(from.Format("1504"))
+	if err != nil {
+		return "", err
+	}
+	e, err := strconv.Atoi(by.Format("1504"))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(flow.searchURL, from.Year(), from.Month(), from.Day(), s, e), nil
+}
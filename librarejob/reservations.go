@@ -0,0 +1,116 @@
+package librarejob
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tebeka/selenium"
+	"go.uber.org/zap"
+)
+
+// ListReservations returns the upcoming reservations shown on my page, earliest first.
+func (c *client) ListReservations(ctx context.Context) (_ []Reserve, err error) {
+	unlock, err := c.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	defer c.l.Sync()
+	defer c.flushConsoleLogs()
+	defer func() { err = c.captureFailure("list_reservations", err) }()
+
+	return c.listReservations(ctx)
+}
+
+func (c *client) listReservations(ctx context.Context) ([]Reserve, error) {
+	if err := c.get(ctx, rarejobMyPageURL); err != nil {
+		return nil, fmt.Errorf("failed to get my page: %w", err)
+	}
+	if c.isAt(rarejobLoginURL) {
+		return nil, ErrSessionExpired
+	}
+
+	items, err := c.findElements(ctx, selenium.ByCSSSelector, c.sel.ReservationItem)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reservations: %w", err)
+	}
+	reservations := make([]Reserve, 0, len(items))
+	for n := 1; n <= len(items); n++ {
+		dateText, err := c.elementText(ctx, selenium.ByCSSSelector, fmt.Sprintf(c.sel.ReservationDate, n))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get date of reservation #%d: %w", n, err)
+		}
+		startAt, err := parseLessonDate(dateText, c.loc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse date of reservation #%d: %w", n, err)
+		}
+		name, _ := c.elementText(ctx, selenium.ByCSSSelector, fmt.Sprintf(c.sel.ReservationTutor, n))
+		typ := ReservationTypeLesson
+		if label, err := c.elementText(ctx, selenium.ByCSSSelector, fmt.Sprintf(c.sel.ReservationType, n)); err == nil {
+			typ = c.sel.reservationTypeOf(label)
+		}
+		r := Reserve{
+			Type:    typ,
+			Name:    strings.TrimSpace(name),
+			StartAt: startAt,
+			EndAt:   startAt.Add(reservationFlows[typ].duration),
+		}
+		r.CancelDeadline = startAt.Add(-defaultCancelDeadline)
+		if text, err := c.elementText(ctx, selenium.ByCSSSelector, fmt.Sprintf(c.sel.ReservationCancelDeadline, n)); err == nil {
+			if d, err := parseLessonDate(text, c.loc); err == nil {
+				r.CancelDeadline = d
+			}
+		}
+		// the link to the lesson room is shown only for the lessons about to start
+		if link, err := c.wd.FindElement(selenium.ByCSSSelector, fmt.Sprintf(c.sel.ReservationRoomLink, n)); err == nil {
+			r.LessonRoomURL, _ = link.GetAttribute("href")
+		}
+		if link, err := c.wd.FindElement(selenium.ByCSSSelector, fmt.Sprintf(c.sel.ReservationMaterialLink, n)); err == nil {
+			r.MaterialURL, _ = link.GetAttribute("href")
+		}
+		reservations = append(reservations, r)
+	}
+
+	c.l.Debug("found reservations", zap.Int("count", len(reservations)))
+	return reservations, nil
+}
+
+// reservationTypeOf returns the type of the session labeled as label on my page.
+func (s Selectors) reservationTypeOf(label string) ReservationType {
+	switch {
+	case s.ReservationSpeakingTestText != "" && strings.Contains(label, s.ReservationSpeakingTestText):
+		return ReservationTypeSpeakingTest
+	case s.ReservationCounselingText != "" && strings.Contains(label, s.ReservationCounselingText):
+		return ReservationTypeCounseling
+	default:
+		return ReservationTypeLesson
+	}
+}
+
+func (c *client) LessonRoomURL(ctx context.Context, startAt time.Time) (_ string, err error) {
+	unlock, err := c.lock(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+	defer c.l.Sync()
+	defer c.flushConsoleLogs()
+	defer func() { err = c.captureFailure("lesson_room_url", err) }()
+
+	reservations, err := c.listReservations(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, r := range reservations {
+		if !startAt.IsZero() && !r.StartAt.Equal(startAt) {
+			continue
+		}
+		if r.LessonRoomURL == "" {
+			return "", fmt.Errorf("lesson room of the lesson at %s is not open yet", r.StartAt.Format(time.DateTime))
+		}
+		return r.LessonRoomURL, nil
+	}
+	return "", ErrReservationNotFound
+}
@@ -0,0 +1,171 @@
+package librarejob
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/tebeka/selenium"
+	"go.uber.org/zap"
+)
+
+// RetryOpts configures the retry of flaky webdriver operations such as element lookups and clicks.
+type RetryOpts struct {
+	// MaxAttempts is the max number of attempts including the first one.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry. It doubles on every retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between retries.
+	MaxBackoff time.Duration
+}
+
+// resolve fills the unset fields with the default values.
+func (o RetryOpts) resolve() RetryOpts {
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = defaultRetryMaxAttempts
+	}
+	if o.InitialBackoff <= 0 {
+		o.InitialBackoff = defaultRetryInitialBackoff
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = defaultRetryMaxBackoff
+	}
+	return o
+}
+
+// backoff returns the wait before the n-th retry (starting from 1) with jitter.
+func (o RetryOpts) backoff(n int) time.Duration {
+	d := o.InitialBackoff << (n - 1)
+	if d <= 0 || d > o.MaxBackoff {
+		d = o.MaxBackoff
+	}
+	// equal jitter: wait at least half of the backoff to keep it exponential
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// retry calls f until it succeeds, it returns a non-retryable error, the attempts are exhausted, or ctx is done.
+func retry[T any](ctx context.Context, l *zap.Logger, o RetryOpts, op string, f func() (T, error)) (T, error) {
+	var v T
+	var err error
+	for attempt := 1; ; attempt++ {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return v, ctxErr
+		}
+		v, err = f()
+		if err == nil || !isRetryable(err) || attempt >= o.MaxAttempts {
+			return v, err
+		}
+		d := o.backoff(attempt)
+		l.Warn("webdriver operation failed, retrying", zap.String("operation", op), zap.Int("attempt", attempt), zap.Duration("backoff", d), zap.Error(err))
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return v, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// isRetryable reports whether the error is likely transient, e.g. the element has been re-rendered
+// or the navigation was interrupted.
+func isRetryable(err error) bool {
+	var se *selenium.Error
+	if errors.As(err, &se) {
+		switch se.Err {
+		case "stale element reference", "no such element", "element click intercepted", "element not interactable", "timeout":
+			return true
+		case "unknown error":
+			// navigation errors are reported as unknown error by both geckodriver and chromedriver
+			return strings.Contains(se.Message, "net::ERR_") || strings.Contains(se.Message, "Reached error page")
+		}
+		// legacy wire protocol: 7 -> NoSuchElement, 10 -> StaleElementReference
+		return se.LegacyCode == 7 || se.LegacyCode == 10
+	}
+	var ne net.Error
+	return errors.As(err, &ne)
+}
+
+// get navigates to the url. The page load is bounded by the deadline of ctx if any.
+func (c *client) get(ctx context.Context, url string) error {
+	_, err := retry(ctx, c.l, c.retry, "get", func() (struct{}, error) {
+		if err := c.throttle.waitNavigation(ctx); err != nil {
+			return struct{}{}, err
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			if err := c.wd.SetPageLoadTimeout(time.Until(deadline)); err != nil {
+				c.l.Debug("failed to set page load timeout", zap.Error(err))
+			} else {
+				// the timeout is of the session, which outlives ctx and is shared by the later navigations
+				defer func() {
+					if err := c.wd.SetPageLoadTimeout(defaultPageLoadTimeout); err != nil {
+						c.l.Debug("failed to restore page load timeout", zap.Error(err))
+					}
+				}()
+			}
+		}
+		return struct{}{}, c.wd.Get(c.url(url))
+	})
+	if err != nil {
+		return err
+	}
+	c.recordStep()
+	// the maintenance page is shown in place of any page, which would fail the flow later with a selector error
+	return c.maintenance()
+}
+
+func (c *client) findElement(ctx context.Context, by, value string) (selenium.WebElement, error) {
+	return retry(ctx, c.l, c.retry, "find element "+value, func() (selenium.WebElement, error) {
+		if err := c.throttle.waitQuery(ctx); err != nil {
+			return nil, err
+		}
+		return c.wd.FindElement(by, value)
+	})
+}
+
+func (c *client) findElements(ctx context.Context, by, value string) ([]selenium.WebElement, error) {
+	return retry(ctx, c.l, c.retry, "find elements "+value, func() ([]selenium.WebElement, error) {
+		if err := c.throttle.waitQuery(ctx); err != nil {
+			return nil, err
+		}
+		return c.wd.FindElements(by, value)
+	})
+}
+
+// elementText finds the element and returns its text. The lookup is retried together with reading
+// the text since the element may become stale in between.
+func (c *client) elementText(ctx context.Context, by, value string) (string, error) {
+	return retry(ctx, c.l, c.retry, "get text of "+value, func() (string, error) {
+		if err := c.throttle.waitQuery(ctx); err != nil {
+			return "", err
+		}
+		elm, err := c.wd.FindElement(by, value)
+		if err != nil {
+			return "", err
+		}
+		return elm.Text()
+	})
+}
+
+// clickElement finds the element and clicks it. The lookup is retried together with the click
+// since the element may become stale in between. Thus it must not be used for the clicks which must not be
+// repeated, e.g. confirming a reservation, which confirmationPage.confirm clicks exactly once.
+func (c *client) clickElement(ctx context.Context, by, value string) error {
+	_, err := retry(ctx, c.l, c.retry, "click "+value, func() (struct{}, error) {
+		if err := c.throttle.waitQuery(ctx); err != nil {
+			return struct{}{}, err
+		}
+		elm, err := c.wd.FindElement(by, value)
+		if err != nil {
+			return struct{}{}, err
+		}
+		return struct{}{}, elm.Click()
+	})
+	if err == nil {
+		c.recordStep()
+	}
+	return err
+}
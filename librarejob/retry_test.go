@@ -0,0 +1,116 @@
+package librarejob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/tebeka/selenium"
+	"go.uber.org/zap"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"stale element", &selenium.Error{Err: "stale element reference"}, true},
+		{"no such element", &selenium.Error{Err: "no such element"}, true},
+		{"click intercepted", &selenium.Error{Err: "element click intercepted"}, true},
+		{"not interactable", &selenium.Error{Err: "element not interactable"}, true},
+		{"timeout", &selenium.Error{Err: "timeout"}, true},
+		{"wrapped", fmt.Errorf("failed to click: %w", &selenium.Error{Err: "stale element reference"}), true},
+		{"chromedriver navigation error", &selenium.Error{Err: "unknown error", Message: "net::ERR_CONNECTION_RESET"}, true},
+		{"geckodriver navigation error", &selenium.Error{Err: "unknown error", Message: "Reached error page: about:neterror"}, true},
+		{"other unknown error", &selenium.Error{Err: "unknown error", Message: "cannot determine loading status"}, false},
+		{"legacy no such element", &selenium.Error{LegacyCode: 7}, true},
+		{"legacy stale element", &selenium.Error{LegacyCode: 10}, true},
+		{"invalid session", &selenium.Error{Err: "invalid session id"}, false},
+		{"network error", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"error of the client", ErrSlotTaken, false},
+		{"cancelled", context.Canceled, false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.err); got != tt.want {
+				t.Errorf("isRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	o := RetryOpts{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	transient := &selenium.Error{Err: "stale element reference"}
+	tests := []struct {
+		name         string
+		errs         []error
+		wantErr      error
+		wantAttempts int
+	}{
+		{"succeeds at once", []error{nil}, nil, 1},
+		{"succeeds after transient errors", []error{transient, transient, nil}, nil, 3},
+		{"exhausts the attempts", []error{transient, transient, transient, nil}, transient, 3},
+		{"stops on non-retryable error", []error{ErrSlotTaken, nil}, ErrSlotTaken, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			_, err := retry(context.Background(), zap.NewNop(), o, "test", func() (struct{}, error) {
+				err := tt.errs[attempts]
+				attempts++
+				return struct{}{}, err
+			})
+			if !errors.Is(err, tt.wantErr) || tt.wantErr == nil && err != nil {
+				t.Errorf("retry() = %v, want %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("retry() attempted %d times, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	o := RetryOpts{MaxAttempts: 3, InitialBackoff: time.Hour, MaxBackoff: time.Hour}
+	attempts := 0
+	_, err := retry(ctx, zap.NewNop(), o, "test", func() (struct{}, error) {
+		attempts++
+		cancel()
+		return struct{}{}, &selenium.Error{Err: "stale element reference"}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("retry() = %v, want context.Canceled", err)
+	}
+	if attempts != 1 {
+		t.Errorf("retry() attempted %d times, want 1", attempts)
+	}
+}
+
+func TestRetryOptsBackoff(t *testing.T) {
+	o := RetryOpts{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	tests := []struct {
+		n    int
+		want time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{100, time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.n), func(t *testing.T) {
+			// the jitter keeps the backoff in [d/2, d]
+			if got := o.backoff(tt.n); got < tt.want/2 || got > tt.want {
+				t.Errorf("backoff(%d) = %s, want in [%s, %s]", tt.n, got, tt.want/2, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package librarejob
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/tebeka/selenium"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// TutorSchedule returns the tutor with the open slots on the date from the schedule on the tutor detail page,
+// which is cheaper than the search to watch a specific tutor.
+func (c *client) TutorSchedule(ctx context.Context, id string, date time.Time) (_ *Tutor, err error) {
+	unlock, err := c.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	defer c.l.Sync()
+	defer c.flushConsoleLogs()
+	defer func() { err = c.captureFailure("tutor_schedule", err) }()
+
+	page := tutorSchedulePage{c: c, id: id, date: date.In(c.loc)}
+	if err := page.open(ctx); err != nil {
+		return nil, err
+	}
+	t, err := page.tutor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slots := []Slot{}
+	for _, s := range t.AvailableSlots {
+		if s.Available() {
+			slots = append(slots, s)
+		}
+	}
+	t.AvailableSlots = slots
+	return &t, nil
+}
+
+// tutorSchedulePage is the schedule of the day on the tutor detail page, which lists the open slots of the tutor.
+type tutorSchedulePage struct {
+	c    *client
+	id   string
+	date time.Time
+}
+
+// open opens the schedule and waits for it to be shown.
+func (p tutorSchedulePage) open(ctx context.Context) error {
+	c := p.c
+	if p.id == "" {
+		return fmt.Errorf("%w: tutor id is empty", ErrInvalidOptions)
+	}
+	if err := c.get(ctx, fmt.Sprintf(rarejobTutorScheduleURL, url.PathEscape(p.id), p.date.Year(), p.date.Month(), p.date.Day())); err != nil {
+		return fmt.Errorf("failed to get tutor schedule: %w", err)
+	}
+	if c.isAt(rarejobLoginURL) {
+		return ErrSessionExpired
+	}
+	if err := c.waitUntilElementLoaded(ctx, c.waits.Search, selenium.ByCSSSelector, c.sel.TutorSchedule); err != nil {
+		return fmt.Errorf("failed to load tutor schedule: %w", err)
+	}
+	c.saveCurrentScreenshot(rarejobctlTempDir, "tutor_schedule.png")
+	return nil
+}
+
+// tutor reads the name and the open slots of the tutor. The slots failed to parse are left unparsed to preserve the index.
+func (p tutorSchedulePage) tutor(ctx context.Context) (Tutor, error) {
+	c := p.c
+	t := Tutor{ID: p.id}
+	name, err := c.elementText(ctx, selenium.ByCSSSelector, c.sel.TutorProfileName)
+	if err != nil {
+		return Tutor{}, fmt.Errorf("failed to get tutor name: %w", err)
+	}
+	t.Name = strings.TrimSpace(name)
+
+	slotElms, err := c.findElements(ctx, selenium.ByCSSSelector, c.sel.TutorScheduleSlot)
+	if err != nil {
+		return Tutor{}, fmt.Errorf("failed to get time slots of tutor %s: %w", p.id, err)
+	}
+	t.AvailableSlots = make([]Slot, len(slotElms))
+	for snum := 1; snum <= len(slotElms); snum++ {
+		t.AvailableSlots[snum-1] = Slot{Status: SlotUnparsed}
+		text, err := c.elementText(ctx, selenium.ByCSSSelector, fmt.Sprintf(c.sel.TutorScheduleSlotButton, snum))
+		if err != nil {
+			continue
+		}
+		t.AvailableSlots[snum-1].Text = text
+		h, m, err := parseTime(text)
+		if err != nil {
+			continue
+		}
+		t.AvailableSlots[snum-1] = AvailableSlot(time.Date(p.date.Year(), p.date.Month(), p.date.Day(), h, m, 0, 0, p.date.Location()))
+	}
+	c.l.Debug("read tutor schedule", zap.Object("tutor", t), zap.Int("slots", len(slotElms)))
+	return t, nil
+}
+
+// openSlot clicks the si-th slot (0-origin), which opens the confirmation page.
+func (p tutorSchedulePage) openSlot(ctx context.Context, si int) error {
+	c := p.c
+	selector := fmt.Sprintf(c.sel.TutorScheduleSlotButton, si+1)
+	c.waitUntilElementLoaded(ctx, c.waits.Reservation, selenium.ByCSSSelector, selector)
+	if err := c.clickElement(ctx, selenium.ByCSSSelector, selector); err != nil {
+		return fmt.Errorf("failed to click time slot button: %w", err)
+	}
+	return nil
+}
+
+// slotSource lists the available slots and opens the one to reserve, which is either the search result
+// or the schedule of a tutor.
+type slotSource interface {
+	// tutors opens the page of the slots in the window of from and margin, and returns the tutors with the slots.
+	tutors(ctx context.Context, from time.Time, margin time.Duration) (Tutors, error)
+	// openSlot opens the confirmation page of the si-th slot of the ti-th tutor (0-origin) of the last tutors.
+	openSlot(ctx context.Context, ti, si int) error
+}
+
+// searchSource is the search result of the tutors of the reservation type.
+type searchSource struct {
+	c   *client
+	typ ReservationType
+}
+
+func (s searchSource) tutors(ctx context.Context, from time.Time, margin time.Duration) (Tutors, error) {
+	return s.c.searchTutors(ctx, s.typ, from, margin)
+}
+
+func (s searchSource) openSlot(ctx context.Context, ti, si int) error {
+	return tutorSearchPage{c: s.c, typ: s.typ}.openSlot(ctx, ti, si)
+}
+
+// scheduleSource is the schedule of the tutor with the id, which has only the regular lessons.
+type scheduleSource struct {
+	c  *client
+	id string
+}
+
+// tutors returns the tutor with the slots in the window. The other slots are left unavailable to preserve the index.
+func (s scheduleSource) tutors(ctx context.Context, from time.Time, margin time.Duration) (_ Tutors, err error) {
+	c := s.c
+	ctx, span := startSpan(ctx, "schedule",
+		attribute.String("tutor", s.id),
+		attribute.String("from", from.Format(time.RFC3339)),
+		attribute.String("margin", margin.String()),
+	)
+	defer func() { endSpan(span, err) }()
+	phaseStarted(ctx, PhaseSearch)
+
+	from = from.In(c.loc)
+	by := from.Add(margin)
+	if !(margin < 24*time.Hour && from.Hour() <= by.Hour()) {
+		return nil, ErrSpreadAcrossTwoDays
+	}
+	page := tutorSchedulePage{c: c, id: s.id, date: from}
+	if err := page.open(ctx); err != nil {
+		return nil, err
+	}
+	t, err := page.tutor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for i, slot := range t.AvailableSlots {
+		if !slot.Available() {
+			continue
+		}
+		if slot.StartAt.Before(from) || slot.StartAt.After(by) {
+			t.AvailableSlots[i].Status = SlotOutOfWindow
+		} else {
+			found = true
+		}
+	}
+	if !found {
+		return nil, &NoTutorsAvailableError{From: from, To: by}
+	}
+	sendProgress(ctx, ProgressEvent{Type: ProgressTutorFound, Tutor: &t})
+	return Tutors{t}, nil
+}
+
+func (s scheduleSource) openSlot(ctx context.Context, ti, si int) error {
+	return tutorSchedulePage{c: s.c, id: s.id}.openSlot(ctx, si)
+}
@@ -0,0 +1,140 @@
+package librarejob
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Selectors is the set of CSS selectors (and link texts) to find the elements on the RareJob pages.
+// The selectors containing %d are formatted with the 1-origin index of the tutor (and the slot).
+type Selectors struct {
+	LoginEmail    string `json:"login_email" yaml:"login_email"`
+	LoginPassword string `json:"login_password" yaml:"login_password"`
+	LoginForm     string `json:"login_form" yaml:"login_form"`
+	LoginSubmit   string `json:"login_submit" yaml:"login_submit"`
+	// LoginError is the error message shown on the login page when the login is rejected.
+	LoginError string `json:"login_error" yaml:"login_error"`
+	// LoginLockedText is the text in the error message when the account is locked.
+	LoginLockedText string `json:"login_locked_text" yaml:"login_locked_text"`
+	// Maintenance matches the message of the site maintenance, which can be shown in place of any page.
+	Maintenance string `json:"maintenance" yaml:"maintenance"`
+	// HumanVerification matches the CAPTCHA or the form of the additional verification shown during login.
+	HumanVerification string `json:"human_verification" yaml:"human_verification"`
+
+	TutorList           string `json:"tutor_list" yaml:"tutor_list"`
+	TutorNoResult       string `json:"tutor_no_result" yaml:"tutor_no_result"`
+	TutorListItem       string `json:"tutor_list_item" yaml:"tutor_list_item"`
+	TutorTimeSlot       string `json:"tutor_time_slot" yaml:"tutor_time_slot"`
+	TutorName           string `json:"tutor_name" yaml:"tutor_name"`
+	TutorRating         string `json:"tutor_rating" yaml:"tutor_rating"`
+	TutorTimeSlotButton string `json:"tutor_time_slot_button" yaml:"tutor_time_slot_button"`
+	TutorReserveButton  string `json:"tutor_reserve_button" yaml:"tutor_reserve_button"`
+	// TutorLink is the link to the tutor detail page, which has the tutor id in its URL.
+	TutorLink string `json:"tutor_link" yaml:"tutor_link"`
+
+	TutorProfileName         string `json:"tutor_profile_name" yaml:"tutor_profile_name"`
+	TutorProfileRating       string `json:"tutor_profile_rating" yaml:"tutor_profile_rating"`
+	TutorProfileTotalLessons string `json:"tutor_profile_total_lessons" yaml:"tutor_profile_total_lessons"`
+	TutorProfileIntroduction string `json:"tutor_profile_introduction" yaml:"tutor_profile_introduction"`
+	// TutorProfileSpecialty matches each of the specialties.
+	TutorProfileSpecialty string `json:"tutor_profile_specialty" yaml:"tutor_profile_specialty"`
+	// TutorProfileVideo is the iframe or video element of the introduction video.
+	TutorProfileVideo string `json:"tutor_profile_video" yaml:"tutor_profile_video"`
+	// TutorSchedule is the schedule of the day on the tutor detail page, and TutorScheduleSlot matches each of its open slots.
+	TutorSchedule           string `json:"tutor_schedule" yaml:"tutor_schedule"`
+	TutorScheduleSlot       string `json:"tutor_schedule_slot" yaml:"tutor_schedule_slot"`
+	TutorScheduleSlotButton string `json:"tutor_schedule_slot_button" yaml:"tutor_schedule_slot_button"`
+
+	ReservationConfirmLinkText string `json:"reservation_confirm_link_text" yaml:"reservation_confirm_link_text"`
+
+	LessonHistoryItem     string `json:"lesson_history_item" yaml:"lesson_history_item"`
+	LessonHistoryDate     string `json:"lesson_history_date" yaml:"lesson_history_date"`
+	LessonHistoryTutor    string `json:"lesson_history_tutor" yaml:"lesson_history_tutor"`
+	LessonHistoryMaterial string `json:"lesson_history_material" yaml:"lesson_history_material"`
+	LessonHistoryStatus   string `json:"lesson_history_status" yaml:"lesson_history_status"`
+	// LessonHistoryReportLink is the link to the lesson report, which has the lesson id in its URL.
+	LessonHistoryReportLink string `json:"lesson_history_report_link" yaml:"lesson_history_report_link"`
+	// LessonHistoryCompletedText is the status text of the lessons which have been completed.
+	LessonHistoryCompletedText string `json:"lesson_history_completed_text" yaml:"lesson_history_completed_text"`
+
+	LessonReportTutor               string `json:"lesson_report_tutor" yaml:"lesson_report_tutor"`
+	LessonReportDate                string `json:"lesson_report_date" yaml:"lesson_report_date"`
+	LessonReportMaterial            string `json:"lesson_report_material" yaml:"lesson_report_material"`
+	LessonReportComment             string `json:"lesson_report_comment" yaml:"lesson_report_comment"`
+	LessonReportCorrection          string `json:"lesson_report_correction" yaml:"lesson_report_correction"`
+	LessonReportCorrectionOriginal  string `json:"lesson_report_correction_original" yaml:"lesson_report_correction_original"`
+	LessonReportCorrectionCorrected string `json:"lesson_report_correction_corrected" yaml:"lesson_report_correction_corrected"`
+
+	AccountPlan             string `json:"account_plan" yaml:"account_plan"`
+	AccountRemainingLessons string `json:"account_remaining_lessons" yaml:"account_remaining_lessons"`
+	AccountTicket           string `json:"account_ticket" yaml:"account_ticket"`
+	AccountTicketName       string `json:"account_ticket_name" yaml:"account_ticket_name"`
+	AccountTicketCount      string `json:"account_ticket_count" yaml:"account_ticket_count"`
+	AccountTicketExpiry     string `json:"account_ticket_expiry" yaml:"account_ticket_expiry"`
+	// AccountName, AccountEmail and AccountTimezone are the profile of the account, which are left empty if not found.
+	AccountName     string `json:"account_name" yaml:"account_name"`
+	AccountEmail    string `json:"account_email" yaml:"account_email"`
+	AccountTimezone string `json:"account_timezone" yaml:"account_timezone"`
+
+	ReservationItem     string `json:"reservation_item" yaml:"reservation_item"`
+	ReservationDate     string `json:"reservation_date" yaml:"reservation_date"`
+	ReservationTutor    string `json:"reservation_tutor" yaml:"reservation_tutor"`
+	ReservationRoomLink string `json:"reservation_room_link" yaml:"reservation_room_link"`
+	// ReservationType is the label of the type of the session, which is compared with ReservationCounselingText and
+	// ReservationSpeakingTestText. The regular lesson is assumed if not found or neither of them.
+	ReservationType             string `json:"reservation_type" yaml:"reservation_type"`
+	ReservationCounselingText   string `json:"reservation_counseling_text" yaml:"reservation_counseling_text"`
+	ReservationSpeakingTestText string `json:"reservation_speaking_test_text" yaml:"reservation_speaking_test_text"`
+	// ReservationCancelDeadline is the deadline of the free cancellation. The default deadline is assumed if not found.
+	ReservationCancelDeadline string `json:"reservation_cancel_deadline" yaml:"reservation_cancel_deadline"`
+	ReservationCancelButton   string `json:"reservation_cancel_button" yaml:"reservation_cancel_button"`
+	CancelConfirmLinkText     string `json:"cancel_confirm_link_text" yaml:"cancel_confirm_link_text"`
+	// ReservationMaterialLink is the link to the material assigned to the lesson, which is not shown if no material is assigned.
+	ReservationMaterialLink string `json:"reservation_material_link" yaml:"reservation_material_link"`
+
+	MaterialTitle string `json:"material_title" yaml:"material_title"`
+	// MaterialBlock matches the headings, the paragraphs and the list items of the material in the order shown.
+	MaterialBlock string `json:"material_block" yaml:"material_block"`
+}
+
+//go:embed selectors.json
+var defaultSelectors []byte
+
+// DefaultSelectors returns the selectors shipped with the binary.
+func DefaultSelectors() Selectors {
+	var s Selectors
+	if err := json.Unmarshal(defaultSelectors, &s); err != nil {
+		panic(fmt.Sprintf("invalid embedded selectors: %v", err))
+	}
+	return s
+}
+
+// LoadSelectors reads the selectors from the JSON or YAML file, which is chosen by the extension.
+// The selectors missing in the file are filled with the default ones, so the file only needs to have the overrides.
+func LoadSelectors(path string) (Selectors, error) {
+	s := DefaultSelectors()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return s, fmt.Errorf("failed to read selectors: %w", err)
+	}
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &s)
+	default:
+		err = json.Unmarshal(b, &s)
+	}
+	if err != nil {
+		return s, fmt.Errorf("%w: failed to parse selectors: %w", ErrInvalidOptions, err)
+	}
+	return s, nil
+}
+
+// searchResult matches either the tutor list or the message saying no tutor is found.
+func (s Selectors) searchResult() string {
+	return s.TutorList + ", " + s.TutorNoResult
+}
@@ -0,0 +1,91 @@
+package librarejob
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tebeka/selenium"
+)
+
+// Cookie is a cookie of the logged-in session, which is exported to resume the session in another browser
+// without the password. The value is the session id, so keep it as secret as the password.
+type Cookie struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Path   string `json:"path,omitempty"`
+	Domain string `json:"domain,omitempty"`
+	Secure bool   `json:"secure,omitempty"`
+	// Expiry is the expiry in seconds since the epoch, or zero for the session cookie.
+	Expiry uint `json:"expiry,omitempty"`
+}
+
+// ExportSession returns the cookies of the current session, which can be passed to ResumeSession of another client.
+func (c *client) ExportSession(ctx context.Context) ([]Cookie, error) {
+	unlock, err := c.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	cookies, err := c.wd.GetCookies()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cookies: %w", err)
+	}
+	exported := make([]Cookie, 0, len(cookies))
+	for _, ck := range cookies {
+		exported = append(exported, Cookie{
+			Name:   ck.Name,
+			Value:  ck.Value,
+			Path:   ck.Path,
+			Domain: ck.Domain,
+			Secure: ck.Secure,
+			Expiry: ck.Expiry,
+		})
+	}
+	return exported, nil
+}
+
+// ResumeSession adds the cookies exported by ExportSession to the browser, and opens my page to see if the session is
+// still logged in. It returns ErrSessionExpired if the site asks to log in again, when the caller should Login instead.
+func (c *client) ResumeSession(ctx context.Context, cookies []Cookie) (err error) {
+	unlock, err := c.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	defer c.l.Sync()
+	defer func(start time.Time) {
+		c.audit.record("resume_session", start, map[string]interface{}{"cookies": len(cookies)}, "", nil, err)
+	}(time.Now())
+
+	// the cookies can only be added on a page of the site
+	if err := c.get(ctx, rarejobMyPageURL); err != nil {
+		return fmt.Errorf("failed to get my page: %w", err)
+	}
+	now := uint(time.Now().Unix())
+	for _, ck := range cookies {
+		if ck.Expiry != 0 && ck.Expiry <= now {
+			continue
+		}
+		if err := c.wd.AddCookie(&selenium.Cookie{
+			Name:   ck.Name,
+			Value:  ck.Value,
+			Path:   ck.Path,
+			Domain: ck.Domain,
+			Secure: ck.Secure,
+			Expiry: ck.Expiry,
+		}); err != nil {
+			return fmt.Errorf("failed to add cookie %s: %w", ck.Name, err)
+		}
+	}
+
+	if err := c.get(ctx, rarejobMyPageURL); err != nil {
+		return fmt.Errorf("failed to get my page: %w", err)
+	}
+	if c.isAt(rarejobLoginURL) {
+		return ErrSessionExpired
+	}
+	c.applyDetectedLocale(ctx)
+	return nil
+}
@@ -0,0 +1,68 @@
+package librarejob
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ThrottleOpts configures the throttle of the requests to RareJob, so that polling the site frequently
+// doesn't hammer it or get the account flagged. Nothing is throttled by default.
+type ThrottleOpts struct {
+	// NavigationInterval is the min interval between page navigations.
+	NavigationInterval time.Duration
+	// QueryInterval is the min interval between element lookups, clicks and scripts.
+	QueryInterval time.Duration
+	// Limiter caps the rate of every navigation and element query in addition to the intervals.
+	// Share it between the clients to cap the rate of the whole process, e.g. the gRPC server.
+	Limiter *rate.Limiter
+}
+
+// throttle waits before the webdriver operations as configured by ThrottleOpts.
+type throttle struct {
+	navigation *rate.Limiter
+	query      *rate.Limiter
+	global     *rate.Limiter
+}
+
+func newThrottle(o ThrottleOpts) *throttle {
+	return &throttle{
+		navigation: intervalLimiter(o.NavigationInterval),
+		query:      intervalLimiter(o.QueryInterval),
+		global:     o.Limiter,
+	}
+}
+
+// intervalLimiter returns the limiter which allows an event every d, or nil if d is not positive.
+func intervalLimiter(d time.Duration) *rate.Limiter {
+	if d <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Every(d), 1)
+}
+
+// waitNavigation blocks until the next page navigation is allowed or ctx is done.
+func (t *throttle) waitNavigation(ctx context.Context) error {
+	return t.wait(ctx, t.navigation)
+}
+
+// waitQuery blocks until the next element query is allowed or ctx is done.
+func (t *throttle) waitQuery(ctx context.Context) error {
+	return t.wait(ctx, t.query)
+}
+
+func (t *throttle) wait(ctx context.Context, l *rate.Limiter) error {
+	if t == nil {
+		return nil
+	}
+	if l != nil {
+		if err := l.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	if t.global != nil {
+		return t.global.Wait(ctx)
+	}
+	return nil
+}
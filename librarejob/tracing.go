@@ -0,0 +1,27 @@
+package librarejob
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer traces the phases of the browser flow. The spans are not recorded unless the embedding application
+// sets the tracer provider with otel.SetTracerProvider.
+var tracer = otel.Tracer("github.com/musaprg/rarejobctl/librarejob")
+
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan ends span, marking it failed with the error code if err is not nil.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, ErrorCode(err))
+	}
+	span.End()
+}
@@ -0,0 +1,44 @@
+package librarejob
+
+import "time"
+
+// WaitOpts configures how long and how often the client polls for a page condition.
+type WaitOpts struct {
+	// Timeout is the maximum duration to wait for the condition.
+	Timeout time.Duration
+	// Interval is the duration between checks of the condition.
+	Interval time.Duration
+}
+
+// WaitConfig holds the wait settings for each operation. Zero values fall back to Default,
+// and then to the library defaults.
+type WaitConfig struct {
+	Default WaitOpts
+	// Login is used while loading the login page and waiting for the login to complete.
+	Login WaitOpts
+	// Search is used while loading the tutor search result.
+	Search WaitOpts
+	// Reservation is used while loading the reservation page and waiting for its confirmation.
+	Reservation WaitOpts
+}
+
+// resolve fills the unset fields with the default values.
+func (c WaitConfig) resolve() WaitConfig {
+	def := c.Default.withFallback(WaitOpts{Timeout: defaultWaitTimeout, Interval: defaultWaitInterval})
+	return WaitConfig{
+		Default:     def,
+		Login:       c.Login.withFallback(def),
+		Search:      c.Search.withFallback(def),
+		Reservation: c.Reservation.withFallback(def),
+	}
+}
+
+func (o WaitOpts) withFallback(fallback WaitOpts) WaitOpts {
+	if o.Timeout <= 0 {
+		o.Timeout = fallback.Timeout
+	}
+	if o.Interval <= 0 {
+		o.Interval = fallback.Interval
+	}
+	return o
+}
@@ -0,0 +1,29 @@
+package notify
+
+import (
+	"context"
+	"os"
+
+	"github.com/disgoorg/disgo/rest"
+	"github.com/disgoorg/disgo/webhook"
+)
+
+func init() {
+	Register("discord", newDiscord)
+}
+
+// newDiscord posts to DISCORD_WEBHOOK_URL via the incoming webhook.
+func newDiscord() (Notifier, error) {
+	u := os.Getenv("DISCORD_WEBHOOK_URL")
+	if u == "" {
+		return nil, nil
+	}
+	c, err := webhook.NewWithURL(u)
+	if err != nil {
+		return nil, err
+	}
+	return Text(SenderFunc(func(ctx context.Context, text string) error {
+		_, err := c.CreateContent(text, rest.WithCtx(ctx))
+		return err
+	})), nil
+}
@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register("email", newEmail)
+}
+
+// newEmail sends the mails from NOTIFY_EMAIL_FROM to the comma-separated NOTIFY_EMAIL_TO via SMTP_HOST:SMTP_PORT,
+// authenticated with SMTP_USERNAME and SMTP_PASSWORD if given.
+func newEmail() (Notifier, error) {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return nil, nil
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	from := os.Getenv("NOTIFY_EMAIL_FROM")
+	var to []string
+	for _, s := range strings.Split(os.Getenv("NOTIFY_EMAIL_TO"), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			to = append(to, s)
+		}
+	}
+	if from == "" || len(to) == 0 {
+		return nil, fmt.Errorf("NOTIFY_EMAIL_FROM and NOTIFY_EMAIL_TO are required with SMTP_HOST")
+	}
+	var auth smtp.Auth
+	if u := os.Getenv("SMTP_USERNAME"); u != "" {
+		auth = smtp.PlainAuth("", u, os.Getenv("SMTP_PASSWORD"), host)
+	}
+	addr := net.JoinHostPort(host, port)
+
+	return Text(SenderFunc(func(ctx context.Context, text string) error {
+		subject, _, _ := strings.Cut(text, "\n")
+		msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: [rarejobctl] %s\r\nDate: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
+			from, strings.Join(to, ", "), subject, time.Now().Format(time.RFC1123Z), strings.ReplaceAll(text, "\n", "\r\n"))
+		// net/smtp doesn't take a context, so the mail is sent even if ctx is done
+		return smtp.SendMail(addr, auth, from, to, []byte(msg))
+	})), nil
+}
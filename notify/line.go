@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const lineNotifyURL = "https://notify-api.line.me/api/notify"
+
+func init() {
+	Register("line", newLINE)
+}
+
+// newLINE posts via LINE Notify with the access token LINE_NOTIFY_TOKEN.
+func newLINE() (Notifier, error) {
+	token := os.Getenv("LINE_NOTIFY_TOKEN")
+	if token == "" {
+		return nil, nil
+	}
+	return Text(SenderFunc(func(ctx context.Context, text string) error {
+		form := url.Values{"message": {text}}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, lineNotifyURL, strings.NewReader(form.Encode()))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Authorization", "Bearer "+token)
+		return do(req)
+	})), nil
+}
+
+// do sends the request and fails unless the response is 2xx.
+func do(req *http.Request) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status from %s: %s", req.URL.Host, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+// Package notify tells the user what rarejobctl has done through the chat services, email or webhooks.
+//
+// The notifiers are registered by name with Register, and every notifier configured in the environment
+// is notified through the Multi returned by Configured. Register your own notifier in an init function
+// to add another service.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+)
+
+// Notifier is notified of the events of rarejobctl.
+type Notifier interface {
+	// OnReserved is called when a lesson is reserved.
+	OnReserved(ctx context.Context, r *librarejob.Reserve) error
+	// OnFailed is called when a reservation or a rebooking fails.
+	OnFailed(ctx context.Context, f Failure) error
+	// OnCancelled is called when a reservation is cancelled.
+	OnCancelled(ctx context.Context, r *librarejob.Reserve) error
+	// OnReminder is called when something needs the attention of the user, e.g. the lesson is about to start.
+	OnReminder(ctx context.Context, r Reminder) error
+}
+
+// Failure describes the failed operation.
+type Failure struct {
+	// Action is the failed operation, i.e. reserve or rebook.
+	Action string `json:"action"`
+	Err    error  `json:"-"`
+	// Cancelled is the reservation cancelled before the failure, e.g. the old lesson of the rebooking.
+	Cancelled *librarejob.Reserve `json:"cancelled,omitempty"`
+}
+
+// Reminder is a notice which needs the attention of the user.
+type Reminder struct {
+	// Kind identifies the reminder, e.g. lesson_start or tickets_expiring.
+	Kind string `json:"kind"`
+	// Message is the human-readable text of the reminder.
+	Message string `json:"message"`
+	// URL is the page to open for the reminder if any, e.g. the lesson room.
+	URL string `json:"url,omitempty"`
+	// Material is the material of the lesson attached to the reminder if any, to preview it before the lesson.
+	Material *librarejob.Material `json:"material,omitempty"`
+}
+
+// Factory creates the notifier from the environment. It returns nil without an error if the notifier is not configured.
+type Factory func() (Notifier, error)
+
+var (
+	mu        sync.Mutex
+	factories = map[string]Factory{}
+)
+
+// Register adds the factory of the notifier with the name. It panics if the name is already registered.
+func Register(name string, f Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := factories[name]; ok {
+		panic(fmt.Sprintf("notify: notifier %s is already registered", name))
+	}
+	factories[name] = f
+}
+
+// Configured returns the notifiers configured in the environment in the order of their names.
+// It returns the notifiers created so far with the errors of the others.
+func Configured() (Multi, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var m Multi
+	var errs []error
+	for _, name := range names {
+		n, err := factories[name]()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to configure notifier %s: %w", name, err))
+			continue
+		}
+		if n != nil {
+			m = append(m, n)
+		}
+	}
+	return m, errors.Join(errs...)
+}
+
+// Multi notifies every notifier in order. The errors of the notifiers don't stop notifying the rest.
+type Multi []Notifier
+
+func (m Multi) OnReserved(ctx context.Context, r *librarejob.Reserve) error {
+	return m.each(func(n Notifier) error { return n.OnReserved(ctx, r) })
+}
+
+func (m Multi) OnFailed(ctx context.Context, f Failure) error {
+	return m.each(func(n Notifier) error { return n.OnFailed(ctx, f) })
+}
+
+func (m Multi) OnCancelled(ctx context.Context, r *librarejob.Reserve) error {
+	return m.each(func(n Notifier) error { return n.OnCancelled(ctx, r) })
+}
+
+func (m Multi) OnReminder(ctx context.Context, r Reminder) error {
+	return m.each(func(n Notifier) error { return n.OnReminder(ctx, r) })
+}
+
+func (m Multi) each(f func(Notifier) error) error {
+	var errs []error
+	for _, n := range m {
+		errs = append(errs, f(n))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,30 @@
+package notify
+
+import (
+	"context"
+	"os"
+
+	"github.com/slack-go/slack"
+)
+
+func init() {
+	Register("slack", newSlack)
+}
+
+// newSlack posts to SLACK_CHANNEL via the Slack API with SLACK_API_TOKEN, or to SLACK_WEBHOOK_URL via the incoming webhook.
+func newSlack() (Notifier, error) {
+	if token := os.Getenv("SLACK_API_TOKEN"); token != "" {
+		api := slack.New(token)
+		channel := os.Getenv("SLACK_CHANNEL")
+		return Text(SenderFunc(func(ctx context.Context, text string) error {
+			_, _, err := api.PostMessageContext(ctx, channel, slack.MsgOptionText(text, false), slack.MsgOptionAsUser(true))
+			return err
+		})), nil
+	}
+	if u := os.Getenv("SLACK_WEBHOOK_URL"); u != "" {
+		return Text(SenderFunc(func(ctx context.Context, text string) error {
+			return slack.PostWebhookContext(ctx, u, &slack.WebhookMessage{Text: text})
+		})), nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+)
+
+// Sender posts a plain-text message, e.g. to a chat.
+type Sender interface {
+	Send(ctx context.Context, text string) error
+}
+
+// SenderFunc is the function implementing Sender.
+type SenderFunc func(ctx context.Context, text string) error
+
+func (f SenderFunc) Send(ctx context.Context, text string) error {
+	return f(ctx, text)
+}
+
+// Text returns the notifier which renders the events into the messages for humans and posts them with s.
+func Text(s Sender) Notifier {
+	return textNotifier{s: s}
+}
+
+type textNotifier struct {
+	s Sender
+}
+
+func (n textNotifier) OnReserved(ctx context.Context, r *librarejob.Reserve) error {
+	return n.s.Send(ctx, ReservedText(r))
+}
+
+func (n textNotifier) OnFailed(ctx context.Context, f Failure) error {
+	return n.s.Send(ctx, FailedText(f))
+}
+
+func (n textNotifier) OnCancelled(ctx context.Context, r *librarejob.Reserve) error {
+	return n.s.Send(ctx, CancelledText(r))
+}
+
+func (n textNotifier) OnReminder(ctx context.Context, r Reminder) error {
+	return n.s.Send(ctx, ReminderText(r))
+}
+
+// ReservedText renders the message of the reservation.
+func ReservedText(r *librarejob.Reserve) string {
+	return fmt.Sprintf(`Reservation completed! Enjoy your EIKAIWA lesson yay.
+
+Tutor Name: %s
+Start: %s
+End: %s
+`, r.Name, r.StartAt, r.EndAt)
+}
+
+// FailedText renders the message of the failure.
+func FailedText(f Failure) string {
+	switch {
+	case f.Cancelled != nil:
+		return fmt.Sprintf("rebooking failed! the lesson with %s at %s has been CANCELLED, but no new lesson was booked. book one by yourself.", f.Cancelled.Name, f.Cancelled.StartAt)
+	case errors.Is(f.Err, librarejob.ErrNoTutorsAvailable):
+		return fmt.Sprintf("no tutors were available... (%s)", f.Err)
+	default:
+		return "something went wrong... I failed to reserve your tutor. try again later."
+	}
+}
+
+// materialPreviewBlocks is the number of the blocks of the material shown in the reminder.
+const materialPreviewBlocks = 3
+
+// ReminderText renders the message of the reminder with the preview of the material if attached.
+func ReminderText(r Reminder) string {
+	if r.Material == nil {
+		return r.Message
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\nMaterial: %s\n%s\n", r.Message, r.Material.Title, r.Material.URL)
+	for i, bl := range r.Material.Blocks {
+		if i == materialPreviewBlocks {
+			b.WriteString("\n...")
+			break
+		}
+		fmt.Fprintf(&b, "\n%s", bl.Text)
+	}
+	return b.String()
+}
+
+// CancelledText renders the message of the cancellation.
+func CancelledText(r *librarejob.Reserve) string {
+	return fmt.Sprintf("Reservation cancelled.\n\nTutor Name: %s\nStart: %s\nEnd: %s\n", r.Name, r.StartAt, r.EndAt)
+}
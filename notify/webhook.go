@@ -0,0 +1,78 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+)
+
+func init() {
+	Register("webhook", newWebhook)
+}
+
+// Event is the JSON body posted by the webhook notifier.
+type Event struct {
+	// Type is reserved, failed, cancelled or reminder.
+	Type        string              `json:"type"`
+	Time        time.Time           `json:"time"`
+	Reservation *librarejob.Reserve `json:"reservation,omitempty"`
+	Failure     *Failure            `json:"failure,omitempty"`
+	// Error and ErrorCode are set for the failure.
+	Error     string    `json:"error,omitempty"`
+	ErrorCode string    `json:"error_code,omitempty"`
+	Reminder  *Reminder `json:"reminder,omitempty"`
+	// Text is the message rendered for humans.
+	Text string `json:"text"`
+}
+
+// newWebhook posts the events as JSON to NOTIFY_WEBHOOK_URL.
+func newWebhook() (Notifier, error) {
+	u := os.Getenv("NOTIFY_WEBHOOK_URL")
+	if u == "" {
+		return nil, nil
+	}
+	return webhookNotifier{url: u}, nil
+}
+
+type webhookNotifier struct {
+	url string
+}
+
+func (n webhookNotifier) OnReserved(ctx context.Context, r *librarejob.Reserve) error {
+	return n.post(ctx, Event{Type: "reserved", Reservation: r, Text: ReservedText(r)})
+}
+
+func (n webhookNotifier) OnFailed(ctx context.Context, f Failure) error {
+	e := Event{Type: "failed", Failure: &f, Text: FailedText(f)}
+	if f.Err != nil {
+		e.Error, e.ErrorCode = f.Err.Error(), librarejob.ErrorCode(f.Err)
+	}
+	return n.post(ctx, e)
+}
+
+func (n webhookNotifier) OnCancelled(ctx context.Context, r *librarejob.Reserve) error {
+	return n.post(ctx, Event{Type: "cancelled", Reservation: r, Text: CancelledText(r)})
+}
+
+func (n webhookNotifier) OnReminder(ctx context.Context, r Reminder) error {
+	return n.post(ctx, Event{Type: "reminder", Reminder: &r, Text: ReminderText(r)})
+}
+
+func (n webhookNotifier) post(ctx context.Context, e Event) error {
+	e.Time = time.Now()
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return do(req)
+}
@@ -0,0 +1,4 @@
+// Package rarejobpb is the gRPC service definition of the rarejob client, which is served by rarejobctl serve.
+package rarejobpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative rarejob.proto
@@ -0,0 +1,297 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.25.1
+// source: rarejob.proto
+
+package rarejobpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Rarejob_Login_FullMethodName            = "/rarejobctl.v1.Rarejob/Login"
+	Rarejob_SearchTutors_FullMethodName     = "/rarejobctl.v1.Rarejob/SearchTutors"
+	Rarejob_Reserve_FullMethodName          = "/rarejobctl.v1.Rarejob/Reserve"
+	Rarejob_Cancel_FullMethodName           = "/rarejobctl.v1.Rarejob/Cancel"
+	Rarejob_ListReservations_FullMethodName = "/rarejobctl.v1.Rarejob/ListReservations"
+)
+
+// RarejobClient is the client API for Rarejob service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RarejobClient interface {
+	// Login logs in to rarejob again with the credentials of the server. The credentials in the request must be empty,
+	// since the sessions are shared by all the callers.
+	Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error)
+	// SearchTutors lists the tutors available in the window.
+	SearchTutors(ctx context.Context, in *SearchTutorsRequest, opts ...grpc.CallOption) (*SearchTutorsResponse, error)
+	// Reserve reserves the first available slot, streaming the progress until the reservation is sent last.
+	Reserve(ctx context.Context, in *ReserveRequest, opts ...grpc.CallOption) (Rarejob_ReserveClient, error)
+	// Cancel cancels the reservation.
+	Cancel(ctx context.Context, in *CancelRequest, opts ...grpc.CallOption) (*CancelResponse, error)
+	// ListReservations lists the upcoming reservations, earliest first.
+	ListReservations(ctx context.Context, in *ListReservationsRequest, opts ...grpc.CallOption) (*ListReservationsResponse, error)
+}
+
+type rarejobClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRarejobClient(cc grpc.ClientConnInterface) RarejobClient {
+	return &rarejobClient{cc}
+}
+
+func (c *rarejobClient) Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error) {
+	out := new(LoginResponse)
+	err := c.cc.Invoke(ctx, Rarejob_Login_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rarejobClient) SearchTutors(ctx context.Context, in *SearchTutorsRequest, opts ...grpc.CallOption) (*SearchTutorsResponse, error) {
+	out := new(SearchTutorsResponse)
+	err := c.cc.Invoke(ctx, Rarejob_SearchTutors_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rarejobClient) Reserve(ctx context.Context, in *ReserveRequest, opts ...grpc.CallOption) (Rarejob_ReserveClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Rarejob_ServiceDesc.Streams[0], Rarejob_Reserve_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &rarejobReserveClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Rarejob_ReserveClient interface {
+	Recv() (*ReserveEvent, error)
+	grpc.ClientStream
+}
+
+type rarejobReserveClient struct {
+	grpc.ClientStream
+}
+
+func (x *rarejobReserveClient) Recv() (*ReserveEvent, error) {
+	m := new(ReserveEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *rarejobClient) Cancel(ctx context.Context, in *CancelRequest, opts ...grpc.CallOption) (*CancelResponse, error) {
+	out := new(CancelResponse)
+	err := c.cc.Invoke(ctx, Rarejob_Cancel_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rarejobClient) ListReservations(ctx context.Context, in *ListReservationsRequest, opts ...grpc.CallOption) (*ListReservationsResponse, error) {
+	out := new(ListReservationsResponse)
+	err := c.cc.Invoke(ctx, Rarejob_ListReservations_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RarejobServer is the server API for Rarejob service.
+// All implementations must embed UnimplementedRarejobServer
+// for forward compatibility
+type RarejobServer interface {
+	// Login logs in to rarejob again with the credentials of the server. The credentials in the request must be empty,
+	// since the sessions are shared by all the callers.
+	Login(context.Context, *LoginRequest) (*LoginResponse, error)
+	// SearchTutors lists the tutors available in the window.
+	SearchTutors(context.Context, *SearchTutorsRequest) (*SearchTutorsResponse, error)
+	// Reserve reserves the first available slot, streaming the progress until the reservation is sent last.
+	Reserve(*ReserveRequest, Rarejob_ReserveServer) error
+	// Cancel cancels the reservation.
+	Cancel(context.Context, *CancelRequest) (*CancelResponse, error)
+	// ListReservations lists the upcoming reservations, earliest first.
+	ListReservations(context.Context, *ListReservationsRequest) (*ListReservationsResponse, error)
+	mustEmbedUnimplementedRarejobServer()
+}
+
+// UnimplementedRarejobServer must be embedded to have forward compatible implementations.
+type UnimplementedRarejobServer struct {
+}
+
+func (UnimplementedRarejobServer) Login(context.Context, *LoginRequest) (*LoginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
+}
+func (UnimplementedRarejobServer) SearchTutors(context.Context, *SearchTutorsRequest) (*SearchTutorsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchTutors not implemented")
+}
+func (UnimplementedRarejobServer) Reserve(*ReserveRequest, Rarejob_ReserveServer) error {
+	return status.Errorf(codes.Unimplemented, "method Reserve not implemented")
+}
+func (UnimplementedRarejobServer) Cancel(context.Context, *CancelRequest) (*CancelResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Cancel not implemented")
+}
+func (UnimplementedRarejobServer) ListReservations(context.Context, *ListReservationsRequest) (*ListReservationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListReservations not implemented")
+}
+func (UnimplementedRarejobServer) mustEmbedUnimplementedRarejobServer() {}
+
+// UnsafeRarejobServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RarejobServer will
+// result in compilation errors.
+type UnsafeRarejobServer interface {
+	mustEmbedUnimplementedRarejobServer()
+}
+
+func RegisterRarejobServer(s grpc.ServiceRegistrar, srv RarejobServer) {
+	s.RegisterService(&Rarejob_ServiceDesc, srv)
+}
+
+func _Rarejob_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RarejobServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Rarejob_Login_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RarejobServer).Login(ctx, req.(*LoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Rarejob_SearchTutors_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchTutorsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RarejobServer).SearchTutors(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Rarejob_SearchTutors_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RarejobServer).SearchTutors(ctx, req.(*SearchTutorsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Rarejob_Reserve_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReserveRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RarejobServer).Reserve(m, &rarejobReserveServer{stream})
+}
+
+type Rarejob_ReserveServer interface {
+	Send(*ReserveEvent) error
+	grpc.ServerStream
+}
+
+type rarejobReserveServer struct {
+	grpc.ServerStream
+}
+
+func (x *rarejobReserveServer) Send(m *ReserveEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Rarejob_Cancel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RarejobServer).Cancel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Rarejob_Cancel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RarejobServer).Cancel(ctx, req.(*CancelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Rarejob_ListReservations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListReservationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RarejobServer).ListReservations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Rarejob_ListReservations_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RarejobServer).ListReservations(ctx, req.(*ListReservationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Rarejob_ServiceDesc is the grpc.ServiceDesc for Rarejob service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Rarejob_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "rarejobctl.v1.Rarejob",
+	HandlerType: (*RarejobServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Login",
+			Handler:    _Rarejob_Login_Handler,
+		},
+		{
+			MethodName: "SearchTutors",
+			Handler:    _Rarejob_SearchTutors_Handler,
+		},
+		{
+			MethodName: "Cancel",
+			Handler:    _Rarejob_Cancel_Handler,
+		},
+		{
+			MethodName: "ListReservations",
+			Handler:    _Rarejob_ListReservations_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Reserve",
+			Handler:       _Rarejob_Reserve_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "rarejob.proto",
+}
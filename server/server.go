@@ -0,0 +1,220 @@
+// Package server serves the rarejob client over gRPC.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/musaprg/rarejobctl/librarejob"
+	"github.com/musaprg/rarejobctl/rarejobpb"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server implements rarejobpb.RarejobServer with the browser sessions in a pool.
+type Server struct {
+	rarejobpb.UnimplementedRarejobServer
+
+	// pool serves a browser session to each call. The calls wait for an idle session if all of them are in use.
+	pool     *librarejob.Pool
+	email    string
+	password string
+}
+
+// New returns the server calling the clients in pool, which are logged in with email and password.
+func New(pool *librarejob.Pool, email, password string) *Server {
+	return &Server{pool: pool, email: email, password: password}
+}
+
+// Login logs the session in again with the credentials of the server. The credentials in the request are rejected,
+// since the sessions are shared by all the callers and must not be switched to another account.
+func (s *Server) Login(ctx context.Context, req *rarejobpb.LoginRequest) (*rarejobpb.LoginResponse, error) {
+	if req.GetEmail() != "" || req.GetPassword() != "" {
+		return nil, status.Error(codes.InvalidArgument, "the credentials in the request are not accepted, the server logs in with its own")
+	}
+	rc, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	err = rc.Login(ctx, s.email, s.password)
+	s.pool.Release(rc, err)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &rarejobpb.LoginResponse{}, nil
+}
+
+func (s *Server) SearchTutors(ctx context.Context, req *rarejobpb.SearchTutorsRequest) (*rarejobpb.SearchTutorsResponse, error) {
+	rc, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	tutors, err := rc.SearchTutors(ctx, req.GetFrom().AsTime().Local(), req.GetMargin().AsDuration())
+	s.pool.Release(rc, err)
+	if err != nil && !errors.Is(err, librarejob.ErrNoTutorsAvailable) {
+		return nil, toStatus(err)
+	}
+	res := &rarejobpb.SearchTutorsResponse{}
+	for _, t := range tutors {
+		pt := &rarejobpb.Tutor{Id: t.ID, Name: t.Name, Rating: t.Rating}
+		for _, slot := range t.OpenSlots() {
+			pt.AvailableSlots = append(pt.AvailableSlots, timestamppb.New(slot))
+		}
+		res.Tutors = append(res.Tutors, pt)
+	}
+	return res, nil
+}
+
+func (s *Server) Reserve(req *rarejobpb.ReserveRequest, stream rarejobpb.Rarejob_ReserveServer) error {
+	ctx := stream.Context()
+	typ, err := librarejob.ParseReservationType(req.GetType())
+	if err != nil {
+		return toStatus(err)
+	}
+	r := librarejob.ReserveRequest{
+		Type:        typ,
+		From:        req.GetFrom().AsTime().Local(),
+		Margin:      req.GetMargin().AsDuration(),
+		Force:       req.GetForce(),
+		Consecutive: req.GetConsecutive(),
+	}
+	for _, b := range req.GetBusy() {
+		r.Busy = append(r.Busy, librarejob.Interval{Start: b.GetStart().AsTime().Local(), End: b.GetEnd().AsTime().Local()})
+	}
+
+	if err := sendProgress(stream, "wait", "waiting for an idle browser session"); err != nil {
+		return err
+	}
+	rc, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return toStatus(err)
+	}
+	ctx = librarejob.WithProgress(ctx, func(ev librarejob.ProgressEvent) {
+		stage, message := describeProgress(ev)
+		if err := sendProgress(stream, stage, message); err != nil {
+			zap.L().Warn("failed to send progress", zap.Error(err))
+		}
+	})
+	reserve, err := rc.Reserve(ctx, r)
+	s.pool.Release(rc, err)
+	if err != nil {
+		return toStatus(err)
+	}
+	return stream.Send(&rarejobpb.ReserveEvent{Event: &rarejobpb.ReserveEvent_Reservation{Reservation: toReservation(reserve)}})
+}
+
+func (s *Server) Cancel(ctx context.Context, req *rarejobpb.CancelRequest) (*rarejobpb.CancelResponse, error) {
+	rc, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	r, err := rc.Cancel(ctx, req.GetStartAt().AsTime().Local(), req.GetForce())
+	s.pool.Release(rc, err)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &rarejobpb.CancelResponse{Reservation: toReservation(r)}, nil
+}
+
+func (s *Server) ListReservations(ctx context.Context, req *rarejobpb.ListReservationsRequest) (*rarejobpb.ListReservationsResponse, error) {
+	rc, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	reservations, err := rc.ListReservations(ctx)
+	s.pool.Release(rc, err)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	res := &rarejobpb.ListReservationsResponse{}
+	for i := range reservations {
+		res.Reservations = append(res.Reservations, toReservation(&reservations[i]))
+	}
+	return res, nil
+}
+
+func sendProgress(stream rarejobpb.Rarejob_ReserveServer, stage, message string) error {
+	return stream.Send(&rarejobpb.ReserveEvent{Event: &rarejobpb.ReserveEvent_Progress{Progress: &rarejobpb.Progress{Stage: stage, Message: message}}})
+}
+
+// describeProgress returns the stage and the message of the progress. The stage is the phase for the phases,
+// otherwise the type of the event.
+func describeProgress(ev librarejob.ProgressEvent) (string, string) {
+	switch ev.Type {
+	case librarejob.ProgressPhaseStarted:
+		return ev.Phase, fmt.Sprintf("%s started", ev.Phase)
+	case librarejob.ProgressTutorFound:
+		return string(ev.Type), fmt.Sprintf("found tutor %s (%.2f)", ev.Tutor.Name, ev.Tutor.Rating)
+	case librarejob.ProgressSlotSelected:
+		return string(ev.Type), fmt.Sprintf("selected tutor %s at %s", ev.Tutor.Name, ev.Slot.Format(time.DateTime))
+	case librarejob.ProgressReserved:
+		return string(ev.Type), fmt.Sprintf("reserved tutor %s at %s", ev.Reserve.Name, ev.Reserve.StartAt.Format(time.DateTime))
+	case librarejob.ProgressFailed:
+		return string(ev.Type), ev.Err.Error()
+	default:
+		return string(ev.Type), ""
+	}
+}
+
+func toReservation(r *librarejob.Reserve) *rarejobpb.Reservation {
+	return &rarejobpb.Reservation{
+		Type:           string(r.Type),
+		Name:           r.Name,
+		StartAt:        toTimestamp(r.StartAt),
+		EndAt:          toTimestamp(r.EndAt),
+		DryRun:         r.DryRun,
+		AlreadyExists:  r.AlreadyExists,
+		CancelDeadline: toTimestamp(r.CancelDeadline),
+		LessonRoomUrl:  r.LessonRoomURL,
+	}
+}
+
+// toTimestamp leaves the zero time unset.
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// statusCodes maps the failure classes to the gRPC status codes.
+var statusCodes = []struct {
+	err  error
+	code codes.Code
+}{
+	{context.Canceled, codes.Canceled},
+	{context.DeadlineExceeded, codes.DeadlineExceeded},
+	{librarejob.ErrInvalidOptions, codes.InvalidArgument},
+	{librarejob.ErrSpreadAcrossTwoDays, codes.InvalidArgument},
+	{librarejob.ErrAccountLocked, codes.PermissionDenied},
+	{librarejob.ErrLoginFailed, codes.Unauthenticated},
+	{librarejob.ErrSessionExpired, codes.Unauthenticated},
+	{librarejob.ErrHumanVerificationRequired, codes.Unauthenticated},
+	{librarejob.ErrSiteMaintenance, codes.Unavailable},
+	{librarejob.ErrNoTutorsAvailable, codes.NotFound},
+	{librarejob.ErrReservationNotFound, codes.NotFound},
+	{librarejob.ErrMaterialNotFound, codes.NotFound},
+	{librarejob.ErrSlotTaken, codes.Aborted},
+	{librarejob.ErrConflict, codes.FailedPrecondition},
+	{librarejob.ErrCancelDeadlinePassed, codes.FailedPrecondition},
+	{librarejob.ErrReservationUnverified, codes.Internal},
+}
+
+// toStatus converts err to the status with the code of its failure class. The rest are failures of the site or selenium.
+func toStatus(err error) error {
+	for _, c := range statusCodes {
+		if errors.Is(err, c.err) {
+			return status.Error(c.code, err.Error())
+		}
+	}
+	zap.L().Error("failed to serve request", zap.Error(err))
+	return status.Error(codes.Unavailable, err.Error())
+}
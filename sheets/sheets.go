@@ -0,0 +1,126 @@
+// Package sheets appends rows to a Google Sheet with a service account, through the Sheets API v4.
+package sheets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/oauth2/jwt"
+)
+
+const (
+	// Scope is the OAuth2 scope to read and write the spreadsheets.
+	Scope = "https://www.googleapis.com/auth/spreadsheets"
+
+	defaultTokenURL = "https://oauth2.googleapis.com/token"
+	apiURL          = "https://sheets.googleapis.com/v4/spreadsheets/"
+)
+
+// serviceAccount is the part of the JSON key file of the service account used for the auth.
+type serviceAccount struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// Client appends rows to the sheets of the spreadsheet.
+type Client struct {
+	hc            *http.Client
+	spreadsheetID string
+}
+
+// New returns the client of the spreadsheet authorized with the JSON key file of the service account.
+// The spreadsheet must be shared with the email of the service account as an editor.
+func New(ctx context.Context, credentialsJSON []byte, spreadsheetID string) (*Client, error) {
+	var sa serviceAccount
+	if err := json.Unmarshal(credentialsJSON, &sa); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials: %w", err)
+	}
+	if sa.Type != "service_account" || sa.ClientEmail == "" || sa.PrivateKey == "" {
+		return nil, fmt.Errorf("credentials are not the key of a service account")
+	}
+	if sa.TokenURI == "" {
+		sa.TokenURI = defaultTokenURL
+	}
+	conf := &jwt.Config{
+		Email:        sa.ClientEmail,
+		PrivateKey:   []byte(sa.PrivateKey),
+		PrivateKeyID: sa.PrivateKeyID,
+		Scopes:       []string{Scope},
+		TokenURL:     sa.TokenURI,
+	}
+	return &Client{hc: conf.Client(ctx), spreadsheetID: spreadsheetID}, nil
+}
+
+// Append appends the rows to the end of the table in the sheet. The header is written first if the sheet is empty,
+// so that a new sheet becomes a table at the first export.
+func (c *Client) Append(ctx context.Context, sheet string, header []string, rows [][]string) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	var current struct {
+		Values [][]string `json:"values"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/values/"+url.PathEscape(rangeOf(sheet, "A1:1")), nil, &current); err != nil {
+		return fmt.Errorf("failed to read header of sheet %s: %w", sheet, err)
+	}
+	values := make([][]string, 0, len(rows)+1)
+	if len(current.Values) == 0 && header != nil {
+		values = append(values, header)
+	}
+	values = append(values, rows...)
+
+	q := url.Values{}
+	// USER_ENTERED lets the sheet parse the numbers and the dates as if they were typed
+	q.Set("valueInputOption", "USER_ENTERED")
+	q.Set("insertDataOption", "INSERT_ROWS")
+	body := map[string]interface{}{"values": values}
+	if err := c.do(ctx, http.MethodPost, "/values/"+url.PathEscape(rangeOf(sheet, "A1"))+":append?"+q.Encode(), body, nil); err != nil {
+		return fmt.Errorf("failed to append rows to sheet %s: %w", sheet, err)
+	}
+	return nil
+}
+
+// rangeOf returns the A1 notation of the cells in the sheet, quoting the name of the sheet.
+func rangeOf(sheet, cells string) string {
+	return "'" + sheet + "'!" + cells
+}
+
+// do calls the API at the path of the spreadsheet and decodes the response into out if not nil.
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, apiURL+url.PathEscape(c.spreadsheetID)+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := c.hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		b, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("unexpected status %s: %s", res.Status, bytes.TrimSpace(b))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
@@ -0,0 +1,425 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisKeyPrefix prefixes the keys of the store not to collide with the other applications sharing the database.
+const redisKeyPrefix = "rarejobctl:"
+
+const (
+	// redisRecordsKey is the sorted set of the ids of the records by their creation time.
+	redisRecordsKey = redisKeyPrefix + "records"
+	// redisRecordKindKey prefixes the sorted sets of the ids of the records of each kind by their creation time.
+	redisRecordKindKey = redisKeyPrefix + "records:kind:"
+	// redisRecordStartsKey is the sorted set of the ids of the reservations and the cancellations by their start time.
+	redisRecordStartsKey = redisKeyPrefix + "records:starts"
+	// redisRecordDataKey is the hash of the records by their ids.
+	redisRecordDataKey = redisKeyPrefix + "records:data"
+	redisRecordSeqKey  = redisKeyPrefix + "records:seq"
+	redisLessonsKey    = redisKeyPrefix + "lessons"
+	redisSamplesKey    = redisKeyPrefix + "samples"
+	redisLocksKey      = redisKeyPrefix + "locks:"
+	// redisSessionsKey prefixes the keys of the sessions of each account, which expire by themselves.
+	redisSessionsKey = redisKeyPrefix + "sessions:"
+)
+
+// DefaultRedisRetention is how long the records and the samples are kept in Redis unless the retention is given in the URL.
+// Unlike the SQLite file, the memory of Redis is shared with the other applications, so the old ones are trimmed as new ones are added.
+const DefaultRedisRetention = 365 * 24 * time.Hour
+
+// redisTryLock sets the lock to the owner unless another owner holds it, extending its expiry.
+var redisTryLock = redis.NewScript(`
+local owner = redis.call("GET", KEYS[1])
+if owner == false or owner == ARGV[1] then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// redisUnlock deletes the lock only if the owner holds it, not to release the lock taken over by another owner after expiry.
+var redisUnlock = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Redis is the store backed by Redis, which is shared by the processes on different hosts.
+// The records are kept in a hash by their ids, indexed by sorted sets of the ids by their creation time (of all and of each kind)
+// and by their start time (of the reservations and the cancellations), so that they are looked up without reading all of them.
+// The ids are zero-padded in the sorted sets so that the records created in the same second are ordered by their ids.
+// The samples are kept in a sorted set by their time, the lessons in a hash by their start time,
+// and the sessions in the keys of each account expiring with them.
+// The times are kept in seconds as SQLite does.
+type Redis struct {
+	rdb       *redis.Client
+	retention time.Duration
+}
+
+// OpenRedis connects to Redis at the URL, e.g. redis://:password@localhost:6379/0.
+// The records and the samples older than the retention parameter of the URL (e.g. ?retention=720h, 0 to keep them forever)
+// are trimmed, or DefaultRedisRetention if not given.
+func OpenRedis(rawURL string) (*Redis, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse redis url: %w", err)
+	}
+	retention := DefaultRedisRetention
+	q := u.Query()
+	if v := q.Get("retention"); v != "" {
+		if retention, err = time.ParseDuration(v); err != nil || retention < 0 {
+			return nil, fmt.Errorf("invalid retention of redis url: %q", v)
+		}
+		// go-redis rejects the parameters unknown to it
+		q.Del("retention")
+		u.RawQuery = q.Encode()
+	}
+	opts, err := redis.ParseURL(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse redis url: %w", err)
+	}
+	rdb := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		rdb.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	return &Redis{rdb: rdb, retention: retention}, nil
+}
+
+// Close closes the connection.
+func (s *Redis) Close() error {
+	return s.rdb.Close()
+}
+
+// Add records r and sets its ID. CreatedAt is set to now if zero. The records older than the retention are trimmed.
+func (s *Redis) Add(ctx context.Context, r *Record) error {
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	id, err := s.rdb.Incr(ctx, redisRecordSeqKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get id of record: %w", err)
+	}
+	rec := *r
+	rec.ID = id
+	rec.StartAt, rec.EndAt, rec.CreatedAt = seconds(rec.StartAt), seconds(rec.EndAt), seconds(rec.CreatedAt)
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode record: %w", err)
+	}
+	member := redisRecordMember(id)
+	created := redis.Z{Score: float64(rec.CreatedAt.Unix()), Member: member}
+	if _, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, redisRecordDataKey, member, b)
+		pipe.ZAdd(ctx, redisRecordsKey, created)
+		pipe.ZAdd(ctx, redisRecordKindKey+string(rec.Kind), created)
+		if rec.Kind == KindReservation || rec.Kind == KindCancellation {
+			pipe.ZAdd(ctx, redisRecordStartsKey, redis.Z{Score: float64(rec.StartAt.Unix()), Member: member})
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to add record: %w", err)
+	}
+	r.ID = id
+	s.trimRecords(ctx)
+	return nil
+}
+
+// trimRecords deletes the records created before the retention. The failure is ignored since the records are still valid,
+// and they are left to the next trim.
+func (s *Redis) trimRecords(ctx context.Context) {
+	if s.retention == 0 {
+		return
+	}
+	maxScore := "(" + strconv.FormatInt(time.Now().Add(-s.retention).Unix(), 10)
+	members, err := s.rdb.ZRangeByScore(ctx, redisRecordsKey, &redis.ZRangeBy{Min: "-inf", Max: maxScore}).Result()
+	if err != nil || len(members) == 0 {
+		return
+	}
+	ids := make([]interface{}, len(members))
+	for i, m := range members {
+		ids[i] = m
+	}
+	s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, redisRecordDataKey, members...)
+		pipe.ZRem(ctx, redisRecordsKey, ids...)
+		pipe.ZRem(ctx, redisRecordStartsKey, ids...)
+		for _, k := range []Kind{KindReservation, KindCancellation, KindAttempt} {
+			pipe.ZRem(ctx, redisRecordKindKey+string(k), ids...)
+		}
+		return nil
+	})
+}
+
+// List returns the records matching q, newest first.
+func (s *Redis) List(ctx context.Context, q Query) ([]Record, error) {
+	key := redisRecordsKey
+	if q.Kind != "" {
+		key = redisRecordKindKey + string(q.Kind)
+	}
+	by := &redis.ZRangeBy{Min: "-inf", Max: "+inf"}
+	if !q.Since.IsZero() {
+		by.Min = strconv.FormatInt(q.Since.Unix(), 10)
+	}
+	if q.Limit > 0 {
+		by.Count = int64(q.Limit)
+	}
+	// the ids of the same score are also in the reverse order, which is the order of the ids
+	members, err := s.rdb.ZRevRangeByScore(ctx, key, by).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query records: %w", err)
+	}
+	return s.records(ctx, members)
+}
+
+// FindReservation returns the latest reservation starting in [from, to) which has not been cancelled.
+// Dry-run reservations are ignored. It returns nil if there is no such reservation.
+func (s *Redis) FindReservation(ctx context.Context, from, to time.Time) (*Record, error) {
+	members, err := s.rdb.ZRangeByScore(ctx, redisRecordStartsKey, &redis.ZRangeBy{
+		Min: strconv.FormatInt(from.Unix(), 10),
+		Max: "(" + strconv.FormatInt(to.Unix(), 10),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query records: %w", err)
+	}
+	// the cancellations of a reservation start at the same time, so they are also in the range
+	records, err := s.records(ctx, members)
+	if err != nil {
+		return nil, err
+	}
+	var found *Record
+	for i, r := range records {
+		if r.Kind != KindReservation || r.DryRun {
+			continue
+		}
+		if found != nil && (r.CreatedAt.Before(found.CreatedAt) || r.CreatedAt.Equal(found.CreatedAt) && r.ID < found.ID) {
+			continue
+		}
+		cancelled := false
+		for _, c := range records {
+			if c.Kind == KindCancellation && c.StartAt.Equal(r.StartAt) && !c.CreatedAt.Before(r.CreatedAt) {
+				cancelled = true
+				break
+			}
+		}
+		if !cancelled {
+			found = &records[i]
+		}
+	}
+	return found, nil
+}
+
+// records returns the records of the members of the sorted sets in the same order. The members trimmed meanwhile are skipped.
+func (s *Redis) records(ctx context.Context, members []string) ([]Record, error) {
+	if len(members) == 0 {
+		return nil, nil
+	}
+	values, err := s.rdb.HMGet(ctx, redisRecordDataKey, members...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query records: %w", err)
+	}
+	records := make([]Record, 0, len(values))
+	for _, v := range values {
+		b, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal([]byte(b), &r); err != nil {
+			return nil, fmt.Errorf("failed to read record: %w", err)
+		}
+		r.StartAt, r.EndAt, r.CreatedAt = r.StartAt.Local(), r.EndAt.Local(), r.CreatedAt.Local()
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// redisRecordMember returns the member of the sorted sets for the id of the record.
+func redisRecordMember(id int64) string {
+	return fmt.Sprintf("%020d", id)
+}
+
+// SaveLessons adds the lessons, or updates them if they are already saved since the status may have changed.
+// A lesson is identified by its start time as only one lesson can be taken at a time.
+func (s *Redis) SaveLessons(ctx context.Context, lessons []Lesson) error {
+	if len(lessons) == 0 {
+		return nil
+	}
+	values := make([]interface{}, 0, len(lessons)*2)
+	for _, l := range lessons {
+		l.StartAt = seconds(l.StartAt)
+		b, err := json.Marshal(l)
+		if err != nil {
+			return fmt.Errorf("failed to encode lesson: %w", err)
+		}
+		values = append(values, strconv.FormatInt(l.StartAt.Unix(), 10), b)
+	}
+	if err := s.rdb.HSet(ctx, redisLessonsKey, values...).Err(); err != nil {
+		return fmt.Errorf("failed to save lessons: %w", err)
+	}
+	return nil
+}
+
+// ListLessons returns the lessons matching q, newest first.
+func (s *Redis) ListLessons(ctx context.Context, q LessonQuery) ([]Lesson, error) {
+	all, err := s.lessons(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var lessons []Lesson
+	for _, l := range all {
+		if q.TutorName != "" && l.TutorName != q.TutorName || !q.Since.IsZero() && l.StartAt.Unix() < q.Since.Unix() {
+			continue
+		}
+		lessons = append(lessons, l)
+		if q.Limit > 0 && len(lessons) == q.Limit {
+			break
+		}
+	}
+	return lessons, nil
+}
+
+// LatestLesson returns the start time of the latest saved lesson, or zero time if no lesson is saved.
+func (s *Redis) LatestLesson(ctx context.Context) (time.Time, error) {
+	lessons, err := s.lessons(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(lessons) == 0 {
+		return time.Time{}, nil
+	}
+	return lessons[0].StartAt, nil
+}
+
+// lessons returns all the lessons, newest first.
+func (s *Redis) lessons(ctx context.Context) ([]Lesson, error) {
+	values, err := s.rdb.HVals(ctx, redisLessonsKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lessons: %w", err)
+	}
+	lessons := make([]Lesson, 0, len(values))
+	for _, v := range values {
+		var l Lesson
+		if err := json.Unmarshal([]byte(v), &l); err != nil {
+			return nil, fmt.Errorf("failed to read lesson: %w", err)
+		}
+		l.StartAt = l.StartAt.Local()
+		lessons = append(lessons, l)
+	}
+	sort.Slice(lessons, func(i, j int) bool { return lessons[i].StartAt.After(lessons[j].StartAt) })
+	return lessons, nil
+}
+
+// AddSamples records the samples of the availability. SampledAt is set to now if zero. The samples older than the retention are trimmed.
+func (s *Redis) AddSamples(ctx context.Context, samples []Sample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	now := time.Now()
+	members := make([]redis.Z, 0, len(samples))
+	for _, sm := range samples {
+		if sm.SampledAt.IsZero() {
+			sm.SampledAt = now
+		}
+		sm.SlotAt, sm.SampledAt = seconds(sm.SlotAt), seconds(sm.SampledAt)
+		b, err := json.Marshal(sm)
+		if err != nil {
+			return fmt.Errorf("failed to encode sample: %w", err)
+		}
+		members = append(members, redis.Z{Score: float64(sm.SampledAt.Unix()), Member: b})
+	}
+	if err := s.rdb.ZAdd(ctx, redisSamplesKey, members...).Err(); err != nil {
+		return fmt.Errorf("failed to add samples: %w", err)
+	}
+	if s.retention > 0 {
+		// left to the next trim if it fails, as trimRecords does
+		s.rdb.ZRemRangeByScore(ctx, redisSamplesKey, "-inf", "("+strconv.FormatInt(now.Add(-s.retention).Unix(), 10))
+	}
+	return nil
+}
+
+// ListSamples returns the samples of the availability matching q, oldest first.
+func (s *Redis) ListSamples(ctx context.Context, q SampleQuery) ([]Sample, error) {
+	minScore := "-inf"
+	if !q.Since.IsZero() {
+		minScore = strconv.FormatInt(q.Since.Unix(), 10)
+	}
+	members, err := s.rdb.ZRangeByScore(ctx, redisSamplesKey, &redis.ZRangeBy{Min: minScore, Max: "+inf"}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query samples: %w", err)
+	}
+	samples := make([]Sample, 0, len(members))
+	for _, m := range members {
+		var sm Sample
+		if err := json.Unmarshal([]byte(m), &sm); err != nil {
+			return nil, fmt.Errorf("failed to read sample: %w", err)
+		}
+		sm.SlotAt, sm.SampledAt = sm.SlotAt.Local(), sm.SampledAt.Local()
+		samples = append(samples, sm)
+	}
+	return samples, nil
+}
+
+// TryLock acquires the lock of the name for owner until ttl passes, or extends it if owner already holds it.
+// It returns false if another owner holds the lock.
+func (s *Redis) TryLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	n, err := redisTryLock.Run(ctx, s.rdb, []string{redisLocksKey + name}, owner, max(ttl.Milliseconds(), 1)).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	return n == 1, nil
+}
+
+// Unlock releases the lock of the name if owner holds it.
+func (s *Redis) Unlock(ctx context.Context, name, owner string) error {
+	if err := redisUnlock.Run(ctx, s.rdb, []string{redisLocksKey + name}, owner).Err(); err != nil {
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+	return nil
+}
+
+// SaveSession keeps the session of the account until ttl passes, replacing the saved one.
+func (s *Redis) SaveSession(ctx context.Context, account string, session []byte, ttl time.Duration) error {
+	if err := s.rdb.Set(ctx, redisSessionsKey+account, session, max(ttl, time.Millisecond)).Err(); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+// LoadSession returns the session of the account, or nil if there is none or it has expired.
+func (s *Redis) LoadSession(ctx context.Context, account string) ([]byte, error) {
+	session, err := s.rdb.Get(ctx, redisSessionsKey+account).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+	return session, nil
+}
+
+// DeleteSession deletes the session of the account.
+func (s *Redis) DeleteSession(ctx context.Context, account string) error {
+	if err := s.rdb.Del(ctx, redisSessionsKey+account).Err(); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// seconds truncates t to seconds in the local timezone, as the times are read back from SQLite.
+func seconds(t time.Time) time.Time {
+	return time.Unix(t.Unix(), 0).Local()
+}
@@ -0,0 +1,404 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteMigrations are applied in order to bring the database up to date. The number of applied ones is kept in user_version,
+// so never modify the existing ones but append a new one.
+var sqliteMigrations = []string{
+	`
+CREATE TABLE IF NOT EXISTS records (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	kind       TEXT    NOT NULL,
+	tutor_name TEXT    NOT NULL DEFAULT '',
+	start_at   INTEGER NOT NULL,
+	end_at     INTEGER NOT NULL,
+	dry_run    INTEGER NOT NULL DEFAULT 0,
+	error      TEXT    NOT NULL DEFAULT '',
+	error_code TEXT    NOT NULL DEFAULT '',
+	created_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS records_start_at ON records (start_at);
+CREATE TABLE IF NOT EXISTS lessons (
+	start_at   INTEGER PRIMARY KEY,
+	tutor_name TEXT    NOT NULL DEFAULT '',
+	material   TEXT    NOT NULL DEFAULT '',
+	status     TEXT    NOT NULL DEFAULT '',
+	completed  INTEGER NOT NULL DEFAULT 0
+);
+`,
+	`ALTER TABLE lessons ADD COLUMN id TEXT NOT NULL DEFAULT ''`,
+	`
+CREATE TABLE IF NOT EXISTS availability_samples (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	slot_at    INTEGER NOT NULL,
+	open_slots INTEGER NOT NULL,
+	sampled_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS availability_samples_sampled_at ON availability_samples (sampled_at);
+`,
+	`
+CREATE TABLE IF NOT EXISTS locks (
+	name       TEXT    PRIMARY KEY,
+	owner      TEXT    NOT NULL,
+	expires_at INTEGER NOT NULL
+);
+`,
+	`
+CREATE TABLE IF NOT EXISTS sessions (
+	account    TEXT    PRIMARY KEY,
+	data       BLOB    NOT NULL,
+	expires_at INTEGER NOT NULL
+);
+`,
+}
+
+// SQLite is the store backed by a local SQLite database.
+type SQLite struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens the database at path, creating it and its directory if they don't exist.
+func OpenSQLite(path string) (*SQLite, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create directory for database: %w", err)
+	}
+	// busy_timeout lets concurrent processes, e.g. cron jobs overlapping each other, wait for the lock
+	db, err := sql.Open("sqlite3", "file:"+path+"?_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	return &SQLite{db: db}, nil
+}
+
+func migrateSQLite(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	for i := version; i < len(sqliteMigrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(sqliteMigrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration #%d: %w", i+1, err)
+		}
+		// PRAGMA doesn't accept placeholders
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the database.
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+// Add records r and sets its ID. CreatedAt is set to now if zero.
+func (s *SQLite) Add(ctx context.Context, r *Record) error {
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO records (kind, tutor_name, start_at, end_at, dry_run, error, error_code, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.Kind, r.TutorName, r.StartAt.Unix(), r.EndAt.Unix(), r.DryRun, r.Error, r.ErrorCode, r.CreatedAt.Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add record: %w", err)
+	}
+	if r.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to get id of record: %w", err)
+	}
+	return nil
+}
+
+// List returns the records matching q, newest first.
+func (s *SQLite) List(ctx context.Context, q Query) ([]Record, error) {
+	var conds []string
+	var args []interface{}
+	if q.Kind != "" {
+		conds = append(conds, "kind = ?")
+		args = append(args, q.Kind)
+	}
+	if !q.Since.IsZero() {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, q.Since.Unix())
+	}
+	query := "SELECT id, kind, tutor_name, start_at, end_at, dry_run, error, error_code, created_at FROM records"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+	if q.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, q.Limit)
+	}
+	return s.query(ctx, query, args...)
+}
+
+// FindReservation returns the latest reservation starting in [from, to) which has not been cancelled.
+// Dry-run reservations are ignored. It returns nil if there is no such reservation.
+func (s *SQLite) FindReservation(ctx context.Context, from, to time.Time) (*Record, error) {
+	records, err := s.query(ctx, `
+SELECT id, kind, tutor_name, start_at, end_at, dry_run, error, error_code, created_at FROM records r
+WHERE kind = ? AND dry_run = 0 AND start_at >= ? AND start_at < ?
+AND NOT EXISTS (
+	SELECT 1 FROM records c
+	WHERE c.kind = ? AND c.start_at = r.start_at AND c.created_at >= r.created_at
+)
+ORDER BY created_at DESC, id DESC LIMIT 1`,
+		KindReservation, from.Unix(), to.Unix(), KindCancellation,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return &records[0], nil
+}
+
+// SaveLessons adds the lessons, or updates them if they are already saved since the status may have changed.
+// A lesson is identified by its start time as only one lesson can be taken at a time.
+func (s *SQLite) SaveLessons(ctx context.Context, lessons []Lesson) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	for _, l := range lessons {
+		if _, err := tx.ExecContext(ctx, `
+INSERT INTO lessons (start_at, id, tutor_name, material, status, completed) VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (start_at) DO UPDATE SET id = excluded.id, tutor_name = excluded.tutor_name, material = excluded.material, status = excluded.status, completed = excluded.completed`,
+			l.StartAt.Unix(), l.ID, l.TutorName, l.Material, l.Status, l.Completed,
+		); err != nil {
+			return fmt.Errorf("failed to save lesson: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save lessons: %w", err)
+	}
+	return nil
+}
+
+// ListLessons returns the lessons matching q, newest first.
+func (s *SQLite) ListLessons(ctx context.Context, q LessonQuery) ([]Lesson, error) {
+	var conds []string
+	var args []interface{}
+	if q.TutorName != "" {
+		conds = append(conds, "tutor_name = ?")
+		args = append(args, q.TutorName)
+	}
+	if !q.Since.IsZero() {
+		conds = append(conds, "start_at >= ?")
+		args = append(args, q.Since.Unix())
+	}
+	query := "SELECT start_at, id, tutor_name, material, status, completed FROM lessons"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY start_at DESC"
+	if q.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, q.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lessons: %w", err)
+	}
+	defer rows.Close()
+
+	var lessons []Lesson
+	for rows.Next() {
+		var l Lesson
+		var startAt int64
+		if err := rows.Scan(&startAt, &l.ID, &l.TutorName, &l.Material, &l.Status, &l.Completed); err != nil {
+			return nil, fmt.Errorf("failed to read lesson: %w", err)
+		}
+		l.StartAt = time.Unix(startAt, 0).Local()
+		lessons = append(lessons, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read lessons: %w", err)
+	}
+	return lessons, nil
+}
+
+// LatestLesson returns the start time of the latest saved lesson, or zero time if no lesson is saved.
+func (s *SQLite) LatestLesson(ctx context.Context) (time.Time, error) {
+	var startAt sql.NullInt64
+	if err := s.db.QueryRowContext(ctx, "SELECT MAX(start_at) FROM lessons").Scan(&startAt); err != nil {
+		return time.Time{}, fmt.Errorf("failed to query latest lesson: %w", err)
+	}
+	if !startAt.Valid {
+		return time.Time{}, nil
+	}
+	return time.Unix(startAt.Int64, 0).Local(), nil
+}
+
+// AddSamples records the samples of the availability. SampledAt is set to now if zero.
+func (s *SQLite) AddSamples(ctx context.Context, samples []Sample) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	now := time.Now()
+	for _, sm := range samples {
+		if sm.SampledAt.IsZero() {
+			sm.SampledAt = now
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO availability_samples (slot_at, open_slots, sampled_at) VALUES (?, ?, ?)`,
+			sm.SlotAt.Unix(), sm.OpenSlots, sm.SampledAt.Unix(),
+		); err != nil {
+			return fmt.Errorf("failed to add sample: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to add samples: %w", err)
+	}
+	return nil
+}
+
+// ListSamples returns the samples of the availability matching q, oldest first.
+func (s *SQLite) ListSamples(ctx context.Context, q SampleQuery) ([]Sample, error) {
+	query := "SELECT slot_at, open_slots, sampled_at FROM availability_samples"
+	var args []interface{}
+	if !q.Since.IsZero() {
+		query += " WHERE sampled_at >= ?"
+		args = append(args, q.Since.Unix())
+	}
+	query += " ORDER BY sampled_at, id"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query samples: %w", err)
+	}
+	defer rows.Close()
+
+	var samples []Sample
+	for rows.Next() {
+		var sm Sample
+		var slotAt, sampledAt int64
+		if err := rows.Scan(&slotAt, &sm.OpenSlots, &sampledAt); err != nil {
+			return nil, fmt.Errorf("failed to read sample: %w", err)
+		}
+		sm.SlotAt = time.Unix(slotAt, 0).Local()
+		sm.SampledAt = time.Unix(sampledAt, 0).Local()
+		samples = append(samples, sm)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read samples: %w", err)
+	}
+	return samples, nil
+}
+
+// TryLock acquires the lock of the name for owner until ttl passes, or extends it if owner already holds it.
+// It returns false if another owner holds the lock.
+func (s *SQLite) TryLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	res, err := s.db.ExecContext(ctx, `
+INSERT INTO locks (name, owner, expires_at) VALUES (?, ?, ?)
+ON CONFLICT (name) DO UPDATE SET owner = excluded.owner, expires_at = excluded.expires_at
+WHERE locks.owner = excluded.owner OR locks.expires_at <= ?`,
+		name, owner, now.Add(ttl).UnixMilli(), now.UnixMilli(),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	return n == 1, nil
+}
+
+// Unlock releases the lock of the name if owner holds it.
+func (s *SQLite) Unlock(ctx context.Context, name, owner string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM locks WHERE name = ? AND owner = ?`, name, owner); err != nil {
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+	return nil
+}
+
+// SaveSession keeps the session of the account until ttl passes, replacing the saved one.
+func (s *SQLite) SaveSession(ctx context.Context, account string, session []byte, ttl time.Duration) error {
+	if _, err := s.db.ExecContext(ctx, `
+INSERT INTO sessions (account, data, expires_at) VALUES (?, ?, ?)
+ON CONFLICT (account) DO UPDATE SET data = excluded.data, expires_at = excluded.expires_at`,
+		account, session, time.Now().Add(ttl).UnixMilli(),
+	); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+// LoadSession returns the session of the account, or nil if there is none or it has expired.
+func (s *SQLite) LoadSession(ctx context.Context, account string) ([]byte, error) {
+	var session []byte
+	err := s.db.QueryRowContext(ctx, `SELECT data FROM sessions WHERE account = ? AND expires_at > ?`, account, time.Now().UnixMilli()).Scan(&session)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+	return session, nil
+}
+
+// DeleteSession deletes the session of the account.
+func (s *SQLite) DeleteSession(ctx context.Context, account string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE account = ?`, account); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLite) query(ctx context.Context, query string, args ...interface{}) ([]Record, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []Record
+	for rows.Next() {
+		var r Record
+		var startAt, endAt, createdAt int64
+		if err := rows.Scan(&r.ID, &r.Kind, &r.TutorName, &startAt, &endAt, &r.DryRun, &r.Error, &r.ErrorCode, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to read record: %w", err)
+		}
+		r.StartAt = time.Unix(startAt, 0).Local()
+		r.EndAt = time.Unix(endAt, 0).Local()
+		r.CreatedAt = time.Unix(createdAt, 0).Local()
+		records = append(records, r)
+	}
+	if err := rows.Err(); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to read records: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func userVersion(t *testing.T, path string) int {
+	t.Helper()
+	db, err := sql.Open("sqlite3", "file:"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	return version
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	startAt := time.Date(2022, 12, 27, 21, 0, 0, 0, time.Local)
+	tests := []struct {
+		name string
+		// applied is the number of the migrations applied before opening.
+		applied int
+	}{
+		{"new database", 0},
+		{"before lesson id", 1},
+		{"before availability samples", 2},
+		{"before locks", 3},
+		{"before sessions", 4},
+		{"up to date", len(sqliteMigrations)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			path := filepath.Join(t.TempDir(), "sub", "history.db")
+			if tt.applied > 0 {
+				// the database created by the older version
+				path = filepath.Join(t.TempDir(), "history.db")
+				db, err := sql.Open("sqlite3", "file:"+path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for i := 0; i < tt.applied; i++ {
+					if _, err := db.Exec(sqliteMigrations[i]); err != nil {
+						t.Fatalf("migration #%d: %v", i+1, err)
+					}
+				}
+				if _, err := db.Exec("INSERT INTO lessons (start_at, tutor_name, completed) VALUES (?, ?, 1)", startAt.Unix(), "Tutor A"); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", tt.applied)); err != nil {
+					t.Fatal(err)
+				}
+				db.Close()
+			}
+
+			st, err := OpenSQLite(path)
+			if err != nil {
+				t.Fatalf("OpenSQLite() = %v", err)
+			}
+			if got := userVersion(t, path); got != len(sqliteMigrations) {
+				t.Errorf("user_version = %d, want %d", got, len(sqliteMigrations))
+			}
+
+			// every table is usable after the migrations
+			if err := st.Add(ctx, &Record{Kind: KindReservation, StartAt: startAt, EndAt: startAt.Add(25 * time.Minute)}); err != nil {
+				t.Errorf("Add() = %v", err)
+			}
+			if err := st.SaveLessons(ctx, []Lesson{{ID: "1", TutorName: "Tutor B", StartAt: startAt.Add(time.Hour), Completed: true}}); err != nil {
+				t.Errorf("SaveLessons() = %v", err)
+			}
+			if err := st.AddSamples(ctx, []Sample{{SlotAt: startAt, OpenSlots: 3}}); err != nil {
+				t.Errorf("AddSamples() = %v", err)
+			}
+			if _, err := st.TryLock(ctx, "reserve", "owner", time.Minute); err != nil {
+				t.Errorf("TryLock() = %v", err)
+			}
+			if err := st.SaveSession(ctx, "fixture@example.com", []byte("{}"), time.Minute); err != nil {
+				t.Errorf("SaveSession() = %v", err)
+			}
+
+			lessons, err := st.ListLessons(ctx, LessonQuery{})
+			if err != nil {
+				t.Fatalf("ListLessons() = %v", err)
+			}
+			want := 1
+			if tt.applied > 0 {
+				// the lesson saved before the migrations is kept
+				want = 2
+			}
+			if len(lessons) != want {
+				t.Errorf("ListLessons() = %v, want %d lessons", lessons, want)
+			}
+
+			// reopening doesn't apply the migrations again
+			st.Close()
+			if st, err = OpenSQLite(path); err != nil {
+				t.Fatalf("OpenSQLite() again = %v", err)
+			}
+			st.Close()
+		})
+	}
+}
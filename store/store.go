@@ -0,0 +1,153 @@
+// Package store persists what rarejobctl has done, i.e. reservations, cancellations and failed attempts,
+// what it has observed, i.e. the lesson history and the availability of the slots,
+// and the session of the site to be resumed by the next process without logging in again.
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Kind is the kind of the recorded event.
+type Kind string
+
+const (
+	// KindReservation is a completed (or dry-run) reservation.
+	KindReservation Kind = "reservation"
+	// KindCancellation is a cancelled reservation.
+	KindCancellation Kind = "cancellation"
+	// KindAttempt is a failed attempt of the reservation.
+	KindAttempt Kind = "attempt"
+)
+
+// Record is an event recorded in the store.
+type Record struct {
+	ID        int64     `json:"id"`
+	Kind      Kind      `json:"kind"`
+	TutorName string    `json:"tutor_name,omitempty"`
+	StartAt   time.Time `json:"start_at"`
+	EndAt     time.Time `json:"end_at"`
+	DryRun    bool      `json:"dry_run"`
+	// Error and ErrorCode describe why the attempt failed. Empty on success.
+	Error     string    `json:"error,omitempty"`
+	ErrorCode string    `json:"error_code,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Lesson is a past lesson synced from the lesson history on the site.
+type Lesson struct {
+	ID        string    `json:"id"`
+	TutorName string    `json:"tutor_name"`
+	StartAt   time.Time `json:"start_at"`
+	Material  string    `json:"material"`
+	Status    string    `json:"status"`
+	Completed bool      `json:"completed"`
+}
+
+// LessonQuery filters the lessons to list.
+type LessonQuery struct {
+	// TutorName filters the lessons by the tutor. All tutors are listed if empty.
+	TutorName string
+	// Since filters the lessons started at or after the time. All lessons are listed if zero.
+	Since time.Time
+	// Limit is the max number of lessons, newest first. No limit if zero.
+	Limit int
+}
+
+// Sample is the number of the open slots in an hour observed by sampling the search results.
+type Sample struct {
+	// SlotAt is the start of the hour.
+	SlotAt    time.Time `json:"slot_at"`
+	OpenSlots int       `json:"open_slots"`
+	SampledAt time.Time `json:"sampled_at"`
+}
+
+// SampleQuery filters the samples to list.
+type SampleQuery struct {
+	// Since filters the samples taken at or after the time. All samples are listed if zero.
+	Since time.Time
+}
+
+// Query filters the records to list.
+type Query struct {
+	// Kind filters the records by the kind. All kinds are listed if empty.
+	Kind Kind
+	// Since filters the records created at or after the time. All records are listed if zero.
+	Since time.Time
+	// Limit is the max number of records, newest first. No limit if zero.
+	Limit int
+}
+
+// Store is where the state of rarejobctl is kept. SQLite keeps it in a local file, while Redis shares it among
+// the replicas of the daemon and the ephemeral containers so that they don't reserve the same lesson twice.
+type Store interface {
+	// Close closes the store.
+	Close() error
+
+	// Add records r and sets its ID. CreatedAt is set to now if zero.
+	Add(ctx context.Context, r *Record) error
+	// List returns the records matching q, newest first.
+	List(ctx context.Context, q Query) ([]Record, error)
+	// FindReservation returns the latest reservation starting in [from, to) which has not been cancelled.
+	// Dry-run reservations are ignored. It returns nil if there is no such reservation.
+	FindReservation(ctx context.Context, from, to time.Time) (*Record, error)
+
+	// SaveLessons adds the lessons, or updates them if they are already saved. A lesson is identified by its start time.
+	SaveLessons(ctx context.Context, lessons []Lesson) error
+	// ListLessons returns the lessons matching q, newest first.
+	ListLessons(ctx context.Context, q LessonQuery) ([]Lesson, error)
+	// LatestLesson returns the start time of the latest saved lesson, or zero time if no lesson is saved.
+	LatestLesson(ctx context.Context) (time.Time, error)
+
+	// AddSamples records the samples of the availability. SampledAt is set to now if zero.
+	AddSamples(ctx context.Context, samples []Sample) error
+	// ListSamples returns the samples of the availability matching q, oldest first.
+	ListSamples(ctx context.Context, q SampleQuery) ([]Sample, error)
+
+	// SaveSession keeps the session of the account, e.g. the cookies, until ttl passes, replacing the saved one.
+	SaveSession(ctx context.Context, account string, session []byte, ttl time.Duration) error
+	// LoadSession returns the session of the account saved by SaveSession, or nil if there is none or it has expired.
+	LoadSession(ctx context.Context, account string) ([]byte, error)
+	// DeleteSession deletes the session of the account, e.g. when it has been logged out.
+	DeleteSession(ctx context.Context, account string) error
+
+	// TryLock acquires the lock of the name for owner until ttl passes, or extends it if owner already holds it.
+	// It returns false if another owner holds the lock.
+	TryLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error)
+	// Unlock releases the lock of the name if owner holds it.
+	Unlock(ctx context.Context, name, owner string) error
+}
+
+var (
+	_ Store = (*SQLite)(nil)
+	_ Store = (*Redis)(nil)
+)
+
+// Open opens the store at dsn, which is the URL of Redis (redis:// or rediss://) or the path of the SQLite database.
+func Open(dsn string) (Store, error) {
+	if strings.HasPrefix(dsn, "redis://") || strings.HasPrefix(dsn, "rediss://") {
+		st, err := OpenRedis(dsn)
+		if err != nil {
+			return nil, err
+		}
+		return st, nil
+	}
+	st, err := OpenSQLite(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// DefaultPath returns the path of the database used by default.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user config directory: %w", err)
+	}
+	return filepath.Join(dir, "rarejobctl", "history.db"), nil
+}
@@ -0,0 +1,444 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testRedisEnv is the URL of Redis to run the tests of the Redis store against, e.g. redis://localhost:6379/15.
+// The keys of rarejobctl in the database are deleted by the tests, so don't give the database in use.
+const testRedisEnv = "RAREJOB_TEST_REDIS"
+
+// stores returns the constructors of the empty stores to run the same tests against.
+func stores(t *testing.T) map[string]func(t *testing.T) Store {
+	t.Helper()
+	return map[string]func(t *testing.T) Store{
+		"sqlite": func(t *testing.T) Store {
+			st, err := OpenSQLite(filepath.Join(t.TempDir(), "history.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { st.Close() })
+			return st
+		},
+		"redis": func(t *testing.T) Store {
+			return openTestRedis(t, "")
+		},
+	}
+}
+
+// openTestRedis connects to the Redis of testRedisEnv with the query appended to its URL, deleting the keys of rarejobctl.
+// It skips the test if testRedisEnv is not set.
+func openTestRedis(t *testing.T, query string) *Redis {
+	t.Helper()
+	url := os.Getenv(testRedisEnv)
+	if url == "" {
+		t.Skipf("%s is not set", testRedisEnv)
+	}
+	st, err := OpenRedis(url + query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { st.Close() })
+	ctx := context.Background()
+	keys, err := st.rdb.Keys(ctx, redisKeyPrefix+"*").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) > 0 {
+		if err := st.rdb.Del(ctx, keys...).Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return st
+}
+
+func TestStoreList(t *testing.T) {
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	records := []Record{
+		{Kind: KindAttempt, StartAt: base, ErrorCode: "no_tutors", CreatedAt: base},
+		{Kind: KindReservation, TutorName: "Tutor A", StartAt: base, CreatedAt: base.Add(time.Minute)},
+		{Kind: KindCancellation, TutorName: "Tutor A", StartAt: base, CreatedAt: base.Add(2 * time.Minute)},
+		// created in the same second, ordered by the id
+		{Kind: KindReservation, TutorName: "Tutor B", StartAt: base, CreatedAt: base.Add(2 * time.Minute)},
+	}
+	tests := []struct {
+		name string
+		q    Query
+		// want are the indexes of the records listed.
+		want []int
+	}{
+		{"all", Query{}, []int{3, 2, 1, 0}},
+		{"by kind", Query{Kind: KindReservation}, []int{3, 1}},
+		{"since", Query{Since: base.Add(time.Minute)}, []int{3, 2, 1}},
+		{"limit", Query{Limit: 2}, []int{3, 2}},
+		{"by kind with limit", Query{Kind: KindReservation, Limit: 1}, []int{3}},
+		{"none", Query{Kind: KindAttempt, Since: base.Add(time.Minute)}, nil},
+	}
+	for name, open := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			st := open(t)
+			ids := make([]int64, len(records))
+			for i, r := range records {
+				r := r
+				if err := st.Add(ctx, &r); err != nil {
+					t.Fatalf("Add() = %v", err)
+				}
+				if r.ID == 0 {
+					t.Fatalf("Add() didn't set the id")
+				}
+				ids[i] = r.ID
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					listed, err := st.List(ctx, tt.q)
+					if err != nil {
+						t.Fatalf("List() = %v", err)
+					}
+					var got, want []int64
+					for _, r := range listed {
+						got = append(got, r.ID)
+					}
+					for _, i := range tt.want {
+						want = append(want, ids[i])
+					}
+					if !reflect.DeepEqual(got, want) {
+						t.Errorf("List(%+v) = %v, want %v", tt.q, got, want)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestStoreFindReservation(t *testing.T) {
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	from := base.Add(24 * time.Hour)
+	reservation := func(name string, startAt, createdAt time.Time) Record {
+		return Record{Kind: KindReservation, TutorName: name, StartAt: startAt, EndAt: startAt.Add(25 * time.Minute), CreatedAt: createdAt}
+	}
+	cancellation := func(startAt, createdAt time.Time) Record {
+		return Record{Kind: KindCancellation, StartAt: startAt, CreatedAt: createdAt}
+	}
+	tests := []struct {
+		name    string
+		records []Record
+		// want is the tutor of the reservation found, empty if not found.
+		want string
+	}{
+		{"none", nil, ""},
+		{"in the window", []Record{reservation("Tutor A", from.Add(30*time.Minute), base)}, "Tutor A"},
+		{"at the start", []Record{reservation("Tutor A", from, base)}, "Tutor A"},
+		{"at the end", []Record{reservation("Tutor A", from.Add(time.Hour), base)}, ""},
+		{"before the window", []Record{reservation("Tutor A", from.Add(-time.Minute), base)}, ""},
+		{"dry run", []Record{{Kind: KindReservation, TutorName: "Tutor A", StartAt: from, DryRun: true, CreatedAt: base}}, ""},
+		{"attempt", []Record{{Kind: KindAttempt, StartAt: from, CreatedAt: base}}, ""},
+		{
+			"cancelled",
+			[]Record{reservation("Tutor A", from, base), cancellation(from, base.Add(time.Minute))},
+			"",
+		},
+		{
+			"cancelled in the same second",
+			[]Record{reservation("Tutor A", from, base), cancellation(from, base)},
+			"",
+		},
+		{
+			"reserved again after cancellation",
+			[]Record{reservation("Tutor A", from, base), cancellation(from, base.Add(time.Minute)), reservation("Tutor B", from, base.Add(2*time.Minute))},
+			"Tutor B",
+		},
+		{
+			"another slot cancelled",
+			[]Record{reservation("Tutor A", from, base), cancellation(from.Add(30*time.Minute), base.Add(time.Minute))},
+			"Tutor A",
+		},
+		{
+			"latest one",
+			[]Record{reservation("Tutor A", from, base), reservation("Tutor B", from.Add(30*time.Minute), base.Add(time.Minute))},
+			"Tutor B",
+		},
+	}
+	for name, open := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					ctx := context.Background()
+					st := open(t)
+					for _, r := range tt.records {
+						r := r
+						if err := st.Add(ctx, &r); err != nil {
+							t.Fatalf("Add() = %v", err)
+						}
+					}
+					r, err := st.FindReservation(ctx, from, from.Add(time.Hour))
+					if err != nil {
+						t.Fatalf("FindReservation() = %v", err)
+					}
+					got := ""
+					if r != nil {
+						got = r.TutorName
+					}
+					if got != tt.want {
+						t.Errorf("FindReservation() = %q, want %q", got, tt.want)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestStoreLessons(t *testing.T) {
+	base := time.Date(2022, 12, 27, 21, 0, 0, 0, time.Local)
+	for name, open := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			st := open(t)
+			latest, err := st.LatestLesson(ctx)
+			if err != nil || !latest.IsZero() {
+				t.Fatalf("LatestLesson() of empty store = %v, %v, want zero", latest, err)
+			}
+			if err := st.SaveLessons(ctx, []Lesson{
+				{ID: "1", TutorName: "Tutor A", StartAt: base, Status: "reserved"},
+				{ID: "2", TutorName: "Tutor B", StartAt: base.Add(24 * time.Hour), Completed: true},
+			}); err != nil {
+				t.Fatalf("SaveLessons() = %v", err)
+			}
+			// the status is updated
+			if err := st.SaveLessons(ctx, []Lesson{{ID: "1", TutorName: "Tutor A", StartAt: base, Status: "completed", Completed: true}}); err != nil {
+				t.Fatalf("SaveLessons() = %v", err)
+			}
+
+			tests := []struct {
+				name string
+				q    LessonQuery
+				want []string
+			}{
+				{"all", LessonQuery{}, []string{"2", "1"}},
+				{"by tutor", LessonQuery{TutorName: "Tutor A"}, []string{"1"}},
+				{"since", LessonQuery{Since: base.Add(time.Hour)}, []string{"2"}},
+				{"limit", LessonQuery{Limit: 1}, []string{"2"}},
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					lessons, err := st.ListLessons(ctx, tt.q)
+					if err != nil {
+						t.Fatalf("ListLessons() = %v", err)
+					}
+					var got []string
+					for _, l := range lessons {
+						got = append(got, l.ID)
+						if l.ID == "1" && (l.Status != "completed" || !l.Completed) {
+							t.Errorf("lesson 1 = %+v, want updated", l)
+						}
+					}
+					if !reflect.DeepEqual(got, tt.want) {
+						t.Errorf("ListLessons(%+v) = %v, want %v", tt.q, got, tt.want)
+					}
+				})
+			}
+
+			latest, err = st.LatestLesson(ctx)
+			if err != nil || !latest.Equal(base.Add(24*time.Hour)) {
+				t.Errorf("LatestLesson() = %v, %v, want %v", latest, err, base.Add(24*time.Hour))
+			}
+		})
+	}
+}
+
+func TestStoreSamples(t *testing.T) {
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for name, open := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			st := open(t)
+			if err := st.AddSamples(ctx, []Sample{
+				{SlotAt: base.Add(24 * time.Hour), OpenSlots: 1, SampledAt: base.Add(time.Minute)},
+				{SlotAt: base.Add(25 * time.Hour), OpenSlots: 2, SampledAt: base},
+				{SlotAt: base.Add(26 * time.Hour), OpenSlots: 3},
+			}); err != nil {
+				t.Fatalf("AddSamples() = %v", err)
+			}
+			tests := []struct {
+				name string
+				q    SampleQuery
+				want []int
+			}{
+				{"all", SampleQuery{}, []int{2, 1, 3}},
+				{"since", SampleQuery{Since: base.Add(time.Minute)}, []int{1, 3}},
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					samples, err := st.ListSamples(ctx, tt.q)
+					if err != nil {
+						t.Fatalf("ListSamples() = %v", err)
+					}
+					var got []int
+					for _, s := range samples {
+						got = append(got, s.OpenSlots)
+					}
+					if !reflect.DeepEqual(got, tt.want) {
+						t.Errorf("ListSamples(%+v) = %v, want %v", tt.q, got, tt.want)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestStoreLock(t *testing.T) {
+	type step struct {
+		// unlock releases the lock instead of acquiring it.
+		unlock bool
+		owner  string
+		ttl    time.Duration
+		// sleep is the wait before the step.
+		sleep time.Duration
+		want  bool
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"free", []step{{owner: "a", ttl: time.Minute, want: true}}},
+		{"held by another", []step{{owner: "a", ttl: time.Minute, want: true}, {owner: "b", ttl: time.Minute, want: false}}},
+		{"extended", []step{{owner: "a", ttl: time.Minute, want: true}, {owner: "a", ttl: time.Minute, want: true}}},
+		{
+			"released",
+			[]step{{owner: "a", ttl: time.Minute, want: true}, {unlock: true, owner: "a"}, {owner: "b", ttl: time.Minute, want: true}},
+		},
+		{
+			"not released by another",
+			[]step{{owner: "a", ttl: time.Minute, want: true}, {unlock: true, owner: "b"}, {owner: "b", ttl: time.Minute, want: false}},
+		},
+		{
+			"expired",
+			[]step{{owner: "a", ttl: 50 * time.Millisecond, want: true}, {owner: "b", ttl: time.Minute, sleep: 100 * time.Millisecond, want: true}},
+		},
+	}
+	for name, open := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					ctx := context.Background()
+					st := open(t)
+					for i, s := range tt.steps {
+						time.Sleep(s.sleep)
+						if s.unlock {
+							if err := st.Unlock(ctx, "reserve:2022-12-27", s.owner); err != nil {
+								t.Fatalf("step %d: Unlock() = %v", i, err)
+							}
+							continue
+						}
+						ok, err := st.TryLock(ctx, "reserve:2022-12-27", s.owner, s.ttl)
+						if err != nil {
+							t.Fatalf("step %d: TryLock() = %v", i, err)
+						}
+						if ok != s.want {
+							t.Errorf("step %d: TryLock(%s) = %v, want %v", i, s.owner, ok, s.want)
+						}
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestStoreSession(t *testing.T) {
+	for name, open := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			st := open(t)
+			load := func(account string) []byte {
+				t.Helper()
+				b, err := st.LoadSession(ctx, account)
+				if err != nil {
+					t.Fatalf("LoadSession(%s) = %v", account, err)
+				}
+				return b
+			}
+
+			if b := load("a@example.com"); b != nil {
+				t.Errorf("LoadSession() before saving = %q, want nil", b)
+			}
+			if err := st.SaveSession(ctx, "a@example.com", []byte("old"), time.Minute); err != nil {
+				t.Fatalf("SaveSession() = %v", err)
+			}
+			if err := st.SaveSession(ctx, "a@example.com", []byte("new"), time.Minute); err != nil {
+				t.Fatalf("SaveSession() = %v", err)
+			}
+			if b := load("a@example.com"); string(b) != "new" {
+				t.Errorf("LoadSession() = %q, want the latest one", b)
+			}
+			if b := load("b@example.com"); b != nil {
+				t.Errorf("LoadSession() of another account = %q, want nil", b)
+			}
+
+			if err := st.DeleteSession(ctx, "a@example.com"); err != nil {
+				t.Fatalf("DeleteSession() = %v", err)
+			}
+			if b := load("a@example.com"); b != nil {
+				t.Errorf("LoadSession() after deleting = %q, want nil", b)
+			}
+
+			if err := st.SaveSession(ctx, "a@example.com", []byte("expiring"), 50*time.Millisecond); err != nil {
+				t.Fatalf("SaveSession() = %v", err)
+			}
+			time.Sleep(100 * time.Millisecond)
+			if b := load("a@example.com"); b != nil {
+				t.Errorf("LoadSession() after expiry = %q, want nil", b)
+			}
+		})
+	}
+}
+
+func TestRedisRetention(t *testing.T) {
+	ctx := context.Background()
+	st := openTestRedis(t, "?retention=1h")
+	now := time.Now().Truncate(time.Second)
+	old := &Record{Kind: KindReservation, TutorName: "Tutor A", StartAt: now.Add(-2 * time.Hour), CreatedAt: now.Add(-2 * time.Hour)}
+	if err := st.Add(ctx, old); err != nil {
+		t.Fatalf("Add() = %v", err)
+	}
+	recent := &Record{Kind: KindReservation, TutorName: "Tutor B", StartAt: now, CreatedAt: now}
+	if err := st.Add(ctx, recent); err != nil {
+		t.Fatalf("Add() = %v", err)
+	}
+	records, err := st.List(ctx, Query{})
+	if err != nil {
+		t.Fatalf("List() = %v", err)
+	}
+	if len(records) != 1 || records[0].ID != recent.ID {
+		t.Errorf("List() = %v, want only the recent one", records)
+	}
+	if r, err := st.FindReservation(ctx, old.StartAt, old.StartAt.Add(time.Minute)); err != nil || r != nil {
+		t.Errorf("FindReservation() of the trimmed = %v, %v, want nil", r, err)
+	}
+	if n, err := st.rdb.HLen(ctx, redisRecordDataKey).Result(); err != nil || n != 1 {
+		t.Errorf("records kept = %d, %v, want 1", n, err)
+	}
+
+	if err := st.AddSamples(ctx, []Sample{{SlotAt: now, OpenSlots: 1, SampledAt: now.Add(-2 * time.Hour)}, {SlotAt: now, OpenSlots: 2}}); err != nil {
+		t.Fatalf("AddSamples() = %v", err)
+	}
+	samples, err := st.ListSamples(ctx, SampleQuery{})
+	if err != nil {
+		t.Fatalf("ListSamples() = %v", err)
+	}
+	if len(samples) != 1 || samples[0].OpenSlots != 2 {
+		t.Errorf("ListSamples() = %v, want only the recent one", samples)
+	}
+}
+
+func TestOpenRedisInvalidRetention(t *testing.T) {
+	for _, url := range []string{"redis://localhost:6379/0?retention=1y", "redis://localhost:6379/0?retention=-1h"} {
+		if _, err := OpenRedis(url); err == nil {
+			t.Errorf("OpenRedis(%q) succeeded, want error", url)
+		}
+	}
+}